@@ -32,7 +32,7 @@ func NewAddress(value string) (a *Address, err error) {
 
 // NewAddressWithName creates a new Address instance with the given name and value.
 func NewAddressWithName(name, value string) (a *Address, err error) {
-	if name == "" {
+	if name == "" || !validFieldValue(name) {
 		err = ErrorInvalidName
 		return
 	}
@@ -49,7 +49,7 @@ func NewAddressWithName(name, value string) (a *Address, err error) {
 }
 
 // Value returns the value of the Address.
-func (a *Address) Value() string {
+func (a Address) Value() string {
 	if a.name.IsSome() {
 		return fmt.Sprintf("%s <%s>", a.name.Unwrap(), a.value)
 	} else {
@@ -58,7 +58,7 @@ func (a *Address) Value() string {
 }
 
 // String returns the string representation of the Address.
-func (a *Address) String() (s string, err error) {
+func (a Address) String() (s string, err error) {
 	if a.name.IsSome() {
 		var name string
 		if encode {
@@ -81,6 +81,17 @@ func (a *Address) String() (s string, err error) {
 	return
 }
 
+// addrSpec returns the bare addr-spec of the Address, without any display name.
+func (a Address) addrSpec() (s string, err error) {
+	addr, err := mail.ParseAddress(a.value)
+	if err != nil {
+		err = ErrorInvalidAddress
+		return
+	}
+	s = addr.Address
+	return
+}
+
 // Addresses represents a slice of Address.
 type Addresses []Address
 
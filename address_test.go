@@ -49,6 +49,13 @@ func TestAddress(t *testing.T) {
 			expected:    "",
 			expectedErr: rfc5322.ErrorInvalidName,
 		},
+		{
+			name:        "valid email with CRLF in name",
+			inputAddr:   "example@example.com",
+			inputName:   optional.Some("Example\r\nBcc: evil@example.com"),
+			expected:    "",
+			expectedErr: rfc5322.ErrorInvalidName,
+		},
 		{
 			name:        "valid email with None name",
 			inputAddr:   "example@example.com",
@@ -0,0 +1,276 @@
+package rfc5322
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ARCResult is the result of the validation of an ARC chain, as per RFC 8617 section 4.4.
+type ARCResult string
+
+const (
+	// ARCNone means that the message has no ARC set.
+	ARCNone ARCResult = "none"
+	// ARCPass means that the ARC chain is valid.
+	ARCPass ARCResult = "pass"
+	// ARCFail means that the ARC chain is invalid.
+	ARCFail ARCResult = "fail"
+)
+
+// maxARCInstance is the highest instance of an ARC set, as per RFC 8617 section 4.2.1.
+const maxARCInstance = 50
+
+// defaultARCFields are the fields signed by the ARC-Message-Signature, when they are present.
+var defaultARCFields = []string{
+	"From", "Sender", "Reply-To", "To", "Cc", "Subject", "Date", "Message-ID", "In-Reply-To", "References",
+	"MIME-Version", "Content-Type", "Content-Transfer-Encoding", "DKIM-Signature",
+}
+
+// ARCSealer adds ARC sets to messages, as per RFC 8617 section 5.1.
+type ARCSealer struct {
+	domain    string
+	selector  string
+	key       crypto.Signer
+	algorithm string
+	fields    []string
+}
+
+// NewARCSealer creates a new ARCSealer signing for the domain with the key published at the selector.
+// RSA keys use rsa-sha256 and Ed25519 keys use ed25519-sha256.
+func NewARCSealer(domain, selector string, key crypto.Signer) (s *ARCSealer, err error) {
+	algorithm, err := signingAlgorithm(key)
+	if err != nil {
+		return
+	}
+	s = &ARCSealer{
+		domain:    domain,
+		selector:  selector,
+		key:       key,
+		algorithm: algorithm,
+		fields:    defaultARCFields,
+	}
+	return
+}
+
+// SetFields sets the names of the fields signed by the ARC-Message-Signature. Only the fields present in the message are signed.
+func (s *ARCSealer) SetFields(names ...string) *ARCSealer {
+	s.fields = names
+	return s
+}
+
+// Seal returns the message with a new ARC set on top of its header.
+// cv is the result of ValidateARC for the message as it was received, before any modification,
+// and authResults is the value of the Authentication-Results field computed by the sealer, starting with its authserv-id.
+func (s *ARCSealer) Seal(message []byte, cv ARCResult, authResults string) (sealed []byte, err error) {
+	set, err := s.seal(message, cv, authResults)
+	if err != nil {
+		return
+	}
+	var buf bytes.Buffer
+	for _, f := range slices.Backward(set) {
+		fmt.Fprintf(&buf, "%s: %s\r\n", f.name, f.value)
+	}
+	buf.Write(normalizeLineEndings(message))
+	sealed = buf.Bytes()
+	return
+}
+
+// SealEMail adds a new ARC set on top of the Header of the EMail, with the same arguments as Seal.
+// The set signs the EMail as it is rendered, so the EMail must not be modified afterwards.
+func (s *ARCSealer) SealEMail(e *EMail, cv ARCResult, authResults string) (err error) {
+	message, err := e.String()
+	if err != nil {
+		return
+	}
+	set, err := s.seal([]byte(message), cv, authResults)
+	if err != nil {
+		return
+	}
+	for _, f := range set {
+		e.header.PrependTrace(f.name, f.value)
+	}
+	return
+}
+
+// seal returns the ARC-Authentication-Results, ARC-Message-Signature and ARC-Seal fields of a new ARC set for the message.
+func (s *ARCSealer) seal(message []byte, cv ARCResult, authResults string) (set []field, err error) {
+	if !validFieldValue(authResults) {
+		err = ErrorInvalidFieldValue
+		return
+	}
+	fields, body := rawFields(message)
+	sets, err := arcSets(fields)
+	if err != nil {
+		return
+	}
+	// The first set has no chain to validate, as per RFC 8617 section 4.1.3.
+	if len(sets) >= maxARCInstance || (len(sets) == 0) != (cv == ARCNone) || (cv != ARCNone && cv != ARCPass && cv != ARCFail) {
+		err = ErrorInvalidARC
+		return
+	}
+	instance := len(sets) + 1
+	timestamp := time.Now().Unix()
+
+	aar := fmt.Sprintf("ARC-Authentication-Results: i=%d; %s\r\n", instance, strings.TrimSpace(authResults))
+
+	names := make([]string, 0, len(s.fields))
+	for _, name := range s.fields {
+		if slices.ContainsFunc(fields, func(f string) bool { return strings.EqualFold(rawFieldName(f), name) }) {
+			names = append(names, name)
+		}
+	}
+	ams := fmt.Sprintf("ARC-Message-Signature: i=%d; a=%s; c=relaxed/relaxed; d=%s; s=%s; t=%d; h=%s; bh=%s; b=",
+		instance, s.algorithm, s.domain, s.selector, timestamp, strings.Join(names, ":"), bodyHash(body, canonicalizationRelaxed))
+	b, err := signHash(s.key, headerHash(selectFields(fields, names), ams, canonicalizationRelaxed))
+	if err != nil {
+		return
+	}
+	ams += b + "\r\n"
+
+	as := fmt.Sprintf("ARC-Seal: i=%d; a=%s; t=%d; cv=%s; d=%s; s=%s; b=", instance, s.algorithm, timestamp, cv, s.domain, s.selector)
+	b, err = signHash(s.key, sealHash(append(sets, arcSet{aar: aar, ams: ams, as: as})))
+	if err != nil {
+		return
+	}
+	as += b + "\r\n"
+
+	for _, f := range []string{aar, ams, as} {
+		set = append(set, field{name: rawFieldName(f), value: rawFieldValue(f)})
+	}
+	return
+}
+
+// ValidateARC validates the ARC chain of the message, as per RFC 8617 section 5.2.
+// The ARC-Message-Signature of the latest set and all the ARC-Seal fields are verified with the public keys retrieved by lookup,
+// or from the DNS if lookup is nil. When the result is ARCFail, the error describes the failure.
+func ValidateARC(ctx context.Context, message []byte, lookup TXTLookup) (result ARCResult, err error) {
+	fields, body := rawFields(message)
+	sets, err := arcSets(fields)
+	if err != nil {
+		return ARCFail, err
+	}
+	if len(sets) == 0 {
+		return ARCNone, nil
+	}
+	seals := make([]map[string]string, len(sets))
+	for i, set := range sets {
+		if seals[i], err = parseTagList(rawFieldValue(set.as)); err != nil {
+			return ARCFail, err
+		}
+		// The first set has no chain to validate, and every later set must have validated the chain.
+		cv := ARCResult(seals[i]["cv"])
+		if (i == 0 && cv != ARCNone) || (i > 0 && cv != ARCPass) {
+			// A failed chain stays failed, as per RFC 8617 section 5.2 step 2.
+			return ARCFail, ErrorInvalidARC
+		}
+	}
+
+	latest := sets[len(sets)-1]
+	if err = verifyARCMessageSignature(ctx, fields, body, latest.ams, lookup); err != nil {
+		return ARCFail, err
+	}
+	for i := len(sets) - 1; i >= 0; i-- {
+		tags := seals[i]
+		var pub crypto.PublicKey
+		if pub, err = lookupPublicKey(ctx, lookup, tags["s"], tags["d"]); err != nil {
+			return ARCFail, err
+		}
+		if err = verifyHash(pub, tags["a"], sealHash(sets[:i+1]), tags["b"]); err != nil {
+			return ARCFail, err
+		}
+	}
+	return ARCPass, nil
+}
+
+// arcSet is an ARC set of raw header fields.
+type arcSet struct {
+	aar string
+	ams string
+	as  string
+}
+
+// arcSets returns the ARC sets of the fields ordered by instance, as per RFC 8617 section 5.2 steps 1 and 2.
+func arcSets(fields []string) (sets []arcSet, err error) {
+	byInstance := make(map[int]*arcSet)
+	for _, f := range fields {
+		name := strings.ToLower(rawFieldName(f))
+		if name != "arc-authentication-results" && name != "arc-message-signature" && name != "arc-seal" {
+			continue
+		}
+		i, _, _ := strings.Cut(rawFieldValue(f), ";")
+		tag, value, _ := strings.Cut(i, "=")
+		instance, e := strconv.Atoi(strings.TrimSpace(value))
+		if strings.TrimSpace(tag) != "i" || e != nil || instance < 1 || instance > maxARCInstance {
+			err = ErrorInvalidARC
+			return
+		}
+		set, ok := byInstance[instance]
+		if !ok {
+			set = &arcSet{}
+			byInstance[instance] = set
+		}
+		var slot *string
+		switch name {
+		case "arc-authentication-results":
+			slot = &set.aar
+		case "arc-message-signature":
+			slot = &set.ams
+		default:
+			slot = &set.as
+		}
+		if *slot != "" {
+			err = ErrorInvalidARC
+			return
+		}
+		*slot = f
+	}
+	for i := 1; i <= len(byInstance); i++ {
+		set, ok := byInstance[i]
+		if !ok || set.aar == "" || set.ams == "" || set.as == "" {
+			err = ErrorInvalidARC
+			return
+		}
+		sets = append(sets, *set)
+	}
+	return
+}
+
+// verifyARCMessageSignature verifies an ARC-Message-Signature field, as per RFC 8617 section 5.2 step 5.
+func verifyARCMessageSignature(ctx context.Context, fields []string, body []byte, ams string, lookup TXTLookup) (err error) {
+	tags, err := parseTagList(rawFieldValue(ams))
+	if err != nil {
+		return
+	}
+	headerCanon, bodyCanon, err := parseCanonicalization(tags["c"])
+	if err != nil {
+		return
+	}
+	names := strings.Split(tags["h"], ":")
+	if slices.ContainsFunc(names, func(name string) bool { return strings.EqualFold(strings.TrimSpace(name), "ARC-Seal") }) {
+		return ErrorInvalidARC
+	}
+	if bodyHash(body, bodyCanon) != removeWSP(tags["bh"]) {
+		return ErrorInvalidSignature
+	}
+	pub, err := lookupPublicKey(ctx, lookup, tags["s"], tags["d"])
+	if err != nil {
+		return
+	}
+	return verifyHash(pub, tags["a"], headerHash(selectFields(fields, names), ams, headerCanon), tags["b"])
+}
+
+// sealHash returns the hash signed by the ARC-Seal of the last set, as per RFC 8617 section 5.1.1.
+// The fields of the sets are hashed in order of instance, and the ARC-Seal of the last set is hashed without its signature.
+func sealHash(sets []arcSet) []byte {
+	fields := make([]string, 0, len(sets)*3)
+	for _, set := range sets {
+		fields = append(fields, set.aar, set.ams, set.as)
+	}
+	return headerHash(fields[:len(fields)-1], fields[len(fields)-1], canonicalizationRelaxed)
+}
@@ -0,0 +1,156 @@
+package rfc5322_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aethiopicuschan/rfc5322-go"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestLookup returns a TXTLookup serving the DKIM key records of the keys by domain name.
+func newTestLookup(t *testing.T, keys map[string]crypto.Signer) rfc5322.TXTLookup {
+	t.Helper()
+	records := make(map[string]string)
+	for name, key := range keys {
+		switch pub := key.Public().(type) {
+		case ed25519.PublicKey:
+			records[name] = "v=DKIM1; k=ed25519; p=" + base64.StdEncoding.EncodeToString(pub)
+		default:
+			der, err := x509.MarshalPKIXPublicKey(pub)
+			if err != nil {
+				t.Fatal(err)
+			}
+			records[name] = "v=DKIM1; k=rsa; p=" + base64.StdEncoding.EncodeToString(der)
+		}
+	}
+	return func(ctx context.Context, name string) ([]string, error) {
+		if record, ok := records[name]; ok {
+			return []string{record}, nil
+		}
+		return nil, errors.New("no such host")
+	}
+}
+
+const testARCMessage = "From: Alice <alice@example.com>\r\n" +
+	"To: list@lists.example.org\r\n" +
+	"Subject: Meeting\r\n" +
+	"Date: Sun, 01 Oct 2023 12:00:00 +0000\r\n" +
+	"Message-ID: <1@example.com>\r\n" +
+	"\r\n" +
+	"See you  at noon.\r\n"
+
+func TestARC(t *testing.T) {
+	ctx := context.Background()
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	lookup := newTestLookup(t, map[string]crypto.Signer{
+		"arc._domainkey.mx.example.net":    rsaKey,
+		"arc._domainkey.lists.example.org": edKey,
+	})
+
+	result, err := rfc5322.ValidateARC(ctx, []byte(testARCMessage), lookup)
+	assert.NoError(t, err)
+	assert.Equal(t, rfc5322.ARCNone, result)
+
+	// The first hop seals the message as received.
+	mx, err := rfc5322.NewARCSealer("mx.example.net", "arc", rsaKey)
+	assert.NoError(t, err)
+	sealed, err := mx.Seal([]byte(testARCMessage), rfc5322.ARCNone, "mx.example.net; spf=pass smtp.mailfrom=example.com")
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(sealed), "ARC-Seal: i=1; a=rsa-sha256; t="))
+	assert.Contains(t, string(sealed), "ARC-Authentication-Results: i=1; mx.example.net; spf=pass smtp.mailfrom=example.com\r\n")
+	assert.Contains(t, string(sealed), "cv=none")
+	result, err = rfc5322.ValidateARC(ctx, sealed, lookup)
+	assert.NoError(t, err)
+	assert.Equal(t, rfc5322.ARCPass, result)
+
+	// Refolding the header and changing whitespace in the body is tolerated by the relaxed canonicalization.
+	refolded := strings.Replace(string(sealed), "Subject: Meeting", "Subject:\r\n  Meeting ", 1)
+	refolded = strings.Replace(refolded, "See you  at noon.\r\n", "See you at noon.  \r\n\r\n", 1)
+	result, err = rfc5322.ValidateARC(ctx, []byte(refolded), lookup)
+	assert.NoError(t, err)
+	assert.Equal(t, rfc5322.ARCPass, result)
+
+	// The mailing list validates the chain on receipt, modifies the message and adds its own set.
+	cv, err := rfc5322.ValidateARC(ctx, sealed, lookup)
+	assert.NoError(t, err)
+	email, err := rfc5322.Parse(strings.NewReader(string(sealed)))
+	assert.NoError(t, err)
+	email.Header().SetSubject("[list] Meeting")
+	list, err := rfc5322.NewARCSealer("lists.example.org", "arc", edKey)
+	assert.NoError(t, err)
+	err = list.SealEMail(email, cv, "lists.example.org; arc=pass")
+	assert.NoError(t, err)
+	s, err := email.String()
+	assert.NoError(t, err)
+	// The new set is on top of the header, above the set of the previous hop.
+	assert.True(t, strings.HasPrefix(s, "ARC-Seal: i=2; a=ed25519-sha256;"))
+	assert.Less(t, strings.Index(s, "ARC-Authentication-Results: i=2;"), strings.Index(s, "ARC-Seal: i=1;"))
+	assert.Contains(t, s, "cv=pass")
+	result, err = rfc5322.ValidateARC(ctx, []byte(s), lookup)
+	assert.NoError(t, err)
+	assert.Equal(t, rfc5322.ARCPass, result)
+
+	// The rendered message can be parsed and sealed again by the next hop.
+	forwarded, err := rfc5322.Parse(strings.NewReader(s))
+	assert.NoError(t, err)
+	err = mx.SealEMail(forwarded, result, "mx.example.net; arc=pass")
+	assert.NoError(t, err)
+	s3, err := forwarded.String()
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(s3, "ARC-Seal: i=3; a=rsa-sha256;"))
+	result, err = rfc5322.ValidateARC(ctx, []byte(s3), lookup)
+	assert.NoError(t, err)
+	assert.Equal(t, rfc5322.ARCPass, result)
+
+	// A modification after the latest set breaks its ARC-Message-Signature.
+	result, err = rfc5322.ValidateARC(ctx, []byte(strings.Replace(s, "at noon", "at midnight", 1)), lookup)
+	assert.ErrorIs(t, err, rfc5322.ErrorInvalidSignature)
+	assert.Equal(t, rfc5322.ARCFail, result)
+
+	// A modification of an earlier set breaks the seals.
+	result, err = rfc5322.ValidateARC(ctx, []byte(strings.Replace(s, "spf=pass", "spf=fail", 1)), lookup)
+	assert.ErrorIs(t, err, rfc5322.ErrorInvalidSignature)
+	assert.Equal(t, rfc5322.ARCFail, result)
+
+	// The chain validation status must match the existing sets.
+	_, err = list.Seal([]byte(testARCMessage), rfc5322.ARCPass, "lists.example.org; arc=none")
+	assert.ErrorIs(t, err, rfc5322.ErrorInvalidARC)
+	_, err = list.Seal(sealed, rfc5322.ARCNone, "lists.example.org; arc=pass")
+	assert.ErrorIs(t, err, rfc5322.ErrorInvalidARC)
+
+	// An unknown key cannot be verified.
+	result, err = rfc5322.ValidateARC(ctx, sealed, newTestLookup(t, nil))
+	assert.Error(t, err)
+	assert.Equal(t, rfc5322.ARCFail, result)
+}
+
+func TestARCInvalidChain(t *testing.T) {
+	testCases := []struct {
+		name    string
+		message string
+	}{
+		{"MissingSeal", "ARC-Authentication-Results: i=1; mx.example.net; none\r\nARC-Message-Signature: i=1; a=rsa-sha256; b=\r\n" + testARCMessage},
+		{"Duplicate", "ARC-Seal: i=1; b=\r\nARC-Seal: i=1; b=\r\n" + testARCMessage},
+		{"Gap", "ARC-Authentication-Results: i=2; mx.example.net; none\r\nARC-Message-Signature: i=2; b=\r\nARC-Seal: i=2; b=\r\n" + testARCMessage},
+		{"NoInstance", "ARC-Seal: a=rsa-sha256; i=1; b=\r\n" + testARCMessage},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			result, err := rfc5322.ValidateARC(context.Background(), []byte(tc.message), nil)
+			assert.ErrorIs(t, err, rfc5322.ErrorInvalidARC)
+			assert.Equal(t, rfc5322.ARCFail, result)
+		})
+	}
+}
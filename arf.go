@@ -0,0 +1,194 @@
+package rfc5322
+
+import (
+	"bytes"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FeedbackType is the type of a feedback report, as per RFC 5965 section 7.3 and RFC 6591.
+type FeedbackType string
+
+const (
+	FeedbackAbuse       FeedbackType = "abuse"
+	FeedbackFraud       FeedbackType = "fraud"
+	FeedbackVirus       FeedbackType = "virus"
+	FeedbackNotSpam     FeedbackType = "not-spam"
+	FeedbackAuthFailure FeedbackType = "auth-failure"
+	FeedbackOther       FeedbackType = "other"
+)
+
+// FeedbackReport is the content of a message/feedback-report part, as per RFC 5965 section 3.
+type FeedbackReport struct {
+	FeedbackType FeedbackType
+	// UserAgent is the name and version of the software that generated the report.
+	UserAgent string
+	// Version is the version of the format, which is 1 if it is 0.
+	Version            int
+	OriginalEnvelopeID string
+	// OriginalMailFrom is the envelope sender of the original message.
+	OriginalMailFrom string
+	// ArrivalDate is the time the original message arrived, or the zero time if it is not given.
+	ArrivalDate time.Time
+	// ReportingMTA is the DNS name of the MTA that received the original message.
+	ReportingMTA string
+	// SourceIP is the IP address from which the original message was received, or the zero Addr if it is not given.
+	SourceIP netip.Addr
+	// Incidents is the number of incidents represented by the report, or 0 if it is not given.
+	Incidents             int
+	AuthenticationResults []AuthenticationResults
+	// OriginalRcptTo are the envelope recipients of the original message.
+	OriginalRcptTo []string
+	ReportedDomain []string
+	ReportedURI    []string
+}
+
+// ARF is an abuse or feedback report in the Abuse Reporting Format, as per RFC 5965.
+type ARF struct {
+	// Text is the human readable explanation of the report.
+	Text   string
+	Report FeedbackReport
+	// Original is the reported message, which is required when generating a report.
+	Original *EMail
+	// HeadersOnly includes only the header of the reported message, such as for privacy reasons.
+	HeadersOnly bool
+}
+
+// Body returns the multipart/report Body of the report, with a message/feedback-report part and the reported message.
+func (a ARF) Body() (b *Body, err error) {
+	r := a.Report
+	if r.FeedbackType == "" || r.UserAgent == "" || a.Original == nil {
+		err = ErrorInvalidARF
+		return
+	}
+	values := []string{string(r.FeedbackType), r.UserAgent, r.OriginalEnvelopeID, r.OriginalMailFrom, r.ReportingMTA}
+	values = append(values, r.OriginalRcptTo...)
+	values = append(values, r.ReportedDomain...)
+	values = append(values, r.ReportedURI...)
+	for _, v := range values {
+		if strings.ContainsAny(v, "\r\n\x00") {
+			err = ErrorInvalidFieldValue
+			return
+		}
+	}
+	version := r.Version
+	if version == 0 {
+		version = 1
+	}
+
+	var buf bytes.Buffer
+	writeField(&buf, "Feedback-Type", string(r.FeedbackType))
+	writeField(&buf, "User-Agent", r.UserAgent)
+	writeField(&buf, "Version", strconv.Itoa(version))
+	writeField(&buf, "Original-Envelope-Id", r.OriginalEnvelopeID)
+	if r.OriginalMailFrom != "" {
+		writeField(&buf, "Original-Mail-From", "<"+r.OriginalMailFrom+">")
+	}
+	writeDate(&buf, "Arrival-Date", r.ArrivalDate)
+	if r.ReportingMTA != "" {
+		writeField(&buf, "Reporting-MTA", "dns; "+r.ReportingMTA)
+	}
+	if r.SourceIP.IsValid() {
+		writeField(&buf, "Source-IP", r.SourceIP.String())
+	}
+	if r.Incidents > 0 {
+		writeField(&buf, "Incidents", strconv.Itoa(r.Incidents))
+	}
+	for _, results := range r.AuthenticationResults {
+		writeField(&buf, "Authentication-Results", results.String())
+	}
+	for _, rcpt := range r.OriginalRcptTo {
+		writeField(&buf, "Original-Rcpt-To", "<"+rcpt+">")
+	}
+	for _, domain := range r.ReportedDomain {
+		writeField(&buf, "Reported-Domain", domain)
+	}
+	for _, uri := range r.ReportedURI {
+		writeField(&buf, "Reported-URI", uri)
+	}
+
+	report := NewBody()
+	report.setHeader("Content-Type", "message/feedback-report")
+	report.content = buf.Bytes()
+	return newReport("feedback-report", a.Text, report, a.Original, a.HeadersOnly)
+}
+
+// EMail returns the report as an EMail with the Header.
+func (a ARF) EMail(header *Header) (e *EMail, err error) {
+	b, err := a.Body()
+	if err != nil {
+		return
+	}
+	e = NewEMail(header, b)
+	return
+}
+
+// ParseARF parses the feedback report of the EMail.
+// The multipart/report Body with report-type=feedback-report is looked up in the whole tree of the Body.
+// Invalid optional fields are ignored.
+func ParseARF(e *EMail) (a *ARF, err error) {
+	report, ok := e.body.findReport("feedback-report")
+	if !ok {
+		err = ErrorNotReport
+		return
+	}
+	text, machine, original, headersOnly, err := report.reportParts("message/feedback-report")
+	if err != nil {
+		return
+	}
+	content, err := machine.decodedContent()
+	if err != nil {
+		return
+	}
+	blocks := parseFieldBlocks(content)
+	if len(blocks) == 0 {
+		err = ErrorInvalidARF
+		return
+	}
+	fields := blocks[0]
+
+	r := FeedbackReport{
+		FeedbackType:       FeedbackType(strings.ToLower(fieldValue(fields, "Feedback-Type"))),
+		UserAgent:          fieldValue(fields, "User-Agent"),
+		OriginalEnvelopeID: fieldValue(fields, "Original-Envelope-Id"),
+		OriginalMailFrom:   strings.Trim(fieldValue(fields, "Original-Mail-From"), "<>"),
+		ArrivalDate:        dateValue(fields, "Arrival-Date"),
+		ReportingMTA:       typedValue(fields, "Reporting-MTA"),
+	}
+	if r.FeedbackType == "" {
+		err = ErrorInvalidARF
+		return
+	}
+	if r.ArrivalDate.IsZero() {
+		// Received-Date is the legacy name of Arrival-Date.
+		r.ArrivalDate = dateValue(fields, "Received-Date")
+	}
+	r.Version, _ = strconv.Atoi(fieldValue(fields, "Version"))
+	r.SourceIP, _ = netip.ParseAddr(fieldValue(fields, "Source-IP"))
+	r.Incidents, _ = strconv.Atoi(fieldValue(fields, "Incidents"))
+	for _, f := range fields {
+		value := strings.TrimSpace(f.value)
+		switch strings.ToLower(f.name) {
+		case "authentication-results":
+			if results, e := ParseAuthenticationResults(value); e == nil {
+				r.AuthenticationResults = append(r.AuthenticationResults, results)
+			}
+		case "original-rcpt-to":
+			r.OriginalRcptTo = append(r.OriginalRcptTo, strings.Trim(value, "<>"))
+		case "reported-domain":
+			r.ReportedDomain = append(r.ReportedDomain, value)
+		case "reported-uri":
+			r.ReportedURI = append(r.ReportedURI, value)
+		}
+	}
+
+	a = &ARF{
+		Text:        text,
+		Report:      r,
+		Original:    original,
+		HeadersOnly: headersOnly && original != nil,
+	}
+	return
+}
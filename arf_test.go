@@ -0,0 +1,151 @@
+package rfc5322_test
+
+import (
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aethiopicuschan/rfc5322-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestARF(t *testing.T) {
+	results, err := rfc5322.ParseAuthenticationResults("mx.example.com; spf=fail smtp.mailfrom=example.com")
+	assert.NoError(t, err)
+	arf := rfc5322.ARF{
+		Text: "This is an email abuse report for an email message received from IP 192.0.2.1.",
+		Report: rfc5322.FeedbackReport{
+			FeedbackType:          rfc5322.FeedbackAbuse,
+			UserAgent:             "ExampleFBL/1.0",
+			OriginalMailFrom:      "alice@example.com",
+			ArrivalDate:           time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC),
+			ReportingMTA:          "mx.example.net",
+			SourceIP:              netip.MustParseAddr("192.0.2.1"),
+			Incidents:             3,
+			AuthenticationResults: []rfc5322.AuthenticationResults{results},
+			OriginalRcptTo:        []string{"bob@example.net"},
+			ReportedDomain:        []string{"example.com"},
+		},
+		Original: newTestOriginal(t),
+	}
+	from, _ := rfc5322.NewAddress("fbl@example.net")
+	header := rfc5322.NewHeader(*rfc5322.NewDate(time.Date(2023, 10, 1, 13, 0, 0, 0, time.UTC)), rfc5322.NewAddresses(*from)).
+		AddTo(mustAddress(t, "abuse@example.com")).
+		SetSubject("FW: Lunch")
+	email, err := arf.EMail(header)
+	assert.NoError(t, err)
+	s, err := email.String()
+	assert.NoError(t, err)
+	assert.Contains(t, s, "report-type=feedback-report")
+	assert.Contains(t, s, "Content-Type: message/feedback-report\r\n\r\n"+
+		"Feedback-Type: abuse\r\n"+
+		"User-Agent: ExampleFBL/1.0\r\n"+
+		"Version: 1\r\n"+
+		"Original-Mail-From: <alice@example.com>\r\n"+
+		"Arrival-Date: Sun, 01 Oct 2023 12:00:00 +0000\r\n"+
+		"Reporting-MTA: dns; mx.example.net\r\n"+
+		"Source-IP: 192.0.2.1\r\n"+
+		"Incidents: 3\r\n"+
+		"Authentication-Results: mx.example.com;\r\n\tspf=fail smtp.mailfrom=example.com\r\n"+
+		"Original-Rcpt-To: <bob@example.net>\r\n"+
+		"Reported-Domain: example.com\r\n")
+	assert.Contains(t, s, "Content-Type: message/rfc822\r\n")
+	assert.Contains(t, s, "Are you free on Friday?")
+
+	parsed, err := rfc5322.Parse(strings.NewReader(s))
+	assert.NoError(t, err)
+	got, err := rfc5322.ParseARF(parsed)
+	assert.NoError(t, err)
+	assert.Equal(t, arf.Text, got.Text)
+	assert.Equal(t, 1, got.Report.Version)
+	got.Report.Version = 0
+	assert.True(t, arf.Report.ArrivalDate.Equal(got.Report.ArrivalDate))
+	got.Report.ArrivalDate = arf.Report.ArrivalDate
+	assert.Equal(t, arf.Report, got.Report)
+	assert.False(t, got.HeadersOnly)
+	assert.Equal(t, "Lunch", got.Original.Header().Get("Subject"))
+
+	_, err = rfc5322.ARF{Report: arf.Report}.Body()
+	assert.ErrorIs(t, err, rfc5322.ErrorInvalidARF)
+	_, err = rfc5322.ARF{Report: rfc5322.FeedbackReport{FeedbackType: rfc5322.FeedbackAbuse}, Original: arf.Original}.Body()
+	assert.ErrorIs(t, err, rfc5322.ErrorInvalidARF)
+	_, err = rfc5322.ParseARF(arf.Original)
+	assert.ErrorIs(t, err, rfc5322.ErrorNotReport)
+}
+
+func TestParseARF(t *testing.T) {
+	// The example of RFC 5965 appendix B.2, with LF line endings.
+	message := `From: <abusedesk@example.com>
+Date: Thu, 8 Mar 2005 17:40:36 EDT
+Subject: FW: Earn money
+To: <abuse@example.net>
+MIME-Version: 1.0
+Content-Type: multipart/report; report-type=feedback-report;
+     boundary="part1_13d.2e68ed54_boundary"
+
+--part1_13d.2e68ed54_boundary
+Content-Type: text/plain; charset="US-ASCII"
+Content-Transfer-Encoding: 7bit
+
+This is an email abuse report for an email message received from IP
+192.0.2.1 on Thu, 8 Mar 2005 14:00:00 EDT.  For more information
+about this format please see http://www.mipassoc.org/arf/.
+
+--part1_13d.2e68ed54_boundary
+Content-Type: message/feedback-report
+
+Feedback-Type: abuse
+User-Agent: SomeGenerator/1.0
+Version: 1
+Original-Mail-From: <somespammer@example.net>
+Original-Rcpt-To: <user@example.com>
+Received-Date: Thu, 8 Mar 2005 14:00:00 EDT
+Source-IP: 192.0.2.1
+Authentication-Results: mail.example.com;
+               spf=fail smtp.mailfrom=somespammer@example.com
+Reported-Domain: example.net
+Reported-Uri: http://example.net/earn_money.html
+Reported-Uri: mailto:user@example.com
+Removal-Recipient: user@example.com
+
+--part1_13d.2e68ed54_boundary
+Content-Type: message/rfc822
+Content-Disposition: inline
+
+From: <somespammer@example.net>
+Received: from mailserver.example.net (mailserver.example.net
+        [192.0.2.1]) by example.com with ESMTP id M63d4137594e46;
+        Thu, 08 Mar 2005 14:00:00 -0400
+To: <Undisclosed Recipients>
+Subject: Earn money
+MIME-Version: 1.0
+Content-type: text/plain
+Message-ID: 8787KJKJ3K4J3K4J3K4J3.mail@example.net
+Date: Thu, 02 Sep 2004 12:34:56 -0400
+
+Spam Spam Spam
+Spam Spam Spam
+Spam Spam Spam
+Spam Spam Spam
+--part1_13d.2e68ed54_boundary--
+`
+	parsed, err := rfc5322.Parse(strings.NewReader(message))
+	assert.NoError(t, err)
+	arf, err := rfc5322.ParseARF(parsed)
+	assert.NoError(t, err)
+	r := arf.Report
+	assert.Equal(t, rfc5322.FeedbackAbuse, r.FeedbackType)
+	assert.Equal(t, "SomeGenerator/1.0", r.UserAgent)
+	assert.Equal(t, "somespammer@example.net", r.OriginalMailFrom)
+	assert.Equal(t, []string{"user@example.com"}, r.OriginalRcptTo)
+	assert.False(t, r.ArrivalDate.IsZero())
+	assert.Equal(t, netip.MustParseAddr("192.0.2.1"), r.SourceIP)
+	if assert.Len(t, r.AuthenticationResults, 1) {
+		spf, ok := r.AuthenticationResults[0].Result(rfc5322.AuthMethodSPF)
+		assert.True(t, ok)
+		assert.Equal(t, rfc5322.AuthResultFail, spf.Result)
+	}
+	assert.Equal(t, []string{"http://example.net/earn_money.html", "mailto:user@example.com"}, r.ReportedURI)
+	assert.Contains(t, arf.Text, "This is an email abuse report")
+}
@@ -0,0 +1,298 @@
+package rfc5322
+
+import (
+	"strconv"
+	"strings"
+)
+
+// AuthResultValue is the result of an authentication method, as per RFC 8601 section 2.7.
+type AuthResultValue string
+
+const (
+	AuthResultNone      AuthResultValue = "none"
+	AuthResultPass      AuthResultValue = "pass"
+	AuthResultFail      AuthResultValue = "fail"
+	AuthResultSoftFail  AuthResultValue = "softfail"
+	AuthResultNeutral   AuthResultValue = "neutral"
+	AuthResultPolicy    AuthResultValue = "policy"
+	AuthResultTempError AuthResultValue = "temperror"
+	AuthResultPermError AuthResultValue = "permerror"
+)
+
+// Authentication methods registered by IANA, as used in the method of an AuthResult.
+const (
+	AuthMethodSPF   = "spf"
+	AuthMethodDKIM  = "dkim"
+	AuthMethodDMARC = "dmarc"
+	AuthMethodARC   = "arc"
+	AuthMethodAuth  = "auth"
+	AuthMethodIPRev = "iprev"
+)
+
+// AuthProperty is a property of an AuthResult, such as smtp.mailfrom=example.com.
+type AuthProperty struct {
+	// Type is the type of the property, such as "smtp", "header", "body" or "policy".
+	Type string
+	// Name is the name of the property, such as "mailfrom" or "d".
+	Name  string
+	Value string
+}
+
+// AuthResult is the result of a single authentication method.
+type AuthResult struct {
+	// Method is the authentication method, such as "spf" or "dkim".
+	Method string
+	// Version is the version of the method, or 0 if it is not given.
+	Version    int
+	Result     AuthResultValue
+	Reason     string
+	Properties []AuthProperty
+}
+
+// Property returns the value of the property with the type and the name, or an empty string if it is not set.
+func (r AuthResult) Property(ptype, name string) string {
+	for _, p := range r.Properties {
+		if strings.EqualFold(p.Type, ptype) && strings.EqualFold(p.Name, name) {
+			return p.Value
+		}
+	}
+	return ""
+}
+
+// AuthenticationResults represents the Authentication-Results field, as per RFC 8601.
+type AuthenticationResults struct {
+	// AuthServID identifies the authentication service that produced the results.
+	AuthServID string
+	// Version is the version of the field, or 0 if it is not given.
+	Version int
+	Results []AuthResult
+}
+
+// NewAuthenticationResults creates a new AuthenticationResults instance for the authentication service.
+func NewAuthenticationResults(authServID string) *AuthenticationResults {
+	return &AuthenticationResults{
+		AuthServID: authServID,
+		Results:    make([]AuthResult, 0),
+	}
+}
+
+// Add adds a result.
+func (a *AuthenticationResults) Add(result AuthResult) *AuthenticationResults {
+	a.Results = append(a.Results, result)
+	return a
+}
+
+// Result returns the first result of the method.
+func (a AuthenticationResults) Result(method string) (result AuthResult, ok bool) {
+	for _, r := range a.Results {
+		if strings.EqualFold(r.Method, method) {
+			return r, true
+		}
+	}
+	return
+}
+
+// String returns the value of the Authentication-Results field, with each result on its own folded line.
+func (a AuthenticationResults) String() string {
+	var sb strings.Builder
+	sb.WriteString(authValue(a.AuthServID))
+	if a.Version > 0 {
+		sb.WriteString(" " + strconv.Itoa(a.Version))
+	}
+	if len(a.Results) == 0 {
+		sb.WriteString("; none")
+		return sb.String()
+	}
+	for _, r := range a.Results {
+		sb.WriteString(";\r\n\t" + r.Method)
+		if r.Version > 0 {
+			sb.WriteString("/" + strconv.Itoa(r.Version))
+		}
+		sb.WriteString("=" + string(r.Result))
+		if r.Reason != "" {
+			sb.WriteString(" reason=" + quoteString(r.Reason))
+		}
+		for _, p := range r.Properties {
+			sb.WriteString(" " + p.Type + "." + p.Name + "=" + authValue(p.Value))
+		}
+	}
+	return sb.String()
+}
+
+// authValue returns the value as is if it is a token, or as a quoted-string otherwise.
+// Addresses such as user@example.com are kept as is, as allowed for property values by RFC 8601 section 2.2.
+func authValue(v string) string {
+	if v == "" || strings.ContainsAny(v, " \t\r\n()<>,;:\\\"/[]?=") {
+		return quoteString(v)
+	}
+	return v
+}
+
+// quoteString returns the value as a quoted-string, as per RFC 5322 section 3.2.4.
+func quoteString(v string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\r", "", "\n", "").Replace(v) + `"`
+}
+
+// ParseAuthenticationResults parses the value of an Authentication-Results field.
+// Comments are ignored, and the names of the methods, results and properties are lowercased.
+func ParseAuthenticationResults(s string) (a AuthenticationResults, err error) {
+	p := &authParser{s: s}
+	p.cfws()
+	if a.AuthServID, err = p.value(); err != nil || a.AuthServID == "" {
+		err = ErrorInvalidAuthenticationResults
+		return
+	}
+	p.cfws()
+	if version := p.keyword(); version != "" {
+		if a.Version, err = strconv.Atoi(version); err != nil {
+			err = ErrorInvalidAuthenticationResults
+			return
+		}
+		p.cfws()
+	}
+	a.Results = make([]AuthResult, 0)
+	for !p.eof() {
+		if !p.consume(';') {
+			err = ErrorInvalidAuthenticationResults
+			return
+		}
+		p.cfws()
+		method := strings.ToLower(p.keyword())
+		p.cfws()
+		if method == "none" && len(a.Results) == 0 && p.eof() {
+			return
+		}
+		r := AuthResult{Method: method}
+		if p.consume('/') {
+			p.cfws()
+			if r.Version, err = strconv.Atoi(p.keyword()); err != nil {
+				err = ErrorInvalidAuthenticationResults
+				return
+			}
+			p.cfws()
+		}
+		if method == "" || !p.consume('=') {
+			err = ErrorInvalidAuthenticationResults
+			return
+		}
+		p.cfws()
+		r.Result = AuthResultValue(strings.ToLower(p.keyword()))
+		if r.Result == "" {
+			err = ErrorInvalidAuthenticationResults
+			return
+		}
+		for p.cfws(); !p.eof() && p.peek() != ';'; p.cfws() {
+			name := strings.ToLower(p.keyword())
+			p.cfws()
+			var prop AuthProperty
+			isProperty := p.consume('.')
+			if isProperty {
+				p.cfws()
+				prop = AuthProperty{Type: name, Name: strings.ToLower(p.keyword())}
+				p.cfws()
+			}
+			if name == "" || (isProperty && prop.Name == "") || (!isProperty && name != "reason") || !p.consume('=') {
+				err = ErrorInvalidAuthenticationResults
+				return
+			}
+			p.cfws()
+			var value string
+			if value, err = p.value(); err != nil {
+				return
+			}
+			if isProperty {
+				prop.Value = value
+				r.Properties = append(r.Properties, prop)
+			} else {
+				r.Reason = value
+			}
+		}
+		a.Results = append(a.Results, r)
+	}
+	return
+}
+
+// authParser is a parser for the Authentication-Results field.
+type authParser struct {
+	s   string
+	pos int
+}
+
+func (p *authParser) eof() bool {
+	return p.pos >= len(p.s)
+}
+
+func (p *authParser) peek() byte {
+	return p.s[p.pos]
+}
+
+// consume consumes the byte if it is the next one.
+func (p *authParser) consume(c byte) bool {
+	if !p.eof() && p.peek() == c {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// cfws skips whitespace and comments, as per RFC 5322 section 3.2.2.
+func (p *authParser) cfws() {
+	depth := 0
+	for !p.eof() {
+		switch c := p.peek(); {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+		case c == '(':
+			depth++
+		case c == ')' && depth > 0:
+			depth--
+		case c == '\\' && depth > 0:
+			p.pos++
+		case depth == 0:
+			return
+		}
+		p.pos++
+	}
+}
+
+// keyword reads a sequence of letters, digits, hyphens and underscores.
+func (p *authParser) keyword() string {
+	start := p.pos
+	for !p.eof() {
+		c := p.peek()
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_') {
+			break
+		}
+		p.pos++
+	}
+	return p.s[start:p.pos]
+}
+
+// value reads a quoted-string, or a token that may contain "@" as in a property value.
+func (p *authParser) value() (v string, err error) {
+	if p.consume('"') {
+		var sb strings.Builder
+		for !p.eof() {
+			c := p.peek()
+			p.pos++
+			switch c {
+			case '"':
+				return sb.String(), nil
+			case '\\':
+				if p.eof() {
+					return "", ErrorInvalidAuthenticationResults
+				}
+				sb.WriteByte(p.peek())
+				p.pos++
+			case '\r', '\n':
+			default:
+				sb.WriteByte(c)
+			}
+		}
+		return "", ErrorInvalidAuthenticationResults
+	}
+	start := p.pos
+	for !p.eof() && !strings.ContainsRune(" \t\r\n();\"", rune(p.peek())) {
+		p.pos++
+	}
+	return p.s[start:p.pos], nil
+}
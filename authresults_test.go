@@ -0,0 +1,137 @@
+package rfc5322_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aethiopicuschan/rfc5322-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAuthenticationResults(t *testing.T) {
+	testCases := []struct {
+		name     string
+		value    string
+		expected rfc5322.AuthenticationResults
+		err      error
+	}{
+		{
+			name:  "None",
+			value: "example.org 1; none",
+			expected: rfc5322.AuthenticationResults{
+				AuthServID: "example.org",
+				Version:    1,
+				Results:    []rfc5322.AuthResult{},
+			},
+		},
+		{
+			name: "Multiple",
+			value: "example.com;\r\n" +
+				"  spf=pass (sender IP is 192.0.2.1) smtp.mailfrom=example.net;\r\n" +
+				"  dkim/1=FAIL reason=\"bad signature\" header.d=example.net header.i=@example.net;\r\n" +
+				"  dmarc=pass header.from=\"example.net\"",
+			expected: rfc5322.AuthenticationResults{
+				AuthServID: "example.com",
+				Results: []rfc5322.AuthResult{
+					{Method: "spf", Result: rfc5322.AuthResultPass, Properties: []rfc5322.AuthProperty{{Type: "smtp", Name: "mailfrom", Value: "example.net"}}},
+					{Method: "dkim", Version: 1, Result: rfc5322.AuthResultFail, Reason: "bad signature", Properties: []rfc5322.AuthProperty{
+						{Type: "header", Name: "d", Value: "example.net"},
+						{Type: "header", Name: "i", Value: "@example.net"},
+					}},
+					{Method: "dmarc", Result: rfc5322.AuthResultPass, Properties: []rfc5322.AuthProperty{{Type: "header", Name: "from", Value: "example.net"}}},
+				},
+			},
+		},
+		{
+			name:  "Comments",
+			value: "(a (nested) comment) example.org; auth=pass (cram-md5) smtp.auth=sender@example.net",
+			expected: rfc5322.AuthenticationResults{
+				AuthServID: "example.org",
+				Results: []rfc5322.AuthResult{
+					{Method: "auth", Result: rfc5322.AuthResultPass, Properties: []rfc5322.AuthProperty{{Type: "smtp", Name: "auth", Value: "sender@example.net"}}},
+				},
+			},
+		},
+		{name: "NoAuthServID", value: "; spf=pass", err: rfc5322.ErrorInvalidAuthenticationResults},
+		{name: "NoResult", value: "example.org; spf=", err: rfc5322.ErrorInvalidAuthenticationResults},
+		{name: "UnknownToken", value: "example.org; spf=pass because=reasons", err: rfc5322.ErrorInvalidAuthenticationResults},
+		{name: "UnterminatedQuote", value: "example.org; spf=pass reason=\"oops", err: rfc5322.ErrorInvalidAuthenticationResults},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			results, err := rfc5322.ParseAuthenticationResults(tc.value)
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, results)
+		})
+	}
+}
+
+func TestAuthenticationResults(t *testing.T) {
+	results := rfc5322.NewAuthenticationResults("mx.example.org").
+		Add(rfc5322.AuthResult{
+			Method:     rfc5322.AuthMethodSPF,
+			Result:     rfc5322.AuthResultPass,
+			Properties: []rfc5322.AuthProperty{{Type: "smtp", Name: "mailfrom", Value: "alice@example.com"}},
+		}).
+		Add(rfc5322.AuthResult{
+			Method:     rfc5322.AuthMethodDKIM,
+			Result:     rfc5322.AuthResultFail,
+			Reason:     `body hash "bh" mismatch`,
+			Properties: []rfc5322.AuthProperty{{Type: "header", Name: "d", Value: "example.com"}},
+		})
+	assert.Equal(t, "mx.example.org;\r\n\tspf=pass smtp.mailfrom=alice@example.com;\r\n\tdkim=fail reason=\"body hash \\\"bh\\\" mismatch\" header.d=example.com", results.String())
+
+	header := newTestHeader(t).AddAuthenticationResults(*results)
+	s, err := rfc5322.NewEMail(header, rfc5322.NewBody()).String()
+	assert.NoError(t, err)
+	assert.Contains(t, s, "Authentication-Results: mx.example.org;\r\n\tspf=pass")
+	// Authentication-Results is prepended like a trace field.
+	assert.True(t, strings.HasPrefix(s, "Authentication-Results: mx.example.org;"))
+	assert.Equal(t, "mx.example.org; spf=pass smtp.mailfrom=alice@example.com; dkim=fail reason=\"body hash \\\"bh\\\" mismatch\" header.d=example.com", header.Get("Authentication-Results"))
+
+	// Downstream filters can read the parsed results.
+	parsed, err := rfc5322.Parse(strings.NewReader(s))
+	assert.NoError(t, err)
+	if assert.True(t, parsed.Header().AuthenticationResults().IsSome()) {
+		all := parsed.Header().AuthenticationResults().Unwrap()
+		assert.Len(t, all, 1)
+		assert.Equal(t, *results, all[0])
+		dkim, ok := all[0].Result(rfc5322.AuthMethodDKIM)
+		assert.True(t, ok)
+		assert.Equal(t, rfc5322.AuthResultFail, dkim.Result)
+		assert.Equal(t, "example.com", dkim.Property("header", "d"))
+	}
+
+	parsed.Header().Del("Authentication-Results")
+	assert.True(t, parsed.Header().AuthenticationResults().IsNone())
+}
+
+func TestAuthenticationResultsOrder(t *testing.T) {
+	first, err := rfc5322.ParseAuthenticationResults("mx.example.net; spf=pass smtp.mailfrom=example.com")
+	assert.NoError(t, err)
+	second, err := rfc5322.ParseAuthenticationResults("lists.example.org; arc=pass")
+	assert.NoError(t, err)
+	header := newTestHeader(t).
+		SetSubject("Hello").
+		AddAuthenticationResults(first).
+		PrependTrace("Received", "from mx.example.net by lists.example.org; Sun, 01 Oct 2023 12:00:00 +0000").
+		AddAuthenticationResults(second)
+
+	s, err := header.String()
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(s, "Received: from mx.example.net by lists.example.org; Sun, 01 Oct 2023 12:00:00 +0000\r\n"+
+		"Authentication-Results: lists.example.org;\r\n\tarc=pass\r\n"+
+		"Authentication-Results: mx.example.net;\r\n\tspf=pass smtp.mailfrom=example.com\r\n"+
+		"MIME-Version: 1.0\r\n"))
+
+	parsed, err := rfc5322.Parse(strings.NewReader(s + "\r\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"lists.example.org; arc=pass", "mx.example.net; spf=pass smtp.mailfrom=example.com"},
+		parsed.Header().Values("Authentication-Results"))
+}
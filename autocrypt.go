@@ -0,0 +1,121 @@
+package rfc5322
+
+import (
+	"encoding/base64"
+	"net/mail"
+	"strings"
+)
+
+// Autocrypt represents the Autocrypt field, as per the Autocrypt Level 1 specification.
+type Autocrypt struct {
+	addr          string
+	preferEncrypt bool
+	keyData       []byte
+}
+
+// NewAutocrypt creates a new Autocrypt instance with the address of the sender and its OpenPGP public key in binary form.
+func NewAutocrypt(addr string, keyData []byte) (a *Autocrypt, err error) {
+	parsed, err := mail.ParseAddress(addr)
+	if err != nil || parsed.Name != "" || parsed.Address != addr {
+		err = ErrorInvalidAddress
+		return
+	}
+	if len(keyData) == 0 {
+		err = ErrorInvalidAutocrypt
+		return
+	}
+	a = &Autocrypt{
+		addr:    addr,
+		keyData: keyData,
+	}
+	return
+}
+
+// SetPreferEncrypt sets whether the sender prefers to receive encrypted messages (prefer-encrypt=mutual).
+func (a *Autocrypt) SetPreferEncrypt(mutual bool) *Autocrypt {
+	a.preferEncrypt = mutual
+	return a
+}
+
+// Addr returns the address of the sender.
+func (a Autocrypt) Addr() string {
+	return a.addr
+}
+
+// PreferEncrypt reports whether the sender prefers to receive encrypted messages.
+func (a Autocrypt) PreferEncrypt() bool {
+	return a.preferEncrypt
+}
+
+// KeyData returns the OpenPGP public key in binary form.
+func (a Autocrypt) KeyData() []byte {
+	return a.keyData
+}
+
+// attributes returns the attributes before the key data.
+func (a Autocrypt) attributes() string {
+	s := "addr=" + a.addr + "; "
+	if a.preferEncrypt {
+		s += "prefer-encrypt=mutual; "
+	}
+	return s + "keydata="
+}
+
+// Value returns the value of the Autocrypt field on a single line.
+func (a Autocrypt) Value() string {
+	return a.attributes() + base64.StdEncoding.EncodeToString(a.keyData)
+}
+
+// String returns the value of the Autocrypt field, with the key data folded into lines of 76 characters.
+func (a Autocrypt) String() string {
+	return a.attributes() + "\r\n " + strings.ReplaceAll(string(base64Lines(a.keyData)), "\r\n", "\r\n ")
+}
+
+// ParseAutocrypt parses the value of an Autocrypt field.
+// Unknown attributes starting with an underscore are ignored, and other unknown attributes make the field invalid.
+func ParseAutocrypt(s string) (a Autocrypt, err error) {
+	var hasKeyData bool
+	for attr := range strings.SplitSeq(s, ";") {
+		name, value, ok := strings.Cut(attr, "=")
+		if !ok {
+			if strings.TrimSpace(attr) == "" {
+				continue
+			}
+			err = ErrorInvalidAutocrypt
+			return
+		}
+		name = strings.TrimSpace(name)
+		switch name {
+		case "addr":
+			a.addr = strings.TrimSpace(value)
+		case "type":
+			if strings.TrimSpace(value) != "1" {
+				err = ErrorInvalidAutocrypt
+				return
+			}
+		case "prefer-encrypt":
+			a.preferEncrypt = strings.TrimSpace(value) == "mutual"
+		case "keydata":
+			clean := strings.Map(func(r rune) rune {
+				if r == ' ' || r == '\t' || r == '\r' || r == '\n' {
+					return -1
+				}
+				return r
+			}, value)
+			if a.keyData, err = base64.StdEncoding.DecodeString(clean); err != nil {
+				err = ErrorInvalidAutocrypt
+				return
+			}
+			hasKeyData = true
+		default:
+			if !strings.HasPrefix(name, "_") {
+				err = ErrorInvalidAutocrypt
+				return
+			}
+		}
+	}
+	if _, e := mail.ParseAddress(a.addr); e != nil || !hasKeyData || len(a.keyData) == 0 {
+		err = ErrorInvalidAutocrypt
+	}
+	return
+}
@@ -0,0 +1,134 @@
+package rfc5322_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/aethiopicuschan/rfc5322-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAutocrypt(t *testing.T) {
+	keyData := bytes.Repeat([]byte{0x99, 0x01, 0x0d}, 60)
+	autocrypt, err := rfc5322.NewAutocrypt("alice@example.com", keyData)
+	assert.NoError(t, err)
+	autocrypt.SetPreferEncrypt(true)
+
+	header := newTestHeader(t).SetAutocrypt(*autocrypt)
+	s, err := rfc5322.NewEMail(header, rfc5322.NewBody()).String()
+	assert.NoError(t, err)
+	assert.Contains(t, s, "Autocrypt: addr=alice@example.com; prefer-encrypt=mutual; keydata=\r\n mQEN")
+	for line := range strings.SplitSeq(s, "\r\n") {
+		assert.LessOrEqual(t, len(line), 78)
+	}
+	assert.Equal(t, autocrypt.Value(), header.Get("Autocrypt"))
+
+	parsed, err := rfc5322.Parse(strings.NewReader(s))
+	assert.NoError(t, err)
+	if assert.True(t, parsed.Header().Autocrypt().IsSome()) {
+		a := parsed.Header().Autocrypt().Unwrap()
+		assert.Equal(t, "alice@example.com", a.Addr())
+		assert.True(t, a.PreferEncrypt())
+		assert.Equal(t, keyData, a.KeyData())
+	}
+
+	_, err = rfc5322.NewAutocrypt("Alice <alice@example.com>", keyData)
+	assert.ErrorIs(t, err, rfc5322.ErrorInvalidAddress)
+	_, err = rfc5322.NewAutocrypt("alice@example.com", nil)
+	assert.ErrorIs(t, err, rfc5322.ErrorInvalidAutocrypt)
+}
+
+func TestParseAutocrypt(t *testing.T) {
+	testCases := []struct {
+		name  string
+		value string
+		err   error
+	}{
+		{"Valid", "addr=alice@example.com; keydata=mQEN", nil},
+		{"Type", "addr=alice@example.com; type=1; keydata=mQEN", nil},
+		{"NonCritical", "addr=alice@example.com; _extra=1; keydata=mQEN", nil},
+		{"UnknownType", "addr=alice@example.com; type=2; keydata=mQEN", rfc5322.ErrorInvalidAutocrypt},
+		{"Critical", "addr=alice@example.com; extra=1; keydata=mQEN", rfc5322.ErrorInvalidAutocrypt},
+		{"NoKeyData", "addr=alice@example.com", rfc5322.ErrorInvalidAutocrypt},
+		{"NoAddr", "keydata=mQEN", rfc5322.ErrorInvalidAutocrypt},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			a, err := rfc5322.ParseAutocrypt(tc.value)
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, "alice@example.com", a.Addr())
+			assert.Equal(t, []byte{0x99, 0x01, 0x0d}, a.KeyData())
+		})
+	}
+}
+
+func TestProtectHeaders(t *testing.T) {
+	header := newTestHeader(t).
+		AddTo(mustAddress(t, "bob@example.com")).
+		AddBcc(mustAddress(t, "carol@example.com")).
+		SetSubject("Quarterly results")
+	body := rfc5322.NewBody()
+	body.SetHeader("Content-Type", "text/plain; charset=UTF-8")
+	body.SetContent([]byte("Confidential"))
+	email := rfc5322.NewEMail(header, body)
+
+	protected, err := email.ProtectHeadersForEncryption()
+	assert.NoError(t, err)
+	assert.Equal(t, "...", protected.Header().Get("Subject"))
+	assert.Equal(t, "Quarterly results", email.Header().Get("Subject"))
+	inner := protected.Body().String()
+	assert.Contains(t, inner, "Content-Type: text/plain; charset=UTF-8; protected-headers=v1\r\n")
+	assert.Contains(t, inner, "Subject: Quarterly results\r\n")
+	assert.Contains(t, inner, "To: bob@example.com\r\n")
+	assert.NotContains(t, inner, "Bcc:")
+	assert.NotContains(t, inner, "MIME-Version:")
+
+	// The protected headers survive encryption and are restored after decryption.
+	pgp := &fakePGP{}
+	encrypted, err := rfc5322.EncryptPGP(protected.Body(), pgp)
+	assert.NoError(t, err)
+	s, err := rfc5322.NewEMail(protected.Header(), encrypted).String()
+	assert.NoError(t, err)
+	assert.NotContains(t, s, "Quarterly results")
+
+	parsed, err := rfc5322.Parse(strings.NewReader(s))
+	assert.NoError(t, err)
+	decrypted, err := rfc5322.DecryptPGP(parsed.Body(), pgp)
+	assert.NoError(t, err)
+	restored, err := rfc5322.NewEMail(parsed.Header(), decrypted).RestoreProtectedHeaders()
+	assert.NoError(t, err)
+	assert.Equal(t, "Quarterly results", restored.Header().Get("Subject"))
+	assert.Equal(t, []string{"Quarterly results"}, restored.Header().Values("Subject"))
+	assert.Equal(t, "Content-Type: text/plain; charset=UTF-8\r\n\r\nConfidential", restored.Body().String())
+}
+
+func TestProtectHeadersSigned(t *testing.T) {
+	header := newTestHeader(t).SetSubject("Signed subject").SetComments("Not protected")
+	body := rfc5322.NewBody()
+	body.SetContent([]byte("Hello"))
+
+	protected, err := rfc5322.NewEMail(header, body).ProtectHeaders("subject")
+	assert.NoError(t, err)
+	assert.NotContains(t, protected.Body().String(), "Comments:")
+	// The Subject of a message that is only signed is not obscured.
+	assert.Equal(t, "Signed subject", protected.Header().Get("Subject"))
+
+	pgp := &fakePGP{}
+	signed, err := rfc5322.SignPGP(protected.Body(), pgp)
+	assert.NoError(t, err)
+	restored, err := rfc5322.NewEMail(protected.Header(), signed).RestoreProtectedHeaders()
+	assert.NoError(t, err)
+	assert.Equal(t, "Signed subject", restored.Header().Get("Subject"))
+	assert.Equal(t, "Not protected", restored.Header().Get("Comments"))
+
+	// The signed part is kept as is, so that it can still be verified.
+	_, err = rfc5322.VerifyPGP(restored.Body(), pgp)
+	assert.NoError(t, err)
+}
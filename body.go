@@ -3,38 +3,93 @@ package rfc5322
 import (
 	"fmt"
 	"mime"
+	"slices"
 	"strings"
 )
 
 // Body represents the body of an email message.
 type Body struct {
-	headers map[string]string
+	headers []field
 	content []byte
 	parts   []*Body
+	// raw is the original representation of a parsed Body that must not be modified, such as a signed part.
+	// It is only used while neither the Body nor its parts are modified.
+	raw []byte
+	// modified reports whether the Body has been modified since it was parsed.
+	modified bool
 }
 
 func NewBody() *Body {
 	return &Body{
-		headers: make(map[string]string),
+		headers: make([]field, 0),
 		content: make([]byte, 0),
 		parts:   make([]*Body, 0),
 	}
 }
 
-func (b *Body) SetHeader(key, value string) {
-	b.headers[key] = value
+// SetHeader sets the header field of the Body.
+// It returns an error if the field name is not valid or the value contains bare CR, LF or NUL.
+func (b *Body) SetHeader(key, value string) error {
+	if !validFieldName(key) {
+		return ErrorInvalidFieldName
+	}
+	if !validFieldValue(value) {
+		return ErrorInvalidFieldValue
+	}
+	b.setHeader(key, value)
+	b.touch()
+	return nil
+}
+
+// touch marks the Body as modified, so that neither its raw representation nor those of its ancestors are used.
+func (b *Body) touch() {
+	b.raw = nil
+	b.modified = true
+}
+
+// changed reports whether the Body or any of its parts has been modified.
+func (b *Body) changed() bool {
+	return b.modified || slices.ContainsFunc(b.parts, (*Body).changed)
+}
+
+// rawValid reports whether the raw representation of the Body can be used, which is not the case once a part is modified.
+func (b *Body) rawValid() bool {
+	return b.raw != nil && !b.changed()
+}
+
+// setHeader sets the header field without validation, keeping the position of an existing field.
+func (b *Body) setHeader(key, value string) {
+	for i, f := range b.headers {
+		if strings.EqualFold(f.name, key) {
+			b.headers[i] = field{name: key, value: value}
+			return
+		}
+	}
+	b.headers = append(b.headers, field{name: key, value: value})
+}
+
+// header returns the value of the header field, matching the name case-insensitively.
+func (b *Body) header(key string) (value string, ok bool) {
+	for _, f := range b.headers {
+		if strings.EqualFold(f.name, key) {
+			return f.value, true
+		}
+	}
+	return "", false
 }
 
 func (b *Body) SetContent(content []byte) {
 	b.content = content
+	b.touch()
 }
 
 func (b *Body) AddPart(part *Body) {
 	b.parts = append(b.parts, part)
+	b.touch()
 }
 
 func (b *Body) ContentType() string {
-	if ct, ok := b.headers["Content-Type"]; ok {
+	if ct, ok := b.header("Content-Type"); ok {
 		mediaType, _, err := mime.ParseMediaType(ct)
 		if err == nil {
 			return mediaType
@@ -52,7 +107,7 @@ func (b *Body) ensureBoundary() string {
 	if !b.IsMultipart() {
 		return ""
 	}
-	ct, ok := b.headers["Content-Type"]
+	ct, ok := b.header("Content-Type")
 	if !ok {
 		return ""
 	}
@@ -63,17 +118,20 @@ func (b *Body) ensureBoundary() string {
 	boundary, exists := params["boundary"]
 	if !exists || boundary == "" {
 		boundary = "BOUNDARY-DEFAULT"
-		b.headers["Content-Type"] = fmt.Sprintf("%s; boundary=%q", mediaType, boundary)
+		b.setHeader("Content-Type", fmt.Sprintf("%s; boundary=%q", mediaType, boundary))
 	}
 	return boundary
 }
 
 func (b *Body) String() string {
+	if b.rawValid() {
+		return string(b.raw)
+	}
 	var builder strings.Builder
 
 	boundary := b.ensureBoundary()
-	for key, value := range b.headers {
-		builder.WriteString(fmt.Sprintf("%s: %s\r\n", key, value))
+	for _, f := range b.headers {
+		builder.WriteString(fmt.Sprintf("%s: %s\r\n", f.name, f.value))
 	}
 	builder.WriteString("\r\n")
 
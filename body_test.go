@@ -1,6 +1,7 @@
 package rfc5322_test
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/aethiopicuschan/rfc5322-go"
@@ -147,3 +148,123 @@ func TestString(t *testing.T) {
 		})
 	}
 }
+
+func TestSetHeader(t *testing.T) {
+	tests := []struct {
+		name     string
+		key      string
+		value    string
+		expected error
+	}{
+		{
+			"valid header",
+			"Content-Type",
+			"text/plain",
+			nil,
+		},
+		{
+			"folded value",
+			"Content-Type",
+			"text/plain;\r\n charset=utf-8",
+			nil,
+		},
+		{
+			"value with CRLF",
+			"Content-Type",
+			"text/plain\r\nBcc: evil@example.com",
+			rfc5322.ErrorInvalidFieldValue,
+		},
+		{
+			"value with bare CR",
+			"Content-Type",
+			"text/plain\rX",
+			rfc5322.ErrorInvalidFieldValue,
+		},
+		{
+			"value with NUL",
+			"Content-Type",
+			"text/plain\x00",
+			rfc5322.ErrorInvalidFieldValue,
+		},
+		{
+			"name with colon",
+			"Content-Type:",
+			"text/plain",
+			rfc5322.ErrorInvalidFieldName,
+		},
+		{
+			"name with space",
+			"Content Type",
+			"text/plain",
+			rfc5322.ErrorInvalidFieldName,
+		},
+		{
+			"empty name",
+			"",
+			"text/plain",
+			rfc5322.ErrorInvalidFieldName,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			b := rfc5322.NewBody()
+			err := b.SetHeader(tc.key, tc.value)
+			assert.ErrorIs(t, err, tc.expected)
+			if tc.expected != nil {
+				assert.Equal(t, "\r\n", b.String())
+			}
+		})
+	}
+}
+
+func TestModifySignedDescendant(t *testing.T) {
+	message := "Content-Type: multipart/signed; protocol=\"application/pgp-signature\"; boundary=s\r\n" +
+		"\r\n" +
+		"--s\r\n" +
+		"Content-Type: multipart/mixed;  boundary=m\r\n" +
+		"\r\n" +
+		"--m\r\nContent-Type: text/plain\r\n\r\nHello\r\n" +
+		"--m\r\nContent-Type: application/octet-stream\r\nX-Secret: 42\r\n\r\nMZ\r\n" +
+		"--m--\r\n" +
+		"--s\r\n" +
+		"Content-Type: application/pgp-signature\r\n" +
+		"\r\n" +
+		"signature\r\n" +
+		"--s--\r\n"
+
+	tests := []struct {
+		name     string
+		modify   func(part *rfc5322.Body)
+		expected string
+		removed  string
+	}{
+		{
+			"set header",
+			func(part *rfc5322.Body) { assert.NoError(t, part.SetHeader("X-Secret", "redacted")) },
+			"X-Secret: redacted",
+			"X-Secret: 42",
+		},
+		{
+			"set content",
+			func(part *rfc5322.Body) { part.SetContent([]byte("removed")) },
+			"removed",
+			"MZ",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			b, err := rfc5322.ParseBody(strings.NewReader(message))
+			assert.NoError(t, err)
+			part, ok := b.Part(rfc5322.BodyPath{0, 1})
+			assert.True(t, ok)
+			tt.modify(part)
+			s := b.String()
+			assert.Contains(t, s, tt.expected)
+			assert.NotContains(t, s, tt.removed)
+		})
+	}
+}
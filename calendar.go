@@ -0,0 +1,528 @@
+package rfc5322
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// CalendarMethod is the iTIP method of a calendar invitation, as per RFC 5546 section 1.4.
+type CalendarMethod string
+
+const (
+	CalendarRequest CalendarMethod = "REQUEST"
+	CalendarReply   CalendarMethod = "REPLY"
+	CalendarCancel  CalendarMethod = "CANCEL"
+)
+
+// PartStat is the participation status of an attendee, as per RFC 5545 section 3.2.12.
+type PartStat string
+
+const (
+	PartStatNeedsAction PartStat = "NEEDS-ACTION"
+	PartStatAccepted    PartStat = "ACCEPTED"
+	PartStatDeclined    PartStat = "DECLINED"
+	PartStatTentative   PartStat = "TENTATIVE"
+)
+
+// Attendee is an attendee of an Event.
+type Attendee struct {
+	Address Address
+	// Status is the participation status, which is NEEDS-ACTION if it is empty.
+	Status PartStat
+	// RSVP requests a reply from the attendee.
+	RSVP bool
+}
+
+// Event is a calendar event, as per RFC 5545 section 3.6.1.
+type Event struct {
+	// UID is the globally unique identifier of the event, which is kept by its updates, replies and cancellation.
+	UID string
+	// Sequence is the revision of the event, which is incremented by each update.
+	Sequence    int
+	Summary     string
+	Description string
+	Location    string
+	Organizer   Address
+	Attendees   []Attendee
+	Start       time.Time
+	// End is the end of the event, or the zero time if it is not given.
+	End time.Time
+	// AllDay writes the start and the end as dates, where the end is exclusive.
+	AllDay bool
+	// RRule is the recurrence rule, such as "FREQ=WEEKLY;BYDAY=MO;COUNT=10", or empty for a single event.
+	RRule string
+	// Stamp is the time the invitation was created, which is the current time if it is zero.
+	Stamp time.Time
+}
+
+// Invitation is a calendar invitation, as per RFC 6047.
+type Invitation struct {
+	Method CalendarMethod
+	Event  Event
+	// Text is the human readable description of the invitation, which is generated from the Event if it is empty.
+	Text string
+}
+
+// Body returns the multipart/alternative Body of the invitation, with a text/plain part and a text/calendar part.
+func (inv Invitation) Body() (b *Body, err error) {
+	data, err := inv.Calendar()
+	if err != nil {
+		return
+	}
+	text := inv.Text
+	if text == "" {
+		text = inv.text()
+	}
+
+	human := NewBody()
+	human.setHeader("Content-Type", "text/plain; charset=UTF-8")
+	human.content = normalizeLineEndings([]byte(text))
+
+	calendar := NewBody()
+	calendar.setHeader("Content-Type", mime.FormatMediaType("text/calendar", map[string]string{
+		"charset": "UTF-8",
+		"method":  string(inv.Method),
+	}))
+	calendar.content = data
+
+	b = NewBody()
+	b.setHeader("Content-Type", mime.FormatMediaType("multipart/alternative", map[string]string{
+		"boundary": randomBoundary(),
+	}))
+	b.parts = []*Body{human, calendar}
+	b = b.sevenBit()
+	return
+}
+
+// EMail returns the invitation as an EMail with the Header.
+// The Header is usually from the organizer to the attendees, or from the attendee to the organizer for a reply.
+func (inv Invitation) EMail(header *Header) (e *EMail, err error) {
+	b, err := inv.Body()
+	if err != nil {
+		return
+	}
+	e = NewEMail(header, b)
+	return
+}
+
+// Reply returns the reply of the attendee to the invitation, with the participation status.
+// It returns ErrorNotAttendee if the address is not an attendee of the Event.
+func (inv Invitation) Reply(attendee Address, status PartStat) (reply Invitation, err error) {
+	addrSpec, err := attendee.addrSpec()
+	if err != nil {
+		return
+	}
+	for _, a := range inv.Event.Attendees {
+		if s, e := a.Address.addrSpec(); e == nil && strings.EqualFold(s, addrSpec) {
+			reply = Invitation{Method: CalendarReply, Event: inv.Event}
+			reply.Event.Attendees = []Attendee{{Address: a.Address, Status: status}}
+			reply.Event.Stamp = time.Time{}
+			return
+		}
+	}
+	err = ErrorNotAttendee
+	return
+}
+
+// Cancel returns the cancellation of the invitation, which is a new revision of the Event.
+func (inv Invitation) Cancel() Invitation {
+	cancel := Invitation{Method: CalendarCancel, Event: inv.Event}
+	cancel.Event.Sequence++
+	cancel.Event.Stamp = time.Time{}
+	return cancel
+}
+
+// Calendar returns the iCalendar object of the invitation, as per RFC 5545.
+func (inv Invitation) Calendar() (data []byte, err error) {
+	ev := inv.Event
+	switch inv.Method {
+	case CalendarRequest, CalendarCancel:
+	case CalendarReply:
+		if len(ev.Attendees) == 0 {
+			err = ErrorInvalidInvitation
+			return
+		}
+	default:
+		err = ErrorInvalidInvitation
+		return
+	}
+	if ev.UID == "" || ev.Start.IsZero() || strings.ContainsAny(ev.UID+ev.RRule, "\r\n\x00") {
+		err = ErrorInvalidInvitation
+		return
+	}
+	organizer, err := calendarAddress(ev.Organizer)
+	if err != nil {
+		return
+	}
+
+	var buf bytes.Buffer
+	writeLine := func(name, value string) {
+		writeContentLine(&buf, name+":"+value)
+	}
+	writeLine("BEGIN", "VCALENDAR")
+	writeLine("PRODID", "-//aethiopicuschan//rfc5322-go//EN")
+	writeLine("VERSION", "2.0")
+	writeLine("CALSCALE", "GREGORIAN")
+	writeLine("METHOD", string(inv.Method))
+	writeLine("BEGIN", "VEVENT")
+	writeLine("UID", ev.UID)
+	stamp := ev.Stamp
+	if stamp.IsZero() {
+		stamp = time.Now()
+	}
+	writeLine("DTSTAMP", calendarTime(stamp))
+	if ev.AllDay {
+		writeLine("DTSTART;VALUE=DATE", ev.Start.Format("20060102"))
+		if !ev.End.IsZero() {
+			writeLine("DTEND;VALUE=DATE", ev.End.Format("20060102"))
+		}
+	} else {
+		writeLine("DTSTART", calendarTime(ev.Start))
+		if !ev.End.IsZero() {
+			writeLine("DTEND", calendarTime(ev.End))
+		}
+	}
+	writeLine("SEQUENCE", strconv.Itoa(ev.Sequence))
+	if ev.RRule != "" {
+		writeLine("RRULE", ev.RRule)
+	}
+	if ev.Summary != "" {
+		writeLine("SUMMARY", escapeText(ev.Summary))
+	}
+	if ev.Description != "" {
+		writeLine("DESCRIPTION", escapeText(ev.Description))
+	}
+	if ev.Location != "" {
+		writeLine("LOCATION", escapeText(ev.Location))
+	}
+	writeLine("ORGANIZER"+organizer.params, organizer.uri)
+	for _, a := range ev.Attendees {
+		attendee, e := calendarAddress(a.Address)
+		if e != nil {
+			err = e
+			return
+		}
+		status := a.Status
+		if status == "" {
+			status = PartStatNeedsAction
+		}
+		params := attendee.params + ";ROLE=REQ-PARTICIPANT;PARTSTAT=" + string(status)
+		if a.RSVP {
+			params += ";RSVP=TRUE"
+		}
+		writeLine("ATTENDEE"+params, attendee.uri)
+	}
+	if inv.Method == CalendarCancel {
+		writeLine("STATUS", "CANCELLED")
+	} else {
+		writeLine("STATUS", "CONFIRMED")
+	}
+	writeLine("END", "VEVENT")
+	writeLine("END", "VCALENDAR")
+	data = buf.Bytes()
+	return
+}
+
+// text returns the human readable description of the invitation.
+func (inv Invitation) text() string {
+	ev := inv.Event
+	var buf strings.Builder
+	switch inv.Method {
+	case CalendarCancel:
+		buf.WriteString("Canceled: ")
+	case CalendarReply:
+		if len(ev.Attendees) > 0 {
+			fmt.Fprintf(&buf, "%s has replied %s: ", ev.Attendees[0].Address.Value(), strings.ToLower(string(ev.Attendees[0].Status)))
+		}
+	}
+	buf.WriteString(ev.Summary + "\r\n")
+	layout := "Mon, 02 Jan 2006 15:04 -0700"
+	if ev.AllDay {
+		layout = "Mon, 02 Jan 2006"
+	}
+	buf.WriteString("When: " + ev.Start.Format(layout))
+	if !ev.End.IsZero() {
+		buf.WriteString(" - " + ev.End.Format(layout))
+	}
+	buf.WriteString("\r\n")
+	if ev.Location != "" {
+		buf.WriteString("Where: " + ev.Location + "\r\n")
+	}
+	buf.WriteString("Organizer: " + ev.Organizer.Value() + "\r\n")
+	if ev.Description != "" {
+		buf.WriteString("\r\n" + ev.Description + "\r\n")
+	}
+	return buf.String()
+}
+
+// calendarUser is the parameters and the mailto URI of a calendar user address.
+type calendarUser struct {
+	params string
+	uri    string
+}
+
+// calendarAddress returns the calendar user of the Address, with its name as the CN parameter.
+func calendarAddress(a Address) (u calendarUser, err error) {
+	addrSpec, err := a.addrSpec()
+	if err != nil {
+		return
+	}
+	u.uri = "mailto:" + addrSpec
+	if a.name.IsSome() {
+		u.params = ";CN=" + paramValue(a.name.Unwrap())
+	}
+	return
+}
+
+// calendarTime returns the time as a UTC date-time.
+func calendarTime(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+// escapeText escapes a TEXT value, as per RFC 5545 section 3.3.11.
+func escapeText(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`, "\r", `\n`).Replace(s)
+}
+
+// unescapeText reverts escapeText.
+func unescapeText(s string) string {
+	var buf strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+			if s[i] == 'n' || s[i] == 'N' {
+				buf.WriteByte('\n')
+			} else {
+				buf.WriteByte(s[i])
+			}
+			continue
+		}
+		buf.WriteByte(s[i])
+	}
+	return buf.String()
+}
+
+// paramValue returns the parameter value, quoted if needed, as per RFC 5545 section 3.1.
+// DQUOTE and control characters cannot appear in a parameter value and are removed.
+func paramValue(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if r == '"' || r < ' ' || r == 0x7f {
+			return -1
+		}
+		return r
+	}, s)
+	if strings.ContainsAny(s, ":;,") {
+		return `"` + s + `"`
+	}
+	return s
+}
+
+// writeContentLine writes the content line, folded to lines of 75 octets without splitting UTF-8 characters.
+func writeContentLine(buf *bytes.Buffer, line string) {
+	limit := 75
+	for len(line) > limit {
+		i := limit
+		for i > 0 && !utf8.RuneStart(line[i]) {
+			i--
+		}
+		buf.WriteString(line[:i] + "\r\n ")
+		line = line[i:]
+		// The leading space of the continuation line counts toward its length.
+		limit = 74
+	}
+	buf.WriteString(line + "\r\n")
+}
+
+// contentLine is a parsed content line of an iCalendar object.
+type contentLine struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// parseContentLines unfolds and parses the content lines of an iCalendar object.
+// Invalid lines are skipped.
+func parseContentLines(data []byte) (lines []contentLine) {
+	data = bytes.ReplaceAll(normalizeLineEndings(data), []byte("\r\n "), nil)
+	data = bytes.ReplaceAll(data, []byte("\r\n\t"), nil)
+	for raw := range strings.SplitSeq(string(data), "\r\n") {
+		// The name and the parameters end with the first colon outside of a quoted string.
+		quoted := false
+		i := strings.IndexFunc(raw, func(r rune) bool {
+			if r == '"' {
+				quoted = !quoted
+			}
+			return r == ':' && !quoted
+		})
+		if i < 0 {
+			continue
+		}
+		l := contentLine{params: map[string]string{}, value: raw[i+1:]}
+		segments := splitParams(raw[:i])
+		l.name = strings.ToUpper(segments[0])
+		for _, p := range segments[1:] {
+			if name, value, ok := strings.Cut(p, "="); ok {
+				l.params[strings.ToUpper(name)] = strings.Trim(value, `"`)
+			}
+		}
+		lines = append(lines, l)
+	}
+	return
+}
+
+// splitParams splits the name and the parameters of a content line at the semicolons outside of quoted strings.
+func splitParams(s string) (segments []string) {
+	quoted := false
+	start := 0
+	for i, r := range s {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case r == ';' && !quoted:
+			segments = append(segments, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(segments, s[start:])
+}
+
+// parseCalendarTime parses a DATE or DATE-TIME value, in the time zone of the TZID parameter for a local time.
+func parseCalendarTime(l contentLine) (t time.Time, allDay bool, err error) {
+	if strings.EqualFold(l.params["VALUE"], "DATE") || len(l.value) == 8 {
+		t, err = time.Parse("20060102", l.value)
+		return t, true, err
+	}
+	if strings.HasSuffix(l.value, "Z") {
+		t, err = time.Parse("20060102T150405Z", l.value)
+		return
+	}
+	loc := time.UTC
+	if tzid := l.params["TZID"]; tzid != "" {
+		if tz, e := time.LoadLocation(tzid); e == nil {
+			loc = tz
+		}
+	}
+	t, err = time.ParseInLocation("20060102T150405", l.value, loc)
+	return
+}
+
+// parseCalendarAddress parses a calendar user address with the mailto scheme, with its CN parameter as the name.
+func parseCalendarAddress(l contentLine) (a Address, err error) {
+	value := l.value
+	if len(value) < 7 || !strings.EqualFold(value[:7], "mailto:") {
+		err = ErrorInvalidAddress
+		return
+	}
+	value = value[7:]
+	var addr *Address
+	if cn := l.params["CN"]; cn != "" {
+		addr, err = NewAddressWithName(cn, value)
+	}
+	if addr == nil {
+		addr, err = NewAddress(value)
+	}
+	if err != nil {
+		return
+	}
+	return *addr, nil
+}
+
+// ParseCalendar parses the first event of an iCalendar object.
+// Unknown properties, components other than the first event and invalid attendees are ignored.
+func ParseCalendar(data []byte) (inv *Invitation, err error) {
+	inv = &Invitation{}
+	var components []string
+	events := 0
+	for _, l := range parseContentLines(data) {
+		switch l.name {
+		case "BEGIN":
+			components = append(components, strings.ToUpper(l.value))
+			if strings.EqualFold(l.value, "VEVENT") {
+				events++
+			}
+			continue
+		case "END":
+			if len(components) > 0 {
+				components = components[:len(components)-1]
+			}
+			continue
+		}
+		if len(components) == 1 && components[0] == "VCALENDAR" && l.name == "METHOD" {
+			inv.Method = CalendarMethod(strings.ToUpper(l.value))
+			continue
+		}
+		if events != 1 || len(components) != 2 || components[1] != "VEVENT" {
+			continue
+		}
+		ev := &inv.Event
+		switch l.name {
+		case "UID":
+			ev.UID = l.value
+		case "SEQUENCE":
+			ev.Sequence, _ = strconv.Atoi(l.value)
+		case "SUMMARY":
+			ev.Summary = unescapeText(l.value)
+		case "DESCRIPTION":
+			ev.Description = unescapeText(l.value)
+		case "LOCATION":
+			ev.Location = unescapeText(l.value)
+		case "RRULE":
+			ev.RRule = l.value
+		case "DTSTART":
+			ev.Start, ev.AllDay, _ = parseCalendarTime(l)
+		case "DTEND":
+			ev.End, _, _ = parseCalendarTime(l)
+		case "DTSTAMP":
+			ev.Stamp, _, _ = parseCalendarTime(l)
+		case "ORGANIZER":
+			ev.Organizer, _ = parseCalendarAddress(l)
+		case "ATTENDEE":
+			if a, e := parseCalendarAddress(l); e == nil {
+				ev.Attendees = append(ev.Attendees, Attendee{
+					Address: a,
+					Status:  PartStat(strings.ToUpper(l.params["PARTSTAT"])),
+					RSVP:    strings.EqualFold(l.params["RSVP"], "TRUE"),
+				})
+			}
+		}
+	}
+	if events == 0 || inv.Event.UID == "" || inv.Event.Start.IsZero() {
+		err = ErrorInvalidInvitation
+		inv = nil
+	}
+	return
+}
+
+// ParseInvitation parses the calendar invitation of the EMail.
+// The text/calendar Body is looked up in the whole tree of the Body, and its method parameter is used if the object has no METHOD.
+func ParseInvitation(e *EMail) (inv *Invitation, err error) {
+	calendars := e.body.FindAll("text/calendar")
+	if len(calendars) == 0 {
+		err = ErrorNotInvitation
+		return
+	}
+	calendar := calendars[0]
+	data, err := calendar.decodedContent()
+	if err != nil {
+		return
+	}
+	if inv, err = ParseCalendar(data); err != nil {
+		return
+	}
+	if inv.Method == "" {
+		_, params := calendar.mediaType()
+		inv.Method = CalendarMethod(strings.ToUpper(params["method"]))
+	}
+	if text, ok := e.body.TextBody(); ok {
+		if inv.Text, err = text.Text(); err != nil {
+			return nil, err
+		}
+	}
+	return
+}
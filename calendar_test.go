@@ -0,0 +1,161 @@
+package rfc5322_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aethiopicuschan/rfc5322-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestInvitation(t *testing.T) rfc5322.Invitation {
+	t.Helper()
+	organizer, err := rfc5322.NewAddressWithName("Alice", "alice@example.com")
+	assert.NoError(t, err)
+	attendee, err := rfc5322.NewAddressWithName("Bob, Jr.", "bob@example.net")
+	assert.NoError(t, err)
+	return rfc5322.Invitation{
+		Method: rfc5322.CalendarRequest,
+		Event: rfc5322.Event{
+			UID:         "20231001-weekly@example.com",
+			Summary:     "Weekly sync; planning",
+			Description: "Agenda:\n- Roadmap\n- Café budget",
+			Location:    "Room 1",
+			Organizer:   *organizer,
+			Attendees:   []rfc5322.Attendee{{Address: *attendee, RSVP: true}},
+			Start:       time.Date(2023, 10, 2, 9, 0, 0, 0, time.UTC),
+			End:         time.Date(2023, 10, 2, 9, 30, 0, 0, time.UTC),
+			RRule:       "FREQ=WEEKLY;BYDAY=MO;COUNT=10",
+			Stamp:       time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC),
+		},
+	}
+}
+
+func TestInvitation(t *testing.T) {
+	inv := newTestInvitation(t)
+	data, err := inv.Calendar()
+	assert.NoError(t, err)
+	s := string(data)
+	assert.True(t, strings.HasPrefix(s, "BEGIN:VCALENDAR\r\n"))
+	assert.Contains(t, s, "METHOD:REQUEST\r\n")
+	assert.Contains(t, s, "DTSTART:20231002T090000Z\r\n")
+	assert.Contains(t, s, "RRULE:FREQ=WEEKLY;BYDAY=MO;COUNT=10\r\n")
+	assert.Contains(t, s, `SUMMARY:Weekly sync\; planning`+"\r\n")
+	assert.Contains(t, s, "ORGANIZER;CN=Alice:mailto:alice@example.com\r\n")
+	assert.Contains(t, s, "ATTENDEE;CN=\"Bob, Jr.\";ROLE=REQ-PARTICIPANT;PARTSTAT=NEEDS-ACTION;RSVP=TRUE\r\n :mailto:bob@example.net\r\n")
+	for line := range strings.SplitSeq(s, "\r\n") {
+		assert.LessOrEqual(t, len(line), 75)
+	}
+
+	header := newTestHeader(t).AddTo(mustAddress(t, "bob@example.net")).SetSubject("Invitation: Weekly sync")
+	email, err := inv.EMail(header)
+	assert.NoError(t, err)
+	m, err := email.String()
+	assert.NoError(t, err)
+	assert.Contains(t, m, "Content-Type: multipart/alternative; boundary=")
+	assert.Contains(t, m, "Content-Type: text/calendar; charset=UTF-8; method=REQUEST\r\n")
+	assert.Less(t, strings.Index(m, "Content-Type: text/plain"), strings.Index(m, "Content-Type: text/calendar"))
+
+	parsed, err := rfc5322.Parse(strings.NewReader(m))
+	assert.NoError(t, err)
+	got, err := rfc5322.ParseInvitation(parsed)
+	assert.NoError(t, err)
+	assert.Equal(t, rfc5322.CalendarRequest, got.Method)
+	assert.Contains(t, got.Text, "Where: Room 1")
+	ev := got.Event
+	assert.Equal(t, inv.Event.UID, ev.UID)
+	assert.Equal(t, inv.Event.Summary, ev.Summary)
+	assert.Equal(t, inv.Event.Description, ev.Description)
+	assert.Equal(t, inv.Event.RRule, ev.RRule)
+	assert.True(t, inv.Event.Start.Equal(ev.Start))
+	assert.True(t, inv.Event.End.Equal(ev.End))
+	assert.Equal(t, inv.Event.Organizer, ev.Organizer)
+	if assert.Len(t, ev.Attendees, 1) {
+		assert.Equal(t, inv.Event.Attendees[0].Address, ev.Attendees[0].Address)
+		assert.Equal(t, rfc5322.PartStatNeedsAction, ev.Attendees[0].Status)
+		assert.True(t, ev.Attendees[0].RSVP)
+	}
+}
+
+func TestInvitationReplyAndCancel(t *testing.T) {
+	inv := newTestInvitation(t)
+
+	reply, err := inv.Reply(mustAddress(t, "BOB@example.net"), rfc5322.PartStatAccepted)
+	assert.NoError(t, err)
+	assert.Equal(t, rfc5322.CalendarReply, reply.Method)
+	data, err := reply.Calendar()
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "PARTSTAT=ACCEPTED")
+	assert.NotContains(t, string(data), "RSVP=TRUE")
+	_, err = inv.Reply(mustAddress(t, "carol@example.net"), rfc5322.PartStatAccepted)
+	assert.ErrorIs(t, err, rfc5322.ErrorNotAttendee)
+
+	cancel := inv.Cancel()
+	assert.Equal(t, 1, cancel.Event.Sequence)
+	data, err = cancel.Calendar()
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "METHOD:CANCEL\r\n")
+	assert.Contains(t, string(data), "STATUS:CANCELLED\r\n")
+	assert.Equal(t, 0, inv.Event.Sequence)
+
+	testCases := []struct {
+		name   string
+		modify func(*rfc5322.Invitation)
+	}{
+		{"NoUID", func(i *rfc5322.Invitation) { i.Event.UID = "" }},
+		{"NoStart", func(i *rfc5322.Invitation) { i.Event.Start = time.Time{} }},
+		{"UnknownMethod", func(i *rfc5322.Invitation) { i.Method = "PUBLISH" }},
+		{"Injection", func(i *rfc5322.Invitation) { i.Event.RRule = "FREQ=DAILY\r\nATTENDEE:mailto:eve@example.com" }},
+		{"ReplyWithoutAttendee", func(i *rfc5322.Invitation) { i.Method = rfc5322.CalendarReply; i.Event.Attendees = nil }},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			i := newTestInvitation(t)
+			tc.modify(&i)
+			_, err := i.Body()
+			assert.ErrorIs(t, err, rfc5322.ErrorInvalidInvitation)
+		})
+	}
+}
+
+func TestParseCalendar(t *testing.T) {
+	data := "BEGIN:VCALENDAR\n" +
+		"VERSION:2.0\n" +
+		"METHOD:REQUEST\n" +
+		"BEGIN:VTIMEZONE\n" +
+		"TZID:Asia/Tokyo\n" +
+		"END:VTIMEZONE\n" +
+		"BEGIN:VEVENT\n" +
+		"UID:abc@example.com\n" +
+		"SUMMARY:Long \n" +
+		" summary\n" +
+		"DTSTART;TZID=Asia/Tokyo:20231002T090000\n" +
+		"ORGANIZER;CN=\"Alice: Org\":MAILTO:alice@example.com\n" +
+		"ATTENDEE;PARTSTAT=TENTATIVE:mailto:bob@example.net\n" +
+		"ATTENDEE:urn:uuid:1234\n" +
+		"BEGIN:VALARM\n" +
+		"DESCRIPTION:Reminder\n" +
+		"END:VALARM\n" +
+		"END:VEVENT\n" +
+		"END:VCALENDAR\n"
+	inv, err := rfc5322.ParseCalendar([]byte(data))
+	assert.NoError(t, err)
+	assert.Equal(t, "Long summary", inv.Event.Summary)
+	assert.Empty(t, inv.Event.Description)
+	assert.True(t, time.Date(2023, 10, 2, 0, 0, 0, 0, time.UTC).Equal(inv.Event.Start))
+	assert.Equal(t, "Alice: Org <alice@example.com>", inv.Event.Organizer.Value())
+	if assert.Len(t, inv.Event.Attendees, 1) {
+		assert.Equal(t, rfc5322.PartStatTentative, inv.Event.Attendees[0].Status)
+	}
+
+	allDay, err := rfc5322.ParseCalendar([]byte("BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nUID:x\r\nDTSTART;VALUE=DATE:20231002\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"))
+	assert.NoError(t, err)
+	assert.True(t, allDay.Event.AllDay)
+
+	_, err = rfc5322.ParseCalendar([]byte("BEGIN:VCALENDAR\r\nEND:VCALENDAR\r\n"))
+	assert.ErrorIs(t, err, rfc5322.ErrorInvalidInvitation)
+	_, err = rfc5322.ParseInvitation(rfc5322.NewEMail(newTestHeader(t), rfc5322.NewBody()))
+	assert.ErrorIs(t, err, rfc5322.ErrorNotInvitation)
+}
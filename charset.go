@@ -0,0 +1,135 @@
+package rfc5322
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/encoding/ianaindex"
+	"golang.org/x/text/transform"
+)
+
+// lookupCharset returns the encoding of the charset by its IANA name or alias, or by its name in the WHATWG Encoding Standard,
+// which covers common mislabelings such as x-sjis.
+func lookupCharset(charset string) (e encoding.Encoding, err error) {
+	if e, err = ianaindex.MIME.Encoding(charset); err == nil && e != nil {
+		return
+	}
+	if e, err = htmlindex.Get(charset); err == nil && e != nil {
+		return
+	}
+	return nil, ErrorUnknownCharset
+}
+
+// isUTF8Charset reports whether the charset is UTF-8, or US-ASCII which is a subset of it, or empty.
+func isUTF8Charset(charset string) bool {
+	switch strings.ToLower(strings.TrimSpace(charset)) {
+	case "", "utf-8", "utf8", "us-ascii", "ascii":
+		return true
+	}
+	return false
+}
+
+// charsetReader returns a reader that converts the input from the charset to UTF-8, for the decoding of encoded-words.
+func charsetReader(charset string, input io.Reader) (io.Reader, error) {
+	e, err := lookupCharset(charset)
+	if err != nil {
+		return nil, err
+	}
+	return transform.NewReader(input, e.NewDecoder()), nil
+}
+
+// decodeCharset converts the content from the charset to UTF-8.
+// Content declared as US-ASCII or without a charset is kept as is if it is valid UTF-8.
+func decodeCharset(content []byte, charset string) (text string, err error) {
+	if isUTF8Charset(charset) && utf8.Valid(content) {
+		return string(content), nil
+	}
+	if charset == "" {
+		charset = "us-ascii"
+	}
+	e, err := lookupCharset(charset)
+	if err != nil {
+		return
+	}
+	decoded, err := e.NewDecoder().Bytes(content)
+	if err != nil {
+		return
+	}
+	text = string(decoded)
+	return
+}
+
+// Text returns the content of a text Body as UTF-8, decoded with its Content-Transfer-Encoding and converted from its charset.
+// It returns ErrorUnknownCharset if the charset is not supported.
+func (b *Body) Text() (text string, err error) {
+	content, err := b.decodedContent()
+	if err != nil {
+		return
+	}
+	_, params := b.mediaType()
+	return decodeCharset(content, params["charset"])
+}
+
+// SetText sets the content of the Body to the text encoded with the charset, such as ISO-2022-JP, Shift_JIS or windows-1252,
+// and sets the charset parameter of its Content-Type, which is text/plain unless it is already a text type.
+// Line endings are converted to CRLF, and the Content-Transfer-Encoding is 7bit if possible,
+// or else the shorter of quoted-printable and base64.
+// It returns ErrorUnknownCharset if the charset is not supported, and ErrorUnencodableText if the text cannot be represented in it.
+func (b *Body) SetText(text, charset string) error {
+	content := normalizeLineEndings([]byte(text))
+	switch strings.ToLower(charset) {
+	case "", "utf-8", "utf8":
+	default:
+		e, err := lookupCharset(charset)
+		if err != nil {
+			return err
+		}
+		if content, err = e.NewEncoder().Bytes(content); err != nil {
+			return ErrorUnencodableText
+		}
+	}
+	if charset == "" {
+		charset = "UTF-8"
+	}
+
+	mediaType, params := b.mediaType()
+	if !strings.HasPrefix(mediaType, "text/") {
+		mediaType, params = "text/plain", map[string]string{}
+	}
+	params["charset"] = charset
+	contentType := mime.FormatMediaType(mediaType, params)
+	if contentType == "" {
+		return ErrorUnknownCharset
+	}
+	b.setHeader("Content-Type", contentType)
+	b.setTransferEncoding(content)
+	return nil
+}
+
+// setTransferEncoding sets the content of the Body with the shortest Content-Transfer-Encoding that keeps it 7bit.
+func (b *Body) setTransferEncoding(content []byte) {
+	b.touch()
+	if isSevenBit(content) {
+		b.setHeader("Content-Transfer-Encoding", "7bit")
+		b.content = content
+		return
+	}
+	var qp bytes.Buffer
+	w := quotedprintable.NewWriter(&qp)
+	w.Write(content)
+	w.Close()
+	encoded := base64Lines(content)
+	if qp.Len() <= len(encoded) {
+		b.setHeader("Content-Transfer-Encoding", "quoted-printable")
+		b.content = qp.Bytes()
+	} else {
+		b.setHeader("Content-Transfer-Encoding", "base64")
+		b.content = encoded
+	}
+}
@@ -0,0 +1,88 @@
+package rfc5322_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aethiopicuschan/rfc5322-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetText(t *testing.T) {
+	testCases := []struct {
+		name     string
+		text     string
+		charset  string
+		header   string
+		content  string
+		expected error
+	}{
+		{"ISO2022JP", "こんにちは\n世界", "ISO-2022-JP", "Content-Transfer-Encoding: 7bit", "\x1b$B$3$s$K$A$O\x1b(B\r\n\x1b$B@$3&\x1b(B", nil},
+		{"ShiftJIS", "日本語のテキスト", "Shift_JIS", "Content-Transfer-Encoding: base64", "k/qWe4zqgsyDZYNMg1iDZw==", nil},
+		{"Windows1252", "Café €5", "windows-1252", "Content-Transfer-Encoding: quoted-printable", "Caf=E9 =805", nil},
+		{"UTF8", "Hello", "", "Content-Transfer-Encoding: 7bit", "Hello", nil},
+		{"Unencodable", "日本語", "windows-1252", "", "", rfc5322.ErrorUnencodableText},
+		{"UnknownCharset", "Hello", "x-unknown", "", "", rfc5322.ErrorUnknownCharset},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			b := rfc5322.NewBody()
+			err := b.SetText(tc.text, tc.charset)
+			if tc.expected != nil {
+				assert.ErrorIs(t, err, tc.expected)
+				return
+			}
+			assert.NoError(t, err)
+			s := b.String()
+			assert.Contains(t, s, tc.header+"\r\n")
+			assert.True(t, strings.HasSuffix(s, "\r\n\r\n"+tc.content))
+
+			parsed, err := rfc5322.ParseBody(strings.NewReader(s))
+			assert.NoError(t, err)
+			text, err := parsed.Text()
+			assert.NoError(t, err)
+			assert.Equal(t, strings.ReplaceAll(tc.text, "\n", "\r\n"), text)
+		})
+	}
+}
+
+func TestText(t *testing.T) {
+	message := "Date: Sun, 01 Oct 2023 12:00:00 +0000\r\n" +
+		"From: =?ISO-2022-JP?B?GyRCOzNFRBsoQg==?= <yamada@example.jp>\r\n" +
+		"Subject: =?Shift_JIS?B?k/qWe4zq?=\r\n" +
+		"Content-Type: text/plain; charset=x-sjis\r\n" +
+		"Content-Transfer-Encoding: base64\r\n" +
+		"\r\n" +
+		"k/qWe4zq\r\n"
+	parsed, err := rfc5322.Parse(strings.NewReader(message))
+	assert.NoError(t, err)
+	assert.Equal(t, "日本語", parsed.Header().Subject().Unwrap())
+	assert.Equal(t, "山田 <yamada@example.jp>", parsed.Header().From()[0].Value())
+	text, err := parsed.Body().Text()
+	assert.NoError(t, err)
+	assert.Equal(t, "日本語", text)
+
+	// Content without a charset is taken as UTF-8 if it is valid.
+	b := rfc5322.NewBody()
+	b.SetContent([]byte("Café"))
+	text, err = b.Text()
+	assert.NoError(t, err)
+	assert.Equal(t, "Café", text)
+
+	assert.NoError(t, b.SetHeader("Content-Type", "text/plain; charset=x-unknown"))
+	_, err = b.Text()
+	assert.ErrorIs(t, err, rfc5322.ErrorUnknownCharset)
+}
+
+func TestInlineBodyCSSCharset(t *testing.T) {
+	html := rfc5322.NewBody()
+	assert.NoError(t, html.SetHeader("Content-Type", "text/html"))
+	assert.NoError(t, html.SetText("<style>p { color: red }</style><p>日本語</p>", "Shift_JIS"))
+	assert.NoError(t, rfc5322.InlineBodyCSS(html))
+	assert.Contains(t, html.String(), "Content-Type: text/html; charset=Shift_JIS\r\n")
+	text, err := html.Text()
+	assert.NoError(t, err)
+	assert.Contains(t, text, `<p style="color: red">日本語</p>`)
+}
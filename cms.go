@@ -0,0 +1,665 @@
+package rfc5322
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"hash"
+	"math/big"
+	"time"
+)
+
+// This file implements the subset of the Cryptographic Message Syntax (RFC 5652) needed for S/MIME:
+// SignedData with a single signer, and EnvelopedData for RSA key transport with AES-CBC.
+
+var (
+	oidData                   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	oidSignedData             = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidEnvelopedData          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 3}
+	oidAttributeContentType   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 3}
+	oidAttributeMessageDigest = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 4}
+	oidAttributeSigningTime   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 5}
+	oidSHA1                   = asn1.ObjectIdentifier{1, 3, 14, 3, 2, 26}
+	oidSHA256                 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidSHA384                 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 2}
+	oidSHA512                 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 3}
+	oidRSAEncryption          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+	oidRSAESOAEP              = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 7}
+	oidECDSAWithSHA256        = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}
+	oidEd25519                = asn1.ObjectIdentifier{1, 3, 101, 112}
+	oidAES128CBC              = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 2}
+	oidAES192CBC              = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 22}
+	oidAES256CBC              = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+)
+
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"explicit,optional,tag:0"`
+}
+
+type signedData struct {
+	Version          int
+	DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+	EncapContentInfo encapsulatedContentInfo
+	Certificates     asn1.RawValue `asn1:"optional,tag:0"`
+	CRLs             asn1.RawValue `asn1:"optional,tag:1"`
+	SignerInfos      []signerInfo  `asn1:"set"`
+}
+
+type encapsulatedContentInfo struct {
+	EContentType asn1.ObjectIdentifier
+	EContent     asn1.RawValue `asn1:"explicit,optional,tag:0"`
+}
+
+type signerInfo struct {
+	Version            int
+	SID                issuerAndSerialNumber
+	DigestAlgorithm    pkix.AlgorithmIdentifier
+	SignedAttrs        asn1.RawValue `asn1:"optional,tag:0"`
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          []byte
+	UnsignedAttrs      asn1.RawValue `asn1:"optional,tag:1"`
+}
+
+type issuerAndSerialNumber struct {
+	Issuer       asn1.RawValue
+	SerialNumber *big.Int
+}
+
+type attribute struct {
+	Type   asn1.ObjectIdentifier
+	Values []asn1.RawValue `asn1:"set"`
+}
+
+type envelopedData struct {
+	Version              int
+	OriginatorInfo       asn1.RawValue   `asn1:"optional,tag:0"`
+	RecipientInfos       []asn1.RawValue `asn1:"set"`
+	EncryptedContentInfo encryptedContentInfo
+	UnprotectedAttrs     asn1.RawValue `asn1:"optional,tag:1"`
+}
+
+type keyTransRecipientInfo struct {
+	Version                int
+	RID                    issuerAndSerialNumber
+	KeyEncryptionAlgorithm pkix.AlgorithmIdentifier
+	EncryptedKey           []byte
+}
+
+type encryptedContentInfo struct {
+	ContentType                asn1.ObjectIdentifier
+	ContentEncryptionAlgorithm pkix.AlgorithmIdentifier
+	EncryptedContent           asn1.RawValue `asn1:"optional,tag:0"`
+}
+
+type rsaesOAEPParams struct {
+	HashFunc pkix.AlgorithmIdentifier `asn1:"optional,explicit,tag:0"`
+}
+
+// cmsSign creates a detached SignedData over the content with signed attributes, and returns its DER encoding.
+func cmsSign(content []byte, cert *x509.Certificate, key crypto.Signer, intermediates []*x509.Certificate, signingTime time.Time) (der []byte, err error) {
+	digest := sha256.Sum256(content)
+	attrs, err := marshalAttributes(digest[:], signingTime)
+	if err != nil {
+		return
+	}
+
+	var sigAlg asn1.ObjectIdentifier
+	var signature []byte
+	switch key.Public().(type) {
+	case *rsa.PublicKey:
+		sigAlg = oidRSAEncryption
+		h := sha256.Sum256(attrs)
+		signature, err = key.Sign(rand.Reader, h[:], crypto.SHA256)
+	case *ecdsa.PublicKey:
+		sigAlg = oidECDSAWithSHA256
+		h := sha256.Sum256(attrs)
+		signature, err = key.Sign(rand.Reader, h[:], crypto.SHA256)
+	case ed25519.PublicKey:
+		sigAlg = oidEd25519
+		signature, err = key.Sign(rand.Reader, attrs, crypto.Hash(0))
+	default:
+		err = ErrorUnsupportedAlgorithm
+	}
+	if err != nil {
+		return
+	}
+
+	var certs []byte
+	for _, c := range append([]*x509.Certificate{cert}, intermediates...) {
+		certs = append(certs, c.Raw...)
+	}
+	// The signed attributes are signed as a SET OF, but encoded with an implicit [0] tag.
+	signedAttrs := append([]byte{0xa0}, attrs[1:]...)
+	sd := signedData{
+		Version:          1,
+		DigestAlgorithms: []pkix.AlgorithmIdentifier{{Algorithm: oidSHA256}},
+		EncapContentInfo: encapsulatedContentInfo{EContentType: oidData},
+		Certificates:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: certs},
+		SignerInfos: []signerInfo{{
+			Version:            1,
+			SID:                issuerAndSerialNumber{Issuer: asn1.RawValue{FullBytes: cert.RawIssuer}, SerialNumber: cert.SerialNumber},
+			DigestAlgorithm:    pkix.AlgorithmIdentifier{Algorithm: oidSHA256},
+			SignedAttrs:        asn1.RawValue{FullBytes: signedAttrs},
+			SignatureAlgorithm: pkix.AlgorithmIdentifier{Algorithm: sigAlg},
+			Signature:          signature,
+		}},
+	}
+	return marshalContentInfo(oidSignedData, sd)
+}
+
+// marshalAttributes returns the DER encoding of the signed attributes as a SET OF.
+func marshalAttributes(digest []byte, signingTime time.Time) (der []byte, err error) {
+	values := []struct {
+		oid   asn1.ObjectIdentifier
+		value any
+	}{
+		{oidAttributeContentType, oidData},
+		{oidAttributeMessageDigest, digest},
+		{oidAttributeSigningTime, signingTime.UTC()},
+	}
+	attrs := make([]attribute, 0, len(values))
+	for _, v := range values {
+		var b []byte
+		if b, err = asn1.Marshal(v.value); err != nil {
+			return
+		}
+		attrs = append(attrs, attribute{Type: v.oid, Values: []asn1.RawValue{{FullBytes: b}}})
+	}
+	return asn1.MarshalWithParams(attrs, "set")
+}
+
+// marshalContentInfo wraps the content in a ContentInfo and returns its DER encoding.
+func marshalContentInfo(contentType asn1.ObjectIdentifier, content any) (der []byte, err error) {
+	b, err := asn1.Marshal(content)
+	if err != nil {
+		return
+	}
+	return asn1.Marshal(contentInfo{
+		ContentType: contentType,
+		Content:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: b},
+	})
+}
+
+// parseContentInfo parses a BER or DER encoded ContentInfo of the expected type and returns the DER encoding of its content.
+func parseContentInfo(ber []byte, expected asn1.ObjectIdentifier) (content []byte, err error) {
+	der, err := berToDER(ber)
+	if err != nil {
+		return
+	}
+	var ci contentInfo
+	if rest, e := asn1.Unmarshal(der, &ci); e != nil || len(rest) > 0 {
+		err = ErrorInvalidCMS
+		return
+	}
+	if !ci.ContentType.Equal(expected) {
+		err = ErrorInvalidCMS
+		return
+	}
+	content = ci.Content.Bytes
+	return
+}
+
+// cmsVerify verifies a SignedData. If content is nil, the encapsulated content is verified and returned.
+// It returns the certificates of the signers, after verifying their chains with opts.
+func cmsVerify(ber []byte, content []byte, opts x509.VerifyOptions) (verified []byte, signers []*x509.Certificate, err error) {
+	b, err := parseContentInfo(ber, oidSignedData)
+	if err != nil {
+		return
+	}
+	var sd signedData
+	if _, err = asn1.Unmarshal(b, &sd); err != nil {
+		err = ErrorInvalidCMS
+		return
+	}
+	if content == nil {
+		if len(sd.EncapContentInfo.EContent.Bytes) == 0 {
+			err = ErrorInvalidCMS
+			return
+		}
+		var octets []byte
+		if _, err = asn1.Unmarshal(sd.EncapContentInfo.EContent.Bytes, &octets); err != nil {
+			err = ErrorInvalidCMS
+			return
+		}
+		content = octets
+	}
+	certs, err := x509.ParseCertificates(sd.Certificates.Bytes)
+	if err != nil {
+		err = ErrorInvalidCMS
+		return
+	}
+	if len(sd.SignerInfos) == 0 {
+		err = ErrorInvalidSignature
+		return
+	}
+
+	if opts.Intermediates == nil {
+		opts.Intermediates = x509.NewCertPool()
+		for _, c := range certs {
+			opts.Intermediates.AddCert(c)
+		}
+	}
+	if len(opts.KeyUsages) == 0 {
+		opts.KeyUsages = []x509.ExtKeyUsage{x509.ExtKeyUsageEmailProtection}
+	}
+	for _, si := range sd.SignerInfos {
+		var cert *x509.Certificate
+		for _, c := range certs {
+			if bytes.Equal(c.RawIssuer, si.SID.Issuer.FullBytes) && c.SerialNumber.Cmp(si.SID.SerialNumber) == 0 {
+				cert = c
+				break
+			}
+		}
+		if cert == nil {
+			err = ErrorInvalidSignature
+			return
+		}
+		if err = verifySignerInfo(si, cert, content); err != nil {
+			return
+		}
+		if _, err = cert.Verify(opts); err != nil {
+			return
+		}
+		signers = append(signers, cert)
+	}
+	verified = content
+	return
+}
+
+// verifySignerInfo verifies the signature of a single signer over the content.
+// SHA-1 digests are rejected, since SHA-1 is no longer collision resistant, as per RFC 8551 section 2.1.
+func verifySignerInfo(si signerInfo, cert *x509.Certificate, content []byte) (err error) {
+	if si.DigestAlgorithm.Algorithm.Equal(oidSHA1) {
+		return ErrorUnsupportedAlgorithm
+	}
+	h, hashFunc, err := newHash(si.DigestAlgorithm.Algorithm)
+	if err != nil {
+		return
+	}
+	h.Write(content)
+	digest := h.Sum(nil)
+
+	signed := content
+	if len(si.SignedAttrs.FullBytes) > 0 {
+		var attrs []attribute
+		// The signature is computed over the attributes encoded as a SET OF.
+		signed = append([]byte{0x31}, si.SignedAttrs.FullBytes[1:]...)
+		if _, err = asn1.UnmarshalWithParams(signed, &attrs, "set"); err != nil {
+			return ErrorInvalidCMS
+		}
+		// The content-type attribute must be present and match the signed content, as per RFC 5652 section 11.1.
+		var messageDigest []byte
+		var contentType asn1.ObjectIdentifier
+		for _, attr := range attrs {
+			switch {
+			case attr.Type.Equal(oidAttributeMessageDigest) && len(attr.Values) == 1:
+				asn1.Unmarshal(attr.Values[0].FullBytes, &messageDigest)
+			case attr.Type.Equal(oidAttributeContentType) && len(attr.Values) == 1:
+				asn1.Unmarshal(attr.Values[0].FullBytes, &contentType)
+			}
+		}
+		if !contentType.Equal(oidData) || !bytes.Equal(messageDigest, digest) {
+			return ErrorInvalidSignature
+		}
+		h.Reset()
+		h.Write(signed)
+		digest = h.Sum(nil)
+	}
+
+	switch pub := cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		err = rsa.VerifyPKCS1v15(pub, hashFunc, digest, si.Signature)
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(pub, digest, si.Signature) {
+			err = ErrorInvalidSignature
+		}
+	case ed25519.PublicKey:
+		if !ed25519.Verify(pub, signed, si.Signature) {
+			err = ErrorInvalidSignature
+		}
+	default:
+		err = ErrorUnsupportedAlgorithm
+	}
+	if err != nil && !errors.Is(err, ErrorUnsupportedAlgorithm) {
+		err = ErrorInvalidSignature
+	}
+	return
+}
+
+// newHash returns the hash function for the digest algorithm.
+func newHash(oid asn1.ObjectIdentifier) (h hash.Hash, hashFunc crypto.Hash, err error) {
+	switch {
+	case oid.Equal(oidSHA1):
+		return sha1.New(), crypto.SHA1, nil
+	case oid.Equal(oidSHA256):
+		return sha256.New(), crypto.SHA256, nil
+	case oid.Equal(oidSHA384):
+		return sha512.New384(), crypto.SHA384, nil
+	case oid.Equal(oidSHA512):
+		return sha512.New(), crypto.SHA512, nil
+	}
+	err = ErrorUnsupportedAlgorithm
+	return
+}
+
+// cmsEncrypt encrypts the content with AES-256-CBC for the RSA recipients, and returns the DER encoding of the EnvelopedData.
+func cmsEncrypt(content []byte, recipients []*x509.Certificate) (der []byte, err error) {
+	key := make([]byte, 32)
+	iv := make([]byte, aes.BlockSize)
+	if _, err = rand.Read(key); err != nil {
+		return
+	}
+	if _, err = rand.Read(iv); err != nil {
+		return
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return
+	}
+	padding := aes.BlockSize - len(content)%aes.BlockSize
+	encrypted := append(bytes.Clone(content), bytes.Repeat([]byte{byte(padding)}, padding)...)
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(encrypted, encrypted)
+
+	infos := make([]asn1.RawValue, 0, len(recipients))
+	for _, cert := range recipients {
+		pub, ok := cert.PublicKey.(*rsa.PublicKey)
+		if !ok {
+			err = ErrorUnsupportedAlgorithm
+			return
+		}
+		var encryptedKey, b []byte
+		if encryptedKey, err = rsa.EncryptPKCS1v15(rand.Reader, pub, key); err != nil {
+			return
+		}
+		b, err = asn1.Marshal(keyTransRecipientInfo{
+			Version:                0,
+			RID:                    issuerAndSerialNumber{Issuer: asn1.RawValue{FullBytes: cert.RawIssuer}, SerialNumber: cert.SerialNumber},
+			KeyEncryptionAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidRSAEncryption, Parameters: asn1.NullRawValue},
+			EncryptedKey:           encryptedKey,
+		})
+		if err != nil {
+			return
+		}
+		infos = append(infos, asn1.RawValue{FullBytes: b})
+	}
+
+	params, err := asn1.Marshal(iv)
+	if err != nil {
+		return
+	}
+	ed := envelopedData{
+		Version:        0,
+		RecipientInfos: infos,
+		EncryptedContentInfo: encryptedContentInfo{
+			ContentType:                oidData,
+			ContentEncryptionAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidAES256CBC, Parameters: asn1.RawValue{FullBytes: params}},
+			EncryptedContent:           asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, Bytes: encrypted},
+		},
+	}
+	return marshalContentInfo(oidEnvelopedData, ed)
+}
+
+// cmsDecrypt decrypts an EnvelopedData for the recipient with the certificate and key.
+func cmsDecrypt(ber []byte, cert *x509.Certificate, key crypto.Decrypter) (content []byte, err error) {
+	b, err := parseContentInfo(ber, oidEnvelopedData)
+	if err != nil {
+		return
+	}
+	var ed envelopedData
+	if _, err = asn1.Unmarshal(b, &ed); err != nil {
+		err = ErrorInvalidCMS
+		return
+	}
+
+	eci := ed.EncryptedContentInfo
+	var keyLen int
+	switch alg := eci.ContentEncryptionAlgorithm.Algorithm; {
+	case alg.Equal(oidAES128CBC):
+		keyLen = 16
+	case alg.Equal(oidAES192CBC):
+		keyLen = 24
+	case alg.Equal(oidAES256CBC):
+		keyLen = 32
+	default:
+		err = ErrorUnsupportedAlgorithm
+		return
+	}
+
+	var cek []byte
+	found := false
+	for _, raw := range ed.RecipientInfos {
+		var ri keyTransRecipientInfo
+		if _, e := asn1.Unmarshal(raw.FullBytes, &ri); e != nil {
+			// Other kinds of RecipientInfo are not supported.
+			continue
+		}
+		if !bytes.Equal(ri.RID.Issuer.FullBytes, cert.RawIssuer) || ri.RID.SerialNumber.Cmp(cert.SerialNumber) != 0 {
+			continue
+		}
+		found = true
+		switch {
+		case ri.KeyEncryptionAlgorithm.Algorithm.Equal(oidRSAEncryption):
+			// A random key is returned for an invalid padding, so that it cannot be told apart from a wrong key,
+			// as per RFC 3218 section 2.3.2.
+			cek, err = key.Decrypt(rand.Reader, ri.EncryptedKey, &rsa.PKCS1v15DecryptOptions{SessionKeyLen: keyLen})
+		case ri.KeyEncryptionAlgorithm.Algorithm.Equal(oidRSAESOAEP):
+			// The hash function defaults to SHA-1 as per RFC 4055 section 4.1.
+			hashFunc := crypto.SHA1
+			var params rsaesOAEPParams
+			if len(ri.KeyEncryptionAlgorithm.Parameters.FullBytes) > 0 {
+				if _, e := asn1.Unmarshal(ri.KeyEncryptionAlgorithm.Parameters.FullBytes, &params); e == nil && len(params.HashFunc.Algorithm) > 0 {
+					if _, hashFunc, err = newHash(params.HashFunc.Algorithm); err != nil {
+						return
+					}
+				}
+			}
+			cek, err = key.Decrypt(rand.Reader, ri.EncryptedKey, &rsa.OAEPOptions{Hash: hashFunc})
+		default:
+			err = ErrorUnsupportedAlgorithm
+		}
+		break
+	}
+	if !found {
+		err = ErrorNoRecipient
+		return
+	}
+	if err != nil {
+		if !errors.Is(err, ErrorUnsupportedAlgorithm) {
+			err = ErrorDecryption
+		}
+		return
+	}
+	if len(cek) != keyLen {
+		err = ErrorDecryption
+		return
+	}
+
+	var iv []byte
+	if _, err = asn1.Unmarshal(eci.ContentEncryptionAlgorithm.Parameters.FullBytes, &iv); err != nil || len(iv) != aes.BlockSize {
+		err = ErrorInvalidCMS
+		return
+	}
+	encrypted := eci.EncryptedContent.Bytes
+	if eci.EncryptedContent.IsCompound {
+		// The constructed form is made of OCTET STRING segments.
+		if encrypted, err = concatOctetStrings(encrypted); err != nil {
+			return
+		}
+	}
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		err = ErrorDecryption
+		return
+	}
+	if len(encrypted) == 0 || len(encrypted)%aes.BlockSize != 0 {
+		err = ErrorDecryption
+		return
+	}
+	content = make([]byte, len(encrypted))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(content, encrypted)
+	padding := int(content[len(content)-1])
+	if padding == 0 || padding > aes.BlockSize {
+		err = ErrorDecryption
+		return
+	}
+	// All the padding bytes are checked, as per RFC 5652 section 6.3.
+	valid := 1
+	for _, c := range content[len(content)-padding:] {
+		valid &= subtle.ConstantTimeByteEq(c, byte(padding))
+	}
+	if valid != 1 {
+		err = ErrorDecryption
+		return
+	}
+	content = content[:len(content)-padding]
+	return
+}
+
+// concatOctetStrings concatenates a sequence of DER encoded OCTET STRING values.
+func concatOctetStrings(b []byte) (out []byte, err error) {
+	for len(b) > 0 {
+		var octets []byte
+		if b, err = asn1.Unmarshal(b, &octets); err != nil {
+			err = ErrorInvalidCMS
+			return
+		}
+		out = append(out, octets...)
+	}
+	return
+}
+
+// berToDER converts BER into DER, as far as needed to parse CMS produced by common mail clients:
+// indefinite lengths are replaced with definite lengths, and constructed OCTET STRING values are flattened.
+func berToDER(ber []byte) (der []byte, err error) {
+	der, rest, err := convertBER(ber, 0)
+	if err == nil && len(rest) > 0 {
+		err = ErrorInvalidCMS
+	}
+	return
+}
+
+// maxBERDepth limits the nesting of BER values.
+const maxBERDepth = 64
+
+// convertBER converts a single BER value into DER, and returns the rest of the input.
+func convertBER(b []byte, depth int) (der []byte, rest []byte, err error) {
+	if depth > maxBERDepth || len(b) < 2 {
+		return nil, nil, ErrorInvalidCMS
+	}
+	// Identifier octets.
+	idLen := 1
+	if b[0]&0x1f == 0x1f {
+		for idLen < len(b) && b[idLen]&0x80 != 0 {
+			idLen++
+		}
+		idLen++
+	}
+	if idLen >= len(b) {
+		return nil, nil, ErrorInvalidCMS
+	}
+	identifier := b[:idLen]
+	constructed := b[0]&0x20 != 0
+
+	// Length octets.
+	b = b[idLen:]
+	indefinite := false
+	length := 0
+	switch {
+	case b[0] == 0x80:
+		indefinite = true
+		b = b[1:]
+	case b[0]&0x80 == 0:
+		length = int(b[0])
+		b = b[1:]
+	default:
+		n := int(b[0] & 0x7f)
+		if n > 4 || n+1 > len(b) {
+			return nil, nil, ErrorInvalidCMS
+		}
+		for _, c := range b[1 : n+1] {
+			length = length<<8 | int(c)
+		}
+		b = b[n+1:]
+	}
+	if !indefinite && length > len(b) {
+		return nil, nil, ErrorInvalidCMS
+	}
+
+	if !constructed {
+		if indefinite {
+			return nil, nil, ErrorInvalidCMS
+		}
+		return encodeTLV(identifier, b[:length]), b[length:], nil
+	}
+
+	var children []byte
+	var contents []byte
+	if indefinite {
+		contents = b
+	} else {
+		contents = b[:length]
+		rest = b[length:]
+	}
+	octetString := len(identifier) == 1 && identifier[0] == 0x24
+	var flattened []byte
+	for {
+		if indefinite && len(contents) >= 2 && contents[0] == 0 && contents[1] == 0 {
+			rest = contents[2:]
+			break
+		}
+		if len(contents) == 0 {
+			if indefinite {
+				return nil, nil, ErrorInvalidCMS
+			}
+			break
+		}
+		var child []byte
+		if child, contents, err = convertBER(contents, depth+1); err != nil {
+			return
+		}
+		if octetString {
+			var octets []byte
+			if _, e := asn1.Unmarshal(child, &octets); e != nil {
+				return nil, nil, ErrorInvalidCMS
+			}
+			flattened = append(flattened, octets...)
+		}
+		children = append(children, child...)
+	}
+	if octetString {
+		return encodeTLV([]byte{0x04}, flattened), rest, nil
+	}
+	return encodeTLV(identifier, children), rest, nil
+}
+
+// encodeTLV encodes a value with a definite length.
+func encodeTLV(identifier, contents []byte) []byte {
+	out := bytes.Clone(identifier)
+	n := len(contents)
+	switch {
+	case n < 0x80:
+		out = append(out, byte(n))
+	default:
+		var l []byte
+		for ; n > 0; n >>= 8 {
+			l = append([]byte{byte(n)}, l...)
+		}
+		out = append(out, 0x80|byte(len(l)))
+		out = append(out, l...)
+	}
+	return append(out, contents...)
+}
@@ -0,0 +1,517 @@
+package rfc5322
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// cssDeclaration is a property declaration of a style rule or a style attribute.
+type cssDeclaration struct {
+	property  string
+	value     string
+	important bool
+}
+
+// cssCompound is a compound selector, such as "p.note[lang]".
+type cssCompound struct {
+	tag     string
+	id      string
+	classes []string
+	attrs   []cssAttribute
+}
+
+// cssAttribute is an attribute selector, such as "[lang]" or "[lang=en]".
+type cssAttribute struct {
+	name     string
+	value    string
+	hasValue bool
+}
+
+// cssSelector is a complex selector of compound selectors, where combinators[i] is the combinator
+// between compounds[i] and compounds[i+1], either ' ' for a descendant or '>' for a child.
+type cssSelector struct {
+	compounds   []cssCompound
+	combinators []byte
+}
+
+// cssRule is a style rule with a single selector.
+type cssRule struct {
+	selector     cssSelector
+	specificity  [3]int
+	declarations []cssDeclaration
+}
+
+// InlineCSS inlines the style sheets of the HTML content into the style attributes of its elements.
+// Rules with type, class, id, attribute, descendant and child selectors are inlined by the cascade order,
+// and the other rules and at-rules, such as media queries, are kept in a style element.
+// Style elements for media other than screen are kept as is.
+func InlineCSS(content []byte) (inlined []byte, err error) {
+	doc, err := html.Parse(bytes.NewReader(content))
+	if err != nil {
+		return
+	}
+
+	var rules []cssRule
+	var leftovers []string
+	var styles []*html.Node
+	for n := range doc.Descendants() {
+		if n.Type != html.ElementNode || n.DataAtom != atom.Style {
+			continue
+		}
+		if media := strings.ToLower(strings.TrimSpace(htmlAttribute(n, "media"))); media != "" && media != "all" && media != "screen" {
+			continue
+		}
+		var css strings.Builder
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			css.WriteString(child.Data)
+		}
+		r, leftover := parseCSS(css.String())
+		rules = append(rules, r...)
+		if leftover != "" {
+			leftovers = append(leftovers, leftover)
+		}
+		styles = append(styles, n)
+	}
+	if len(styles) == 0 {
+		return content, nil
+	}
+
+	// The first style element keeps the rules that cannot be inlined.
+	for i, n := range styles {
+		if i == 0 && len(leftovers) > 0 {
+			for n.FirstChild != nil {
+				n.RemoveChild(n.FirstChild)
+			}
+			n.AppendChild(&html.Node{Type: html.TextNode, Data: strings.Join(leftovers, "\n")})
+			continue
+		}
+		n.Parent.RemoveChild(n)
+	}
+
+	for n := range doc.Descendants() {
+		if n.Type == html.ElementNode && n.DataAtom != atom.Style {
+			inlineStyle(n, rules)
+		}
+	}
+	var buf bytes.Buffer
+	if err = html.Render(&buf, doc); err != nil {
+		return
+	}
+	inlined = buf.Bytes()
+	return
+}
+
+// inlineStyle sets the style attribute of the element to the declarations of the matching rules and its own style,
+// in the cascade order of importance, specificity and source order.
+func inlineStyle(n *html.Node, rules []cssRule) {
+	type cascaded struct {
+		declaration cssDeclaration
+		inline      bool
+		specificity [3]int
+		order       int
+	}
+	var declarations []cascaded
+	for i, r := range rules {
+		if !r.selector.match(n, len(r.selector.compounds)-1) {
+			continue
+		}
+		for _, d := range r.declarations {
+			declarations = append(declarations, cascaded{d, false, r.specificity, i})
+		}
+	}
+	if len(declarations) == 0 {
+		return
+	}
+	for _, d := range parseDeclarations(htmlAttribute(n, "style")) {
+		declarations = append(declarations, cascaded{d, true, [3]int{}, len(rules)})
+	}
+	slices.SortStableFunc(declarations, func(a, b cascaded) int {
+		switch {
+		case a.declaration.important != b.declaration.important:
+			return boolCompare(a.declaration.important, b.declaration.important)
+		case a.inline != b.inline:
+			return boolCompare(a.inline, b.inline)
+		}
+		if c := slices.Compare(a.specificity[:], b.specificity[:]); c != 0 {
+			return c
+		}
+		return a.order - b.order
+	})
+
+	// A later declaration of a property replaces an earlier one, and moves to the end to override the related shorthands.
+	var applied []cssDeclaration
+	for _, d := range declarations {
+		applied = slices.DeleteFunc(applied, func(a cssDeclaration) bool { return a.property == d.declaration.property })
+		applied = append(applied, d.declaration)
+	}
+	values := make([]string, 0, len(applied))
+	for _, d := range applied {
+		value := d.property + ": " + d.value
+		if d.important {
+			value += " !important"
+		}
+		values = append(values, value)
+	}
+	setHTMLAttribute(n, "style", strings.Join(values, "; "))
+}
+
+// boolCompare compares false before true.
+func boolCompare(a, b bool) int {
+	if a == b {
+		return 0
+	}
+	if a {
+		return 1
+	}
+	return -1
+}
+
+// setHTMLAttribute sets the attribute of the element, replacing an existing value.
+func setHTMLAttribute(n *html.Node, key, value string) {
+	for i, a := range n.Attr {
+		if a.Namespace == "" && strings.EqualFold(a.Key, key) {
+			n.Attr[i].Val = value
+			return
+		}
+	}
+	n.Attr = append(n.Attr, html.Attribute{Key: key, Val: value})
+}
+
+// parseCSS parses the rules of a style sheet that can be inlined, and returns the other rules and at-rules as CSS text.
+func parseCSS(css string) (rules []cssRule, leftover string) {
+	css = stripCSSComments(css)
+	var kept []string
+	for {
+		css = strings.TrimSpace(css)
+		if css == "" {
+			break
+		}
+		if css[0] == '}' {
+			css = css[1:]
+			continue
+		}
+		open := cssIndex(css, '{')
+		if strings.HasPrefix(css, "@") {
+			// A statement at-rule, such as @import, ends with a semicolon before any block.
+			if semicolon := cssIndex(css, ';'); semicolon >= 0 && (open < 0 || semicolon < open) {
+				kept = append(kept, css[:semicolon+1])
+				css = css[semicolon+1:]
+				continue
+			}
+		}
+		if open < 0 {
+			break
+		}
+		end := cssBlockEnd(css, open)
+		prelude := strings.TrimSpace(css[:open])
+		block := css[open+1 : end]
+		if end < len(css) {
+			css = css[end+1:]
+		} else {
+			css = ""
+		}
+		if strings.HasPrefix(prelude, "@") {
+			kept = append(kept, prelude+" {"+block+"}")
+			continue
+		}
+
+		declarations := parseDeclarations(block)
+		var unsupported []string
+		for _, s := range splitCSS(prelude, ',') {
+			selector, ok := parseSelector(s)
+			if !ok {
+				unsupported = append(unsupported, strings.TrimSpace(s))
+				continue
+			}
+			rules = append(rules, cssRule{selector, selector.specificity(), declarations})
+		}
+		if len(unsupported) > 0 {
+			kept = append(kept, strings.Join(unsupported, ", ")+" {"+block+"}")
+		}
+	}
+	leftover = strings.Join(kept, "\n")
+	return
+}
+
+// stripCSSComments removes the comments of the CSS text.
+func stripCSSComments(css string) string {
+	var buf strings.Builder
+	for {
+		start := strings.Index(css, "/*")
+		if start < 0 {
+			buf.WriteString(css)
+			return buf.String()
+		}
+		buf.WriteString(css[:start])
+		end := strings.Index(css[start+2:], "*/")
+		if end < 0 {
+			return buf.String()
+		}
+		css = css[start+2+end+2:]
+	}
+}
+
+// cssIndex returns the index of the first byte c outside of strings and parentheses, or -1 if there is none.
+func cssIndex(css string, c byte) int {
+	var quote byte
+	depth := 0
+	for i := 0; i < len(css); i++ {
+		switch ch := css[i]; {
+		case quote != 0:
+			if ch == '\\' {
+				i++
+			} else if ch == quote {
+				quote = 0
+			}
+		case ch == '"' || ch == '\'':
+			quote = ch
+		case ch == '(':
+			depth++
+		case ch == ')' && depth > 0:
+			depth--
+		case ch == c && depth == 0:
+			return i
+		}
+	}
+	return -1
+}
+
+// cssBlockEnd returns the index of the brace that closes the block opened at the index, or the length of the text if it is not closed.
+func cssBlockEnd(css string, open int) int {
+	var quote byte
+	depth := 0
+	for i := open; i < len(css); i++ {
+		switch ch := css[i]; {
+		case quote != 0:
+			if ch == '\\' {
+				i++
+			} else if ch == quote {
+				quote = 0
+			}
+		case ch == '"' || ch == '\'':
+			quote = ch
+		case ch == '{':
+			depth++
+		case ch == '}':
+			if depth--; depth == 0 {
+				return i
+			}
+		}
+	}
+	return len(css)
+}
+
+// splitCSS splits the CSS text at the separator outside of strings and parentheses.
+func splitCSS(css string, sep byte) (parts []string) {
+	for {
+		i := cssIndex(css, sep)
+		if i < 0 {
+			return append(parts, css)
+		}
+		parts = append(parts, css[:i])
+		css = css[i+1:]
+	}
+}
+
+// parseDeclarations parses a declaration block, such as the value of a style attribute.
+// Invalid declarations are skipped.
+func parseDeclarations(block string) (declarations []cssDeclaration) {
+	for _, d := range splitCSS(block, ';') {
+		property, value, ok := strings.Cut(d, ":")
+		property = strings.ToLower(strings.TrimSpace(property))
+		value = strings.TrimSpace(value)
+		if !ok || property == "" || value == "" {
+			continue
+		}
+		important := false
+		if i := strings.LastIndex(value, "!"); i >= 0 && strings.EqualFold(strings.TrimSpace(value[i+1:]), "important") {
+			value = strings.TrimSpace(value[:i])
+			important = true
+		}
+		declarations = append(declarations, cssDeclaration{property, value, important})
+	}
+	return
+}
+
+// isCSSName reports whether the byte can be part of an identifier.
+func isCSSName(c byte) bool {
+	return c == '-' || c == '_' || c >= 0x80 || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9')
+}
+
+// cssName returns the identifier at the start of the text and the rest of the text.
+func cssName(s string) (name, rest string) {
+	i := 0
+	for i < len(s) && isCSSName(s[i]) {
+		i++
+	}
+	return s[:i], s[i:]
+}
+
+// parseSelector parses a selector of type, universal, class, id and attribute selectors with descendant and child combinators.
+// It reports false for the other selectors, such as pseudo-classes, which cannot be inlined.
+func parseSelector(s string) (selector cssSelector, ok bool) {
+	s = strings.TrimSpace(s)
+	var combinator byte
+	for s != "" {
+		if s[0] == ' ' || s[0] == '\t' || s[0] == '\n' || s[0] == '\r' || s[0] == '\f' || s[0] == '>' {
+			if s[0] == '>' || combinator == 0 {
+				combinator = ' '
+				if s[0] == '>' {
+					combinator = '>'
+				}
+			}
+			s = s[1:]
+			continue
+		}
+		if len(selector.compounds) > 0 {
+			if combinator == 0 {
+				return
+			}
+			selector.combinators = append(selector.combinators, combinator)
+		} else if combinator == '>' {
+			return
+		}
+		combinator = 0
+
+		var c cssCompound
+		var name string
+		if s[0] == '*' {
+			s = s[1:]
+		} else if name, s = cssName(s); name != "" {
+			c.tag = strings.ToLower(name)
+		}
+		for s != "" && strings.IndexByte(" \t\n\r\f>", s[0]) < 0 {
+			switch s[0] {
+			case '.':
+				if name, s = cssName(s[1:]); name == "" {
+					return
+				}
+				c.classes = append(c.classes, name)
+			case '#':
+				if name, s = cssName(s[1:]); name == "" {
+					return
+				}
+				c.id = name
+			case '[':
+				end := strings.IndexByte(s, ']')
+				if end < 0 {
+					return
+				}
+				attr, value, hasValue := strings.Cut(s[1:end], "=")
+				attr = strings.ToLower(strings.TrimSpace(attr))
+				if attr == "" || strings.ContainsAny(attr, "~|^$*") {
+					return
+				}
+				value = strings.Trim(strings.TrimSpace(value), `"'`)
+				c.attrs = append(c.attrs, cssAttribute{attr, value, hasValue})
+				s = s[end+1:]
+			default:
+				return
+			}
+		}
+		selector.compounds = append(selector.compounds, c)
+	}
+	if len(selector.compounds) == 0 || combinator != 0 && combinator != ' ' {
+		return
+	}
+	return selector, true
+}
+
+// specificity returns the counts of the id, class and attribute, and type selectors.
+func (s cssSelector) specificity() (specificity [3]int) {
+	for _, c := range s.compounds {
+		if c.id != "" {
+			specificity[0]++
+		}
+		specificity[1] += len(c.classes) + len(c.attrs)
+		if c.tag != "" {
+			specificity[2]++
+		}
+	}
+	return
+}
+
+// match reports whether the element matches the selector up to the compound at the index.
+func (s cssSelector) match(n *html.Node, i int) bool {
+	if !s.compounds[i].match(n) {
+		return false
+	}
+	if i == 0 {
+		return true
+	}
+	for parent := n.Parent; parent != nil && parent.Type == html.ElementNode; parent = parent.Parent {
+		if s.match(parent, i-1) {
+			return true
+		}
+		if s.combinators[i-1] == '>' {
+			return false
+		}
+	}
+	return false
+}
+
+// match reports whether the element matches the compound selector.
+func (c cssCompound) match(n *html.Node) bool {
+	if n.Type != html.ElementNode || c.tag != "" && c.tag != n.Data {
+		return false
+	}
+	if c.id != "" && htmlAttribute(n, "id") != c.id {
+		return false
+	}
+	classes := strings.Fields(htmlAttribute(n, "class"))
+	for _, class := range c.classes {
+		if !slices.Contains(classes, class) {
+			return false
+		}
+	}
+	for _, a := range c.attrs {
+		found := false
+		for _, attr := range n.Attr {
+			if attr.Namespace == "" && strings.EqualFold(attr.Key, a.name) && (!a.hasValue || attr.Val == a.value) {
+				found = true
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// transformHTML replaces the content of the text/html parts in the tree of the Body with the result of the function on their text.
+// The content is set by SetText with the same charset, or UTF-8 if the result cannot be represented in it.
+func (b *Body) transformHTML(f func([]byte) ([]byte, error)) (err error) {
+	if b.IsMultipart() {
+		for _, part := range b.parts {
+			if err = part.transformHTML(f); err != nil {
+				return
+			}
+		}
+		return
+	}
+	if b.ContentType() != "text/html" {
+		return
+	}
+	text, err := b.Text()
+	if err != nil {
+		return
+	}
+	content, err := f([]byte(text))
+	if err != nil {
+		return
+	}
+	_, params := b.mediaType()
+	if err = b.SetText(string(content), params["charset"]); err == ErrorUnencodableText {
+		err = b.SetText(string(content), "UTF-8")
+	}
+	return
+}
+
+// InlineBodyCSS inlines the style sheets of the text/html parts in the tree of the Body by InlineCSS.
+func InlineBodyCSS(b *Body) error {
+	return b.transformHTML(InlineCSS)
+}
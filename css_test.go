@@ -0,0 +1,71 @@
+package rfc5322_test
+
+import (
+	"testing"
+
+	"github.com/aethiopicuschan/rfc5322-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInlineCSS(t *testing.T) {
+	testCases := []struct {
+		name     string
+		html     string
+		expected []string
+	}{
+		{
+			"Cascade",
+			`<style>p { color: red; margin: 0 } .note { color: blue } #main p.note { color: green } p { margin-top: 4px }</style>` +
+				`<div id="main"><p class="note" style="font-weight: bold">A</p><p>B</p></div>`,
+			[]string{
+				`<p class="note" style="margin: 0; margin-top: 4px; color: green; font-weight: bold">A</p>`,
+				`<p style="color: red; margin: 0; margin-top: 4px">B</p>`,
+			},
+		},
+		{
+			"Important",
+			`<style>span { color: red !important } span[lang=en] { color: blue }</style><span lang="en" style="color: green">A</span>`,
+			[]string{`<span lang="en" style="color: red !important">A</span>`},
+		},
+		{
+			"Combinators",
+			`<style>ul > li { color: red } div li { font-weight: bold }</style><div><ul><li>A<ol><li>B</li></ol></li></ul></div>`,
+			[]string{`<li style="color: red; font-weight: bold">A<ol><li style="font-weight: bold">B</li></ol></li>`},
+		},
+		{
+			"Leftover",
+			"<html><head><style>/* comment */ a { color: red } a:hover, b { color: blue }\n@media (max-width: 600px) { a { color: green } }</style>" +
+				`<style media="print">a { color: black }</style></head><body><a href="#">A</a><b>B</b></body></html>`,
+			[]string{
+				"<style>a:hover { color: blue }\n@media (max-width: 600px) { a { color: green } }</style>",
+				`<style media="print">a { color: black }</style>`,
+				`<a href="#" style="color: red">A</a><b style="color: blue">B</b>`,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			inlined, err := rfc5322.InlineCSS([]byte(tc.html))
+			assert.NoError(t, err)
+			for _, expected := range tc.expected {
+				assert.Contains(t, string(inlined), expected)
+			}
+		})
+	}
+}
+
+func TestInlineBodyCSS(t *testing.T) {
+	html := rfc5322.NewBody()
+	assert.NoError(t, html.SetHeader("Content-Type", "text/html; charset=UTF-8"))
+	assert.NoError(t, html.SetHeader("Content-Transfer-Encoding", "quoted-printable"))
+	html.SetContent([]byte("<style>p { color: red }</style><p>Caf=C3=A9</p>"))
+	alternative, err := rfc5322.TextAlternative(html)
+	assert.NoError(t, err)
+
+	assert.NoError(t, rfc5322.InlineBodyCSS(alternative))
+	s := alternative.String()
+	assert.Contains(t, s, `<p style=3D"color: red">Caf=C3=A9</p>`)
+	assert.NotContains(t, s, "<style>")
+}
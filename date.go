@@ -16,6 +16,6 @@ func NewDate(t time.Time) (d *Date) {
 }
 
 // String returns the string representation of the Date.
-func (d *Date) String() string {
+func (d Date) String() string {
 	return d.value
 }
@@ -0,0 +1,324 @@
+package rfc5322
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"net"
+	"strings"
+)
+
+// TXTLookup looks up the TXT records of a domain name, like net.Resolver.LookupTXT.
+// It is used to retrieve the public keys of DKIM and ARC signatures.
+type TXTLookup func(ctx context.Context, name string) ([]string, error)
+
+// rawFields splits a message into its header fields, each kept with its folding and trailing CRLF, and its body.
+// The line endings are normalized to CRLF.
+func rawFields(message []byte) (fields []string, body []byte) {
+	data := normalizeLineEndings(message)
+	for len(data) > 0 {
+		i := bytes.Index(data, []byte("\r\n"))
+		if i == 0 {
+			body = data[2:]
+			return
+		}
+		var line string
+		if i < 0 {
+			// The last line of a message without body.
+			line, data = string(data)+"\r\n", nil
+		} else {
+			line, data = string(data[:i+2]), data[i+2:]
+		}
+		if (line[0] == ' ' || line[0] == '\t') && len(fields) > 0 {
+			fields[len(fields)-1] += line
+		} else {
+			fields = append(fields, line)
+		}
+	}
+	return
+}
+
+// rawFieldName returns the name of a raw header field.
+func rawFieldName(f string) string {
+	name, _, _ := strings.Cut(f, ":")
+	return strings.TrimRight(name, " \t")
+}
+
+// rawFieldValue returns the value of a raw header field, unfolded and without leading and trailing whitespace.
+func rawFieldValue(f string) string {
+	_, value, _ := strings.Cut(f, ":")
+	return strings.Trim(strings.ReplaceAll(value, "\r\n", ""), " \t")
+}
+
+// canonicalization is a canonicalization algorithm for header fields and bodies, as per RFC 6376 section 3.4.
+type canonicalization string
+
+const (
+	canonicalizationSimple  canonicalization = "simple"
+	canonicalizationRelaxed canonicalization = "relaxed"
+)
+
+// parseCanonicalization parses the value of a c= tag, such as "relaxed/simple".
+// The default is simple, for both the header and the body.
+func parseCanonicalization(s string) (header, body canonicalization, err error) {
+	header, body = canonicalizationSimple, canonicalizationSimple
+	if s == "" {
+		return
+	}
+	h, b, hasBody := strings.Cut(s, "/")
+	header = canonicalization(h)
+	if hasBody {
+		body = canonicalization(b)
+	}
+	for _, c := range []canonicalization{header, body} {
+		if c != canonicalizationSimple && c != canonicalizationRelaxed {
+			err = ErrorUnsupportedAlgorithm
+			return
+		}
+	}
+	return
+}
+
+// canonicalField canonicalizes a raw header field, as per RFC 6376 sections 3.4.1 and 3.4.2.
+func (c canonicalization) canonicalField(f string) string {
+	if c == canonicalizationSimple {
+		return f
+	}
+	name, value, _ := strings.Cut(f, ":")
+	value = strings.ReplaceAll(value, "\r\n", "")
+	value = strings.Trim(collapseWSP(value), " ")
+	return strings.ToLower(strings.TrimRight(name, " \t")) + ":" + value + "\r\n"
+}
+
+// canonicalBody canonicalizes a body with CRLF line endings, as per RFC 6376 sections 3.4.3 and 3.4.4.
+func (c canonicalization) canonicalBody(body []byte) []byte {
+	if c == canonicalizationRelaxed {
+		lines := strings.Split(string(body), "\r\n")
+		for i, line := range lines {
+			lines[i] = strings.TrimRight(collapseWSP(line), " ")
+		}
+		body = []byte(strings.Join(lines, "\r\n"))
+	}
+	for bytes.HasSuffix(body, []byte("\r\n")) {
+		body = body[:len(body)-2]
+	}
+	if len(body) == 0 {
+		if c == canonicalizationRelaxed {
+			return []byte{}
+		}
+		return []byte("\r\n")
+	}
+	return append(body, "\r\n"...)
+}
+
+// collapseWSP replaces each sequence of spaces and tabs with a single space.
+func collapseWSP(s string) string {
+	var sb strings.Builder
+	wsp := false
+	for i := 0; i < len(s); i++ {
+		if s[i] == ' ' || s[i] == '\t' {
+			wsp = true
+			continue
+		}
+		if wsp {
+			sb.WriteByte(' ')
+			wsp = false
+		}
+		sb.WriteByte(s[i])
+	}
+	if wsp {
+		sb.WriteByte(' ')
+	}
+	return sb.String()
+}
+
+// parseTagList parses a tag list, as per RFC 6376 section 3.2.
+func parseTagList(s string) (tags map[string]string, err error) {
+	tags = make(map[string]string)
+	for spec := range strings.SplitSeq(s, ";") {
+		if strings.TrimSpace(spec) == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(spec, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			err = ErrorInvalidTagList
+			return
+		}
+		if _, exists := tags[name]; exists {
+			err = ErrorInvalidTagList
+			return
+		}
+		tags[name] = strings.TrimSpace(strings.ReplaceAll(value, "\r\n", ""))
+	}
+	return
+}
+
+// stripSignature returns the raw header field with the value of its b= tag removed, and without the trailing CRLF.
+func stripSignature(f string) string {
+	f = strings.TrimSuffix(f, "\r\n")
+	name, value, _ := strings.Cut(f, ":")
+	specs := strings.Split(value, ";")
+	for i, spec := range specs {
+		tag, _, ok := strings.Cut(spec, "=")
+		if ok && strings.TrimSpace(tag) == "b" {
+			specs[i] = spec[:strings.Index(spec, "=")+1]
+		}
+	}
+	return name + ":" + strings.Join(specs, ";")
+}
+
+// removeWSP removes all whitespace from a base64 tag value.
+func removeWSP(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == ' ' || r == '\t' || r == '\r' || r == '\n' {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// selectFields returns the fields to sign or verify for the names of a h= tag, as per RFC 6376 section 5.4.2.
+// Each name selects the last field with that name that has not been selected yet.
+func selectFields(fields []string, names []string) []string {
+	used := make([]bool, len(fields))
+	selected := make([]string, 0, len(names))
+	for _, name := range names {
+		for i := len(fields) - 1; i >= 0; i-- {
+			if !used[i] && strings.EqualFold(rawFieldName(fields[i]), strings.TrimSpace(name)) {
+				used[i] = true
+				selected = append(selected, fields[i])
+				break
+			}
+		}
+	}
+	return selected
+}
+
+// bodyHash returns the base64 SHA-256 hash of the canonicalized body.
+func bodyHash(body []byte, c canonicalization) string {
+	sum := sha256.Sum256(c.canonicalBody(body))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// headerHash returns the SHA-256 hash of the canonicalized fields, followed by the signature field with an empty b= tag.
+func headerHash(fields []string, signature string, c canonicalization) []byte {
+	h := sha256.New()
+	for _, f := range fields {
+		h.Write([]byte(c.canonicalField(f)))
+	}
+	h.Write([]byte(strings.TrimSuffix(c.canonicalField(stripSignature(signature)), "\r\n")))
+	return h.Sum(nil)
+}
+
+// signingAlgorithm returns the name of the signing algorithm for the key, as per RFC 6376 and RFC 8463.
+func signingAlgorithm(key crypto.Signer) (string, error) {
+	switch key.Public().(type) {
+	case *rsa.PublicKey:
+		return "rsa-sha256", nil
+	case ed25519.PublicKey:
+		return "ed25519-sha256", nil
+	}
+	return "", ErrorUnsupportedAlgorithm
+}
+
+// signHash signs the SHA-256 hash and returns the signature in base64.
+func signHash(key crypto.Signer, hash []byte) (string, error) {
+	var opts crypto.SignerOpts = crypto.SHA256
+	if _, ok := key.Public().(ed25519.PublicKey); ok {
+		// Ed25519 signs the hash itself, as per RFC 8463 section 3.
+		opts = crypto.Hash(0)
+	}
+	sig, err := key.Sign(rand.Reader, hash, opts)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(sig), nil
+}
+
+// verifyHash verifies the base64 signature of the SHA-256 hash with the public key.
+func verifyHash(pub crypto.PublicKey, algorithm string, hash []byte, signature string) error {
+	sig, err := base64.StdEncoding.DecodeString(removeWSP(signature))
+	if err != nil {
+		return ErrorInvalidSignature
+	}
+	switch key := pub.(type) {
+	case *rsa.PublicKey:
+		if algorithm != "rsa-sha256" {
+			return ErrorUnsupportedAlgorithm
+		}
+		if rsa.VerifyPKCS1v15(key, crypto.SHA256, hash, sig) != nil {
+			return ErrorInvalidSignature
+		}
+	case ed25519.PublicKey:
+		if algorithm != "ed25519-sha256" {
+			return ErrorUnsupportedAlgorithm
+		}
+		if !ed25519.Verify(key, hash, sig) {
+			return ErrorInvalidSignature
+		}
+	default:
+		return ErrorUnsupportedAlgorithm
+	}
+	return nil
+}
+
+// lookupPublicKey retrieves the public key of the selector from the DNS, as per RFC 6376 section 3.6.2.
+func lookupPublicKey(ctx context.Context, lookup TXTLookup, selector, domain string) (pub crypto.PublicKey, err error) {
+	if lookup == nil {
+		lookup = net.DefaultResolver.LookupTXT
+	}
+	records, err := lookup(ctx, selector+"._domainkey."+domain)
+	if err != nil {
+		return
+	}
+	for _, record := range records {
+		if pub, err = parsePublicKeyRecord(record); err == nil {
+			return
+		}
+	}
+	err = ErrorInvalidPublicKey
+	return
+}
+
+// parsePublicKeyRecord parses a DKIM key record, as per RFC 6376 section 3.6.1.
+func parsePublicKeyRecord(record string) (pub crypto.PublicKey, err error) {
+	tags, err := parseTagList(record)
+	if err != nil {
+		return
+	}
+	if v, ok := tags["v"]; ok && v != "DKIM1" {
+		err = ErrorInvalidPublicKey
+		return
+	}
+	data, err := base64.StdEncoding.DecodeString(removeWSP(tags["p"]))
+	if err != nil || len(data) == 0 {
+		// An empty key means that the key has been revoked.
+		err = ErrorInvalidPublicKey
+		return
+	}
+	switch tags["k"] {
+	case "", "rsa":
+		if pub, err = x509.ParsePKIXPublicKey(data); err != nil {
+			pub, err = x509.ParsePKCS1PublicKey(data)
+		}
+		if _, ok := pub.(*rsa.PublicKey); err != nil || !ok {
+			err = ErrorInvalidPublicKey
+		}
+	case "ed25519":
+		if len(data) != ed25519.PublicKeySize {
+			err = ErrorInvalidPublicKey
+			return
+		}
+		pub = ed25519.PublicKey(data)
+	default:
+		err = ErrorUnsupportedAlgorithm
+	}
+	return
+}
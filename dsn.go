@@ -0,0 +1,191 @@
+package rfc5322
+
+import (
+	"bytes"
+	"net/mail"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// DSNAction is the action performed by the reporting MTA for a recipient, as per RFC 3464 section 2.3.3.
+type DSNAction string
+
+const (
+	DSNActionFailed    DSNAction = "failed"
+	DSNActionDelayed   DSNAction = "delayed"
+	DSNActionDelivered DSNAction = "delivered"
+	DSNActionRelayed   DSNAction = "relayed"
+	DSNActionExpanded  DSNAction = "expanded"
+)
+
+// statusCode matches an enhanced status code, as per RFC 3463 section 2.
+var statusCode = regexp.MustCompile(`^[245]\.\d{1,3}\.\d{1,3}$`)
+
+// RecipientStatus is the delivery status of a recipient, as per RFC 3464 section 2.3.
+// The addresses are rfc822 addresses, the MTAs are DNS names and the diagnostic code is an SMTP reply.
+type RecipientStatus struct {
+	OriginalRecipient string
+	FinalRecipient    string
+	Action            DSNAction
+	// Status is the enhanced status code, such as "5.1.1".
+	Status         string
+	RemoteMTA      string
+	DiagnosticCode string
+	// LastAttemptDate is the time of the last delivery attempt, or the zero time if it is not given.
+	LastAttemptDate time.Time
+	FinalLogID      string
+	// WillRetryUntil is the time until which delivery will be retried for delayed recipients, or the zero time if it is not given.
+	WillRetryUntil time.Time
+}
+
+// DeliveryStatus is the content of a message/delivery-status part, as per RFC 3464 section 2.
+type DeliveryStatus struct {
+	OriginalEnvelopeID string
+	// ReportingMTA is the DNS name of the MTA that attempted the delivery.
+	ReportingMTA    string
+	DSNGateway      string
+	ReceivedFromMTA string
+	// ArrivalDate is the time the message arrived at the reporting MTA, or the zero time if it is not given.
+	ArrivalDate time.Time
+	Recipients  []RecipientStatus
+}
+
+// DSN is a delivery status notification, as per RFC 3464.
+type DSN struct {
+	// Text is the human readable explanation of the notification.
+	Text   string
+	Status DeliveryStatus
+	// Original is the returned message, or nil if it is not returned.
+	Original *EMail
+	// HeadersOnly returns only the header of the original message, as requested by RET=HDRS of RFC 3461.
+	HeadersOnly bool
+}
+
+// Body returns the multipart/report Body of the notification, with a message/delivery-status part.
+func (d DSN) Body() (b *Body, err error) {
+	s := d.Status
+	if s.ReportingMTA == "" || len(s.Recipients) == 0 {
+		err = ErrorInvalidDSN
+		return
+	}
+	var buf bytes.Buffer
+	values := []string{s.OriginalEnvelopeID, s.ReportingMTA, s.DSNGateway, s.ReceivedFromMTA}
+	writeField(&buf, "Original-Envelope-Id", s.OriginalEnvelopeID)
+	writeField(&buf, "Reporting-MTA", "dns; "+s.ReportingMTA)
+	if s.DSNGateway != "" {
+		writeField(&buf, "DSN-Gateway", "dns; "+s.DSNGateway)
+	}
+	if s.ReceivedFromMTA != "" {
+		writeField(&buf, "Received-From-MTA", "dns; "+s.ReceivedFromMTA)
+	}
+	writeDate(&buf, "Arrival-Date", s.ArrivalDate)
+	for _, r := range s.Recipients {
+		if r.FinalRecipient == "" || !statusCode.MatchString(r.Status) {
+			err = ErrorInvalidDSN
+			return
+		}
+		switch r.Action {
+		case DSNActionFailed, DSNActionDelayed, DSNActionDelivered, DSNActionRelayed, DSNActionExpanded:
+		default:
+			err = ErrorInvalidDSN
+			return
+		}
+		values = append(values, r.OriginalRecipient, r.FinalRecipient, r.RemoteMTA, r.DiagnosticCode, r.FinalLogID)
+		buf.WriteString("\r\n")
+		if r.OriginalRecipient != "" {
+			writeField(&buf, "Original-Recipient", "rfc822; "+r.OriginalRecipient)
+		}
+		writeField(&buf, "Final-Recipient", "rfc822; "+r.FinalRecipient)
+		writeField(&buf, "Action", string(r.Action))
+		writeField(&buf, "Status", r.Status)
+		if r.RemoteMTA != "" {
+			writeField(&buf, "Remote-MTA", "dns; "+r.RemoteMTA)
+		}
+		if r.DiagnosticCode != "" {
+			writeField(&buf, "Diagnostic-Code", "smtp; "+r.DiagnosticCode)
+		}
+		writeDate(&buf, "Last-Attempt-Date", r.LastAttemptDate)
+		writeField(&buf, "Final-Log-ID", r.FinalLogID)
+		writeDate(&buf, "Will-Retry-Until", r.WillRetryUntil)
+	}
+	for _, v := range values {
+		if strings.ContainsAny(v, "\r\n\x00") {
+			err = ErrorInvalidFieldValue
+			return
+		}
+	}
+
+	report := NewBody()
+	report.setHeader("Content-Type", "message/delivery-status")
+	report.content = buf.Bytes()
+	return newReport("delivery-status", d.Text, report, d.Original, d.HeadersOnly)
+}
+
+// EMail returns the notification as an EMail with the Header, which is usually from the postmaster of the reporting MTA.
+func (d DSN) EMail(header *Header) (e *EMail, err error) {
+	b, err := d.Body()
+	if err != nil {
+		return
+	}
+	e = NewEMail(header, b)
+	return
+}
+
+// ParseDSN parses the delivery status notification of the EMail.
+// The multipart/report Body with report-type=delivery-status is looked up in the whole tree of the Body.
+func ParseDSN(e *EMail) (d *DSN, err error) {
+	report, ok := e.body.findReport("delivery-status")
+	if !ok {
+		err = ErrorNotReport
+		return
+	}
+	text, machine, original, headersOnly, err := report.reportParts("message/delivery-status")
+	if err != nil {
+		return
+	}
+	content, err := machine.decodedContent()
+	if err != nil {
+		return
+	}
+	blocks := parseFieldBlocks(content)
+	if len(blocks) == 0 {
+		err = ErrorInvalidDSN
+		return
+	}
+
+	d = &DSN{
+		Text:        text,
+		Original:    original,
+		HeadersOnly: headersOnly && original != nil,
+	}
+	fields := blocks[0]
+	d.Status = DeliveryStatus{
+		OriginalEnvelopeID: fieldValue(fields, "Original-Envelope-Id"),
+		ReportingMTA:       typedValue(fields, "Reporting-MTA"),
+		DSNGateway:         typedValue(fields, "DSN-Gateway"),
+		ReceivedFromMTA:    typedValue(fields, "Received-From-MTA"),
+		ArrivalDate:        dateValue(fields, "Arrival-Date"),
+		Recipients:         make([]RecipientStatus, 0, len(blocks)-1),
+	}
+	for _, fields := range blocks[1:] {
+		d.Status.Recipients = append(d.Status.Recipients, RecipientStatus{
+			OriginalRecipient: typedValue(fields, "Original-Recipient"),
+			FinalRecipient:    typedValue(fields, "Final-Recipient"),
+			Action:            DSNAction(strings.ToLower(fieldValue(fields, "Action"))),
+			Status:            fieldValue(fields, "Status"),
+			RemoteMTA:         typedValue(fields, "Remote-MTA"),
+			DiagnosticCode:    typedValue(fields, "Diagnostic-Code"),
+			LastAttemptDate:   dateValue(fields, "Last-Attempt-Date"),
+			FinalLogID:        fieldValue(fields, "Final-Log-ID"),
+			WillRetryUntil:    dateValue(fields, "Will-Retry-Until"),
+		})
+	}
+	return
+}
+
+// dateValue returns the time of the date field with the name, or the zero time if it is not set or invalid.
+func dateValue(fields []field, name string) time.Time {
+	t, _ := mail.ParseDate(fieldValue(fields, name))
+	return t
+}
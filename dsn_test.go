@@ -0,0 +1,192 @@
+package rfc5322_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aethiopicuschan/rfc5322-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestOriginal(t *testing.T) *rfc5322.EMail {
+	t.Helper()
+	header := newTestHeader(t).AddTo(mustAddress(t, "bob@example.net")).
+		AddBcc(mustAddress(t, "dave@example.net")).
+		SetSubject("Lunch")
+	body := rfc5322.NewBody()
+	assert.NoError(t, body.SetHeader("Content-Type", "text/plain; charset=UTF-8"))
+	body.SetContent([]byte("Are you free on Friday?"))
+	return rfc5322.NewEMail(header, body)
+}
+
+func TestDSN(t *testing.T) {
+	attempt := time.Date(2023, 10, 1, 12, 5, 0, 0, time.UTC)
+	dsn := rfc5322.DSN{
+		Text: "Your message could not be delivered.\n",
+		Status: rfc5322.DeliveryStatus{
+			OriginalEnvelopeID: "QQ314159",
+			ReportingMTA:       "mx.example.com",
+			ArrivalDate:        time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC),
+			Recipients: []rfc5322.RecipientStatus{
+				{
+					OriginalRecipient: "bob@example.net",
+					FinalRecipient:    "bob@example.net",
+					Action:            rfc5322.DSNActionFailed,
+					Status:            "5.1.1",
+					RemoteMTA:         "mx.example.net",
+					DiagnosticCode:    "550 5.1.1 User unknown",
+					LastAttemptDate:   attempt,
+				},
+				{
+					FinalRecipient: "carol@example.net",
+					Action:         rfc5322.DSNActionDelayed,
+					Status:         "4.4.1",
+					WillRetryUntil: attempt.Add(72 * time.Hour),
+				},
+			},
+		},
+		Original: newTestOriginal(t),
+	}
+
+	from, err := rfc5322.NewAddressWithName("Mail Delivery System", "postmaster@mx.example.com")
+	assert.NoError(t, err)
+	header := rfc5322.NewHeader(*rfc5322.NewDate(attempt), rfc5322.NewAddresses(*from)).
+		AddTo(mustAddress(t, "alice@example.com")).
+		SetSubject("Undelivered Mail Returned to Sender")
+	email, err := dsn.EMail(header)
+	assert.NoError(t, err)
+	s, err := email.String()
+	assert.NoError(t, err)
+	assert.Contains(t, s, "Content-Type: multipart/report; boundary=")
+	assert.Contains(t, s, "report-type=delivery-status")
+	assert.Contains(t, s, "Content-Type: message/delivery-status\r\n\r\n"+
+		"Original-Envelope-Id: QQ314159\r\n"+
+		"Reporting-MTA: dns; mx.example.com\r\n"+
+		"Arrival-Date: Sun, 01 Oct 2023 12:00:00 +0000\r\n"+
+		"\r\n"+
+		"Original-Recipient: rfc822; bob@example.net\r\n"+
+		"Final-Recipient: rfc822; bob@example.net\r\n"+
+		"Action: failed\r\n"+
+		"Status: 5.1.1\r\n"+
+		"Remote-MTA: dns; mx.example.net\r\n"+
+		"Diagnostic-Code: smtp; 550 5.1.1 User unknown\r\n"+
+		"Last-Attempt-Date: Sun, 01 Oct 2023 12:05:00 +0000\r\n")
+	assert.Contains(t, s, "Content-Type: message/rfc822\r\n\r\nMIME-Version: 1.0\r\n")
+	assert.Contains(t, s, "Are you free on Friday?")
+	assert.NotContains(t, s, "dave@example.net")
+
+	parsed, err := rfc5322.Parse(strings.NewReader(s))
+	assert.NoError(t, err)
+	got, err := rfc5322.ParseDSN(parsed)
+	assert.NoError(t, err)
+	assert.Equal(t, "Your message could not be delivered.\r\n", got.Text)
+	assert.False(t, got.HeadersOnly)
+	assert.Equal(t, dsn.Status.ReportingMTA, got.Status.ReportingMTA)
+	assert.True(t, dsn.Status.ArrivalDate.Equal(got.Status.ArrivalDate))
+	if assert.Len(t, got.Status.Recipients, 2) {
+		bob := got.Status.Recipients[0]
+		assert.Equal(t, "bob@example.net", bob.FinalRecipient)
+		assert.Equal(t, rfc5322.DSNActionFailed, bob.Action)
+		assert.Equal(t, "5.1.1", bob.Status)
+		assert.Equal(t, "550 5.1.1 User unknown", bob.DiagnosticCode)
+		assert.True(t, attempt.Equal(bob.LastAttemptDate))
+		carol := got.Status.Recipients[1]
+		assert.Equal(t, rfc5322.DSNActionDelayed, carol.Action)
+		assert.True(t, attempt.Add(72*time.Hour).Equal(carol.WillRetryUntil))
+	}
+	if assert.NotNil(t, got.Original) {
+		assert.Equal(t, "Lunch", got.Original.Header().Get("Subject"))
+	}
+
+	// Only the header of the original message is returned.
+	dsn.HeadersOnly = true
+	email, err = dsn.EMail(header)
+	assert.NoError(t, err)
+	s, err = email.String()
+	assert.NoError(t, err)
+	assert.Contains(t, s, "Content-Type: text/rfc822-headers\r\n")
+	assert.NotContains(t, s, "Are you free on Friday?")
+	parsed, err = rfc5322.Parse(strings.NewReader(s))
+	assert.NoError(t, err)
+	got, err = rfc5322.ParseDSN(parsed)
+	assert.NoError(t, err)
+	assert.True(t, got.HeadersOnly)
+	assert.Equal(t, "Lunch", got.Original.Header().Get("Subject"))
+}
+
+func TestParseDSN(t *testing.T) {
+	// A bounce as produced by a typical MTA, with LF line endings and comments.
+	message := `Date: Sun, 01 Oct 2023 12:05:00 +0000
+From: MAILER-DAEMON@mx.example.com (Mail Delivery System)
+To: alice@example.com
+Subject: Undelivered Mail Returned to Sender
+MIME-Version: 1.0
+Content-Type: multipart/report; report-type=delivery-status;
+	boundary="BOUNDARY"
+
+--BOUNDARY
+Content-Description: Notification
+Content-Type: text/plain; charset=us-ascii
+
+I'm sorry to have to inform you that your message could not
+be delivered to one or more recipients.
+
+--BOUNDARY
+Content-Description: Delivery report
+Content-Type: message/delivery-status
+
+Reporting-MTA: dns; mx.example.com
+X-Postfix-Queue-ID: 4F1B2C3D4E
+Arrival-Date: Sun,  1 Oct 2023 12:00:00 +0000 (UTC)
+
+Final-Recipient: rfc822; bob@example.net
+Original-Recipient: rfc822;bob@example.net
+Action: failed
+Status: 5.1.1
+Remote-MTA: dns; mx.example.net
+Diagnostic-Code: smtp; 550 5.1.1 <bob@example.net>: Recipient address
+    rejected: User unknown
+
+--BOUNDARY--
+`
+	parsed, err := rfc5322.Parse(strings.NewReader(message))
+	assert.NoError(t, err)
+	dsn, err := rfc5322.ParseDSN(parsed)
+	assert.NoError(t, err)
+	assert.Contains(t, dsn.Text, "could not\nbe delivered")
+	assert.Equal(t, "mx.example.com", dsn.Status.ReportingMTA)
+	assert.False(t, dsn.Status.ArrivalDate.IsZero())
+	assert.Nil(t, dsn.Original)
+	if assert.Len(t, dsn.Status.Recipients, 1) {
+		r := dsn.Status.Recipients[0]
+		assert.Equal(t, "bob@example.net", r.OriginalRecipient)
+		assert.Equal(t, "550 5.1.1 <bob@example.net>: Recipient address    rejected: User unknown", r.DiagnosticCode)
+	}
+
+	_, err = rfc5322.ParseDSN(newTestOriginal(t))
+	assert.ErrorIs(t, err, rfc5322.ErrorNotReport)
+}
+
+func TestDSNInvalid(t *testing.T) {
+	valid := rfc5322.RecipientStatus{FinalRecipient: "bob@example.net", Action: rfc5322.DSNActionFailed, Status: "5.1.1"}
+	testCases := []struct {
+		name   string
+		status rfc5322.DeliveryStatus
+		err    error
+	}{
+		{"NoReportingMTA", rfc5322.DeliveryStatus{Recipients: []rfc5322.RecipientStatus{valid}}, rfc5322.ErrorInvalidDSN},
+		{"NoRecipient", rfc5322.DeliveryStatus{ReportingMTA: "mx.example.com"}, rfc5322.ErrorInvalidDSN},
+		{"InvalidStatus", rfc5322.DeliveryStatus{ReportingMTA: "mx.example.com", Recipients: []rfc5322.RecipientStatus{{FinalRecipient: "bob@example.net", Action: rfc5322.DSNActionFailed, Status: "550"}}}, rfc5322.ErrorInvalidDSN},
+		{"InvalidAction", rfc5322.DeliveryStatus{ReportingMTA: "mx.example.com", Recipients: []rfc5322.RecipientStatus{{FinalRecipient: "bob@example.net", Action: "bounced", Status: "5.1.1"}}}, rfc5322.ErrorInvalidDSN},
+		{"Injection", rfc5322.DeliveryStatus{ReportingMTA: "mx.example.com\r\nAction: delivered", Recipients: []rfc5322.RecipientStatus{valid}}, rfc5322.ErrorInvalidFieldValue},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := rfc5322.DSN{Status: tc.status}.Body()
+			assert.ErrorIs(t, err, tc.err)
+		})
+	}
+}
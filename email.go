@@ -1,6 +1,11 @@
 package rfc5322
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+
+	"github.com/moznion/go-optional"
+)
 
 // Package rfc5322 provides a simple implementation of RFC 5322 email format.
 type EMail struct {
@@ -15,6 +20,16 @@ func NewEMail(header *Header, body *Body) *EMail {
 	}
 }
 
+// Header returns the Header of the EMail.
+func (e *EMail) Header() *Header {
+	return e.header
+}
+
+// Body returns the Body of the EMail.
+func (e *EMail) Body() *Body {
+	return e.body
+}
+
 func (e *EMail) String() (s string, err error) {
 	hs, err := e.header.String()
 	if err != nil {
@@ -24,3 +39,110 @@ func (e *EMail) String() (s string, err error) {
 	s = fmt.Sprintf("%s%s", hs, bs)
 	return
 }
+
+// WithoutBcc returns a copy of the EMail with the Bcc and Resent-Bcc fields removed.
+// The copy is suitable for transmission to the To and Cc recipients.
+func (e *EMail) WithoutBcc() *EMail {
+	header := e.header.Clone()
+	header.bcc = optional.None[Addresses]()
+	header.resentBcc = optional.None[Addresses]()
+	return NewEMail(header, e.body)
+}
+
+// BccCopies returns a copy of the EMail for each Bcc (or Resent-Bcc, if the message is resent) recipient.
+// Each copy only reveals its own recipient in the Bcc field, as described in RFC 5322 section 3.6.3.
+func (e *EMail) BccCopies() []*EMail {
+	copies := make([]*EMail, 0)
+	if e.header.isResent() {
+		for _, bcc := range e.header.resentBcc.TakeOr(nil) {
+			header := e.header.Clone()
+			// The original Bcc recipients are not revealed to the Resent-Bcc recipients.
+			header.bcc = optional.None[Addresses]()
+			header.resentBcc = optional.Some(Addresses{bcc})
+			copies = append(copies, NewEMail(header, e.body))
+		}
+		return copies
+	}
+	for _, bcc := range e.header.bcc.TakeOr(nil) {
+		header := e.header.Clone()
+		header.bcc = optional.Some(Addresses{bcc})
+		copies = append(copies, NewEMail(header, e.body))
+	}
+	return copies
+}
+
+// Envelope represents the SMTP envelope of an EMail.
+type Envelope struct {
+	// From is the reverse-path used for the MAIL FROM command.
+	From string
+	// To is the list of forward-paths used for the RCPT TO commands.
+	To []string
+}
+
+// Envelope returns the SMTP envelope derived from the Header.
+// If the message has Resent fields, the envelope is derived from the Resent block as described in RFC 5322 section 3.6.6.
+// Otherwise, the reverse-path is taken from the Sender field or the first From address,
+// and the forward-paths are taken from the To, Cc and Bcc fields without duplicates.
+func (e *EMail) Envelope() (env Envelope, err error) {
+	h := e.header
+	var sender Address
+	var recipients []Address
+	if h.isResent() {
+		if h.resentSender.IsSome() {
+			sender = h.resentSender.Unwrap()
+		} else if len(h.resentFrom.TakeOr(nil)) > 0 {
+			sender = h.resentFrom.Unwrap()[0]
+		} else {
+			err = ErrorNeedSender
+			return
+		}
+		recipients = append(recipients, h.resentTo.TakeOr(nil)...)
+		recipients = append(recipients, h.resentCc.TakeOr(nil)...)
+		recipients = append(recipients, h.resentBcc.TakeOr(nil)...)
+	} else {
+		if h.sender.IsSome() {
+			sender = h.sender.Unwrap()
+		} else if len(h.from) > 0 {
+			sender = h.from[0]
+		} else {
+			err = ErrorNeedSender
+			return
+		}
+		recipients = append(recipients, h.to.TakeOr(nil)...)
+		recipients = append(recipients, h.cc.TakeOr(nil)...)
+		recipients = append(recipients, h.bcc.TakeOr(nil)...)
+	}
+
+	env.From, err = sender.addrSpec()
+	if err != nil {
+		return
+	}
+	seen := make(map[string]struct{})
+	for _, recipient := range recipients {
+		var addr string
+		addr, err = recipient.addrSpec()
+		if err != nil {
+			return
+		}
+		key := normalizeAddrSpec(addr)
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		env.To = append(env.To, addr)
+	}
+	if len(env.To) == 0 {
+		err = ErrorNeedToCcBcc
+	}
+	return
+}
+
+// normalizeAddrSpec returns a key for comparing addr-specs.
+// The local part is case-sensitive, but the domain is not.
+func normalizeAddrSpec(addr string) string {
+	i := strings.LastIndex(addr, "@")
+	if i < 0 {
+		return addr
+	}
+	return addr[:i] + strings.ToLower(addr[i:])
+}
@@ -0,0 +1,171 @@
+package rfc5322_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aethiopicuschan/rfc5322-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestHeader(t *testing.T) *rfc5322.Header {
+	t.Helper()
+	from, err := rfc5322.NewAddressWithName("Alice", "alice@example.com")
+	assert.NoError(t, err)
+	date := rfc5322.NewDate(time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC))
+	return rfc5322.NewHeader(*date, rfc5322.NewAddresses(*from))
+}
+
+func mustAddress(t *testing.T, value string) rfc5322.Address {
+	t.Helper()
+	addr, err := rfc5322.NewAddress(value)
+	assert.NoError(t, err)
+	return *addr
+}
+
+func TestWithoutBcc(t *testing.T) {
+	header := newTestHeader(t)
+	header.AddTo(mustAddress(t, "bob@example.com"))
+	header.AddBcc(mustAddress(t, "carol@example.com"))
+	email := rfc5322.NewEMail(header, rfc5322.NewBody())
+
+	s, err := email.WithoutBcc().String()
+	assert.NoError(t, err)
+	assert.NotContains(t, s, "Bcc:")
+	assert.Contains(t, s, "To: bob@example.com\r\n")
+
+	// The original EMail is not modified.
+	s, err = email.String()
+	assert.NoError(t, err)
+	assert.Contains(t, s, "Bcc: carol@example.com\r\n")
+}
+
+func TestBccCopies(t *testing.T) {
+	header := newTestHeader(t)
+	header.AddTo(mustAddress(t, "bob@example.com"))
+	header.AddBcc(mustAddress(t, "carol@example.com"))
+	header.AddBcc(mustAddress(t, "dave@example.com"))
+	email := rfc5322.NewEMail(header, rfc5322.NewBody())
+
+	copies := email.BccCopies()
+	assert.Len(t, copies, 2)
+	for i, expected := range []string{"carol@example.com", "dave@example.com"} {
+		s, err := copies[i].String()
+		assert.NoError(t, err)
+		assert.Contains(t, s, "Bcc: "+expected+"\r\n")
+		assert.Equal(t, []string{expected}, copies[i].Header().Values("Bcc"))
+	}
+}
+
+func TestBccCopiesResent(t *testing.T) {
+	header := newTestHeader(t)
+	header.AddTo(mustAddress(t, "bob@example.com"))
+	header.AddBcc(mustAddress(t, "carol@example.com"))
+	header.AddResentTo(mustAddress(t, "dave@example.com"))
+	header.AddResentBcc(mustAddress(t, "erin@example.com"))
+	email := rfc5322.NewEMail(header, rfc5322.NewBody())
+
+	copies := email.BccCopies()
+	if assert.Len(t, copies, 1) {
+		assert.Equal(t, []string{"erin@example.com"}, copies[0].Header().Values("Resent-Bcc"))
+		assert.Empty(t, copies[0].Header().Values("Bcc"))
+	}
+}
+
+func TestEnvelope(t *testing.T) {
+	testCases := []struct {
+		name        string
+		setup       func(h *rfc5322.Header)
+		expected    rfc5322.Envelope
+		expectedErr error
+	}{
+		{
+			name: "to, cc and bcc",
+			setup: func(h *rfc5322.Header) {
+				h.AddTo(mustAddress(t, "Bob <bob@example.com>"))
+				h.AddCc(mustAddress(t, "carol@example.com"))
+				h.AddBcc(mustAddress(t, "dave@example.com"))
+			},
+			expected: rfc5322.Envelope{
+				From: "alice@example.com",
+				To:   []string{"bob@example.com", "carol@example.com", "dave@example.com"},
+			},
+		},
+		{
+			name: "duplicated recipients",
+			setup: func(h *rfc5322.Header) {
+				h.AddTo(mustAddress(t, "bob@example.com"))
+				h.AddCc(mustAddress(t, "bob@EXAMPLE.com"))
+				h.AddBcc(mustAddress(t, "Bob@example.com"))
+			},
+			expected: rfc5322.Envelope{
+				From: "alice@example.com",
+				To:   []string{"bob@example.com", "Bob@example.com"},
+			},
+		},
+		{
+			name: "sender",
+			setup: func(h *rfc5322.Header) {
+				h.SetSender(mustAddress(t, "secretary@example.com"))
+				h.AddTo(mustAddress(t, "bob@example.com"))
+			},
+			expected: rfc5322.Envelope{
+				From: "secretary@example.com",
+				To:   []string{"bob@example.com"},
+			},
+		},
+		{
+			name: "resent",
+			setup: func(h *rfc5322.Header) {
+				h.AddTo(mustAddress(t, "bob@example.com"))
+				h.AddResentFrom(mustAddress(t, "bob@example.com"))
+				h.AddResentTo(mustAddress(t, "carol@example.com"))
+				h.AddResentBcc(mustAddress(t, "dave@example.com"))
+			},
+			expected: rfc5322.Envelope{
+				From: "bob@example.com",
+				To:   []string{"carol@example.com", "dave@example.com"},
+			},
+		},
+		{
+			name: "resent without Resent-From",
+			setup: func(h *rfc5322.Header) {
+				h.AddTo(mustAddress(t, "bob@example.com"))
+				h.SetResentSender(mustAddress(t, "bob@example.com"))
+				h.AddResentTo(mustAddress(t, "carol@example.com"))
+			},
+			expected: rfc5322.Envelope{
+				From: "bob@example.com",
+				To:   []string{"carol@example.com"},
+			},
+		},
+		{
+			name: "resent without resender",
+			setup: func(h *rfc5322.Header) {
+				h.AddTo(mustAddress(t, "bob@example.com"))
+				h.AddResentTo(mustAddress(t, "carol@example.com"))
+			},
+			expectedErr: rfc5322.ErrorNeedSender,
+		},
+		{
+			name:        "no recipients",
+			setup:       func(h *rfc5322.Header) {},
+			expectedErr: rfc5322.ErrorNeedToCcBcc,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			header := newTestHeader(t)
+			tc.setup(header)
+			env, err := rfc5322.NewEMail(header, rfc5322.NewBody()).Envelope()
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expected, env)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package rfc5322
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"strings"
+)
+
+// LineEnding represents the line ending used when writing a message.
+type LineEnding int
+
+const (
+	// CRLF is the line ending of RFC 5322, used on the wire.
+	CRLF LineEnding = iota
+	// LF is the line ending used by most Unix tools.
+	LF
+)
+
+// ReadEML reads an .eml file into an EMail.
+// Line endings are normalized to CRLF, so files with LF or mixed line endings are accepted.
+// The content of binary parts is kept as is, since it may contain bare CR or LF,
+// and the parts of multipart/signed are kept byte for byte, so that their signatures can still be verified.
+func ReadEML(r io.Reader) (e *EMail, err error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return
+	}
+	return Parse(bytes.NewReader(normalizeEntity(data)))
+}
+
+// WriteEML writes the EMail as an .eml file with the given line ending.
+func WriteEML(w io.Writer, e *EMail, ending LineEnding) (err error) {
+	s, err := e.String()
+	if err != nil {
+		return
+	}
+	if ending == LF {
+		s = strings.ReplaceAll(s, "\r\n", "\n")
+	}
+	_, err = io.WriteString(w, s)
+	return
+}
+
+// normalizeEntity converts the line endings of a MIME entity into CRLF, following its structure.
+// The content of binary entities is not converted.
+func normalizeEntity(data []byte) []byte {
+	fields, content := splitMessage(data)
+	normalized := normalizeLineEndings(data[:len(data)-len(content)])
+	entity := &Body{headers: fields}
+	ct, _ := entity.header("Content-Type")
+	if _, params, err := mime.ParseMediaType(ct); err == nil && entity.IsMultipart() && params["boundary"] != "" {
+		return append(normalized, normalizeMultipart(content, params["boundary"])...)
+	}
+	if cte, _ := entity.header("Content-Transfer-Encoding"); strings.EqualFold(strings.TrimSpace(cte), "binary") {
+		return append(normalized, content...)
+	}
+	return append(normalized, normalizeLineEndings(content)...)
+}
+
+// normalizeMultipart converts the line endings of the content of a multipart entity into CRLF, normalizing each part by normalizeEntity.
+// The epilogue is dropped, as it is by the parser.
+func normalizeMultipart(content []byte, boundary string) []byte {
+	preamble, parts := splitMultipart(content, boundary)
+	normalized := normalizeLineEndings(preamble)
+	if len(parts) == 0 {
+		return normalized
+	}
+	if len(normalized) > 0 {
+		normalized = append(normalized, "\r\n"...)
+	}
+	for _, part := range parts {
+		normalized = append(normalized, "--"+boundary+"\r\n"...)
+		normalized = append(normalized, normalizeEntity(part)...)
+		normalized = append(normalized, "\r\n"...)
+	}
+	return append(normalized, "--"+boundary+"--\r\n"...)
+}
+
+// normalizeLineEndings converts LF, CR and CRLF line endings into CRLF.
+func normalizeLineEndings(data []byte) []byte {
+	data = bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+	data = bytes.ReplaceAll(data, []byte("\r"), []byte("\n"))
+	return bytes.ReplaceAll(data, []byte("\n"), []byte("\r\n"))
+}
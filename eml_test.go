@@ -0,0 +1,114 @@
+package rfc5322_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/aethiopicuschan/rfc5322-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadEML(t *testing.T) {
+	input := "Date: Sun, 01 Oct 2023 12:00:00 +0000\n" +
+		"From: alice@example.com\r\n" +
+		"Subject: Hello\n" +
+		"\n" +
+		"line 1\r\n" +
+		"line 2\n" +
+		"line 3\r"
+	email, err := rfc5322.ReadEML(strings.NewReader(input))
+	assert.NoError(t, err)
+
+	testCases := []struct {
+		name     string
+		ending   rfc5322.LineEnding
+		expected string
+	}{
+		{
+			name:   "CRLF",
+			ending: rfc5322.CRLF,
+			expected: "MIME-Version: 1.0\r\n" +
+				"Date: Sun, 01 Oct 2023 12:00:00 +0000\r\n" +
+				"From: alice@example.com\r\n" +
+				"Subject: Hello\r\n" +
+				"\r\n" +
+				"line 1\r\n" +
+				"line 2\r\n" +
+				"line 3\r\n",
+		},
+		{
+			name:   "LF",
+			ending: rfc5322.LF,
+			expected: "MIME-Version: 1.0\n" +
+				"Date: Sun, 01 Oct 2023 12:00:00 +0000\n" +
+				"From: alice@example.com\n" +
+				"Subject: Hello\n" +
+				"\n" +
+				"line 1\n" +
+				"line 2\n" +
+				"line 3\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			var buf bytes.Buffer
+			assert.NoError(t, rfc5322.WriteEML(&buf, email, tc.ending))
+			assert.Equal(t, tc.expected, buf.String())
+		})
+	}
+}
+
+func TestReadEMLSigned(t *testing.T) {
+	signed := "content-type: text/plain;  charset=us-ascii\r\n" +
+		"Content-Transfer-Encoding: 7bit\r\n" +
+		"X-Unusual:   spacing\r\n" +
+		"\r\n" +
+		"Signed text\r\n"
+	input := "Date: Sun, 01 Oct 2023 12:00:00 +0000\n" +
+		"From: alice@example.com\n" +
+		"Content-Type: multipart/signed; protocol=\"application/pgp-signature\"; micalg=pgp-sha256; boundary=\"sig\"\n" +
+		"\n" +
+		"--sig\n" +
+		strings.ReplaceAll(signed, "\r\n", "\n") +
+		"--sig\n" +
+		"Content-Type: application/pgp-signature\n" +
+		"\n" +
+		"SIGNATURE\n" +
+		"--sig--\n"
+	email, err := rfc5322.ReadEML(strings.NewReader(input))
+	assert.NoError(t, err)
+	s, err := email.String()
+	assert.NoError(t, err)
+	assert.Contains(t, s, "--sig\r\n"+signed+"--sig\r\n")
+}
+
+func TestReadEMLTransferEncodings(t *testing.T) {
+	binary := "\x00\x01\r\x02\n\x03\r\n"
+	input := "Date: Sun, 01 Oct 2023 12:00:00 +0000\n" +
+		"From: alice@example.com\n" +
+		"Content-Type: multipart/mixed; boundary=\"b\"\n" +
+		"\n" +
+		"--b\n" +
+		"Content-Type: text/plain; charset=UTF-8\n" +
+		"Content-Transfer-Encoding: 8bit\n" +
+		"\n" +
+		"Héllo\n" +
+		"world\n" +
+		"--b\n" +
+		"Content-Type: application/octet-stream\n" +
+		"Content-Transfer-Encoding: binary\n" +
+		"\n" +
+		binary + "\n" +
+		"--b--\n"
+	email, err := rfc5322.ReadEML(strings.NewReader(input))
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	assert.NoError(t, rfc5322.WriteEML(&buf, email, rfc5322.CRLF))
+	// The 8bit text is line-oriented and gets CRLF line endings, while the binary content is kept byte for byte.
+	assert.Contains(t, buf.String(), "Content-Transfer-Encoding: 8bit\r\n\r\nHéllo\r\nworld\r\n--b\r\n")
+	assert.Contains(t, buf.String(), "Content-Transfer-Encoding: binary\r\n\r\n"+binary+"\r\n--b--\r\n")
+}
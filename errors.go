@@ -7,3 +7,37 @@ var ErrorInvalidName = errors.New("invalid name format")
 var ErrorNeedSender = errors.New("need sender address")
 var ErrorNeedToCcBcc = errors.New("need to, cc, or bcc address")
 var ErrorInvalidMessageID = errors.New("invalid Message-ID format")
+var ErrorInvalidFieldName = errors.New("invalid header field name")
+var ErrorInvalidFieldValue = errors.New("invalid header field value")
+var ErrorNeedDate = errors.New("need date")
+var ErrorNeedFrom = errors.New("need from address")
+var ErrorUnsupportedAlgorithm = errors.New("unsupported algorithm")
+var ErrorInvalidCMS = errors.New("invalid CMS structure")
+var ErrorInvalidSignature = errors.New("invalid signature")
+var ErrorNoRecipient = errors.New("message is not encrypted for the recipient")
+var ErrorDecryption = errors.New("decryption failed")
+var ErrorNotSigned = errors.New("body is not signed")
+var ErrorNotEncrypted = errors.New("body is not encrypted")
+var ErrorInvalidAutocrypt = errors.New("invalid Autocrypt field")
+var ErrorInvalidTagList = errors.New("invalid tag list")
+var ErrorInvalidPublicKey = errors.New("invalid public key record")
+var ErrorInvalidARC = errors.New("invalid ARC set")
+var ErrorInvalidAuthenticationResults = errors.New("invalid Authentication-Results field")
+var ErrorInvalidListID = errors.New("invalid List-Id format")
+var ErrorInvalidListURI = errors.New("invalid list URI")
+var ErrorNeedListUnsubscribeHTTPS = errors.New("need https List-Unsubscribe URI for one-click unsubscription")
+var ErrorEmptyListURIs = errors.New("need list URIs except for List-Post")
+var ErrorNotReport = errors.New("body is not a report")
+var ErrorInvalidDSN = errors.New("invalid delivery status notification")
+var ErrorInvalidMDN = errors.New("invalid message disposition notification")
+var ErrorNoMDNRequest = errors.New("message does not request a disposition notification")
+var ErrorInvalidARF = errors.New("invalid feedback report")
+var ErrorInvalidInvitation = errors.New("invalid calendar invitation")
+var ErrorNotInvitation = errors.New("body is not a calendar invitation")
+var ErrorNotAttendee = errors.New("address is not an attendee of the event")
+var ErrorInvalidTemplate = errors.New("need text or HTML template")
+var ErrorUnknownTemplateFile = errors.New("unknown template file")
+var ErrorNotHTML = errors.New("body is not HTML")
+var ErrorUnknownCharset = errors.New("unknown charset")
+var ErrorUnencodableText = errors.New("text cannot be encoded in the charset")
+var ErrorPartNotFound = errors.New("part not found")
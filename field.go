@@ -0,0 +1,37 @@
+package rfc5322
+
+import "strings"
+
+// validFieldName reports whether name is a valid field name as per RFC 5322 section 3.6.8.
+// A field name consists of printable US-ASCII characters except colon.
+func validFieldName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if c < 33 || c > 126 || c == ':' {
+			return false
+		}
+	}
+	return true
+}
+
+// validFieldValue reports whether value can be written as an unstructured field body.
+// CR and LF are only allowed as a CRLF followed by WSP (folding), and NUL is never allowed.
+func validFieldValue(value string) bool {
+	for i := 0; i < len(value); i++ {
+		switch value[i] {
+		case 0:
+			return false
+		case '\n':
+			return false
+		case '\r':
+			if !strings.HasPrefix(value[i:], "\r\n ") && !strings.HasPrefix(value[i:], "\r\n\t") {
+				return false
+			}
+			i++ // skip LF
+		}
+	}
+	return true
+}
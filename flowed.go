@@ -0,0 +1,201 @@
+package rfc5322
+
+import (
+	"bytes"
+	"mime"
+	"mime/quotedprintable"
+	"strings"
+	"unicode/utf8"
+)
+
+// flowedWidth is the maximum length of an encoded line in characters, including the quote marks and the trailing space.
+const flowedWidth = 78
+
+// signatureSeparator is the signature separator line, which is never flowed.
+const signatureSeparator = "-- "
+
+// EncodeFlowed encodes the text as format=flowed with delsp=yes, as per RFC 3676, with CRLF line endings.
+// Lines longer than 78 characters are soft-wrapped, preferably after spaces, and lines that start with quote marks,
+// either ">>" or "> >", keep their quote depth on each wrapped line.
+// Trailing spaces of the lines are removed, except for the signature separator.
+func EncodeFlowed(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	var buf strings.Builder
+	for i, line := range strings.Split(text, "\n") {
+		if i > 0 {
+			buf.WriteString("\r\n")
+		}
+		if line == signatureSeparator {
+			buf.WriteString(line)
+			continue
+		}
+		depth, content := quoteDepth(line, true)
+		if depth > 0 {
+			content = strings.TrimPrefix(content, " ")
+		}
+		content = strings.TrimRight(content, " ")
+		prefix := strings.Repeat(">", depth)
+		// The space after quote marks, and before content that would be taken as quote marks or an mbox separator, is stuffed.
+		if depth > 0 || strings.HasPrefix(content, " ") || strings.HasPrefix(content, ">") || strings.HasPrefix(content, "From ") {
+			prefix += " "
+		}
+		if content == "" {
+			buf.WriteString(strings.TrimRight(prefix, " "))
+			continue
+		}
+		// The trailing space of a soft line break is deleted by the decoder, as delsp=yes.
+		limit := max(flowedWidth-utf8.RuneCountInString(prefix)-1, 1)
+		for {
+			if utf8.RuneCountInString(content) <= limit+1 {
+				buf.WriteString(prefix + content)
+				break
+			}
+			chunk := flowedChunk(content, limit)
+			buf.WriteString(prefix + chunk + " \r\n")
+			content = content[len(chunk):]
+			// The stuffing of a continuation line is decided by its own content.
+			if depth == 0 {
+				prefix = ""
+				if strings.HasPrefix(content, " ") || strings.HasPrefix(content, ">") || strings.HasPrefix(content, "From ") {
+					prefix = " "
+				}
+			}
+		}
+	}
+	return buf.String()
+}
+
+// flowedChunk returns the first chunk of the content of at most limit characters, which ends after a space if there is one.
+func flowedChunk(content string, limit int) string {
+	end := 0
+	for i := 0; i < limit; i++ {
+		_, size := utf8.DecodeRuneInString(content[end:])
+		end += size
+	}
+	if i := strings.LastIndexByte(content[:end], ' '); i > 0 {
+		return content[:i+1]
+	}
+	return content[:end]
+}
+
+// quoteDepth returns the number of leading quote marks of the line and the content after them.
+// If spaced is true, quote marks separated by single spaces, such as "> > text", are counted as well.
+func quoteDepth(line string, spaced bool) (depth int, content string) {
+	for {
+		if strings.HasPrefix(line, ">") {
+			depth++
+			line = line[1:]
+			continue
+		}
+		if spaced && depth > 0 && strings.HasPrefix(line, " >") {
+			line = line[1:]
+			continue
+		}
+		break
+	}
+	return depth, line
+}
+
+// DecodeFlowed decodes format=flowed content, as per RFC 3676, joining the soft-wrapped lines into paragraphs with LF line endings.
+// The trailing space of a soft line break is deleted if delsp is true. Quoted paragraphs are prefixed with their quote marks and a space.
+func DecodeFlowed(content []byte, delsp bool) string {
+	text := strings.ReplaceAll(string(content), "\r\n", "\n")
+	text = strings.TrimSuffix(text, "\n")
+	var buf, paragraph strings.Builder
+	depth := -1
+	flush := func() {
+		if depth >= 0 {
+			buf.WriteString(strings.TrimRight(quotePrefix(depth)+paragraph.String(), " ") + "\n")
+		}
+		paragraph.Reset()
+		depth = -1
+	}
+	for line := range strings.SplitSeq(text, "\n") {
+		lineDepth, content := quoteDepth(line, false)
+		// The stuffed space is removed.
+		content = strings.TrimPrefix(content, " ")
+		if lineDepth != depth {
+			// A change of quote depth ends the paragraph, even after a soft line break.
+			flush()
+		}
+		if content == signatureSeparator {
+			flush()
+			buf.WriteString(quotePrefix(lineDepth) + signatureSeparator + "\n")
+			continue
+		}
+		depth = lineDepth
+		if strings.HasSuffix(content, " ") {
+			if delsp {
+				content = content[:len(content)-1]
+			}
+			paragraph.WriteString(content)
+			continue
+		}
+		paragraph.WriteString(content)
+		flush()
+	}
+	flush()
+	return buf.String()
+}
+
+// quotePrefix returns the quote marks of the quote depth followed by a space, or an empty string for unquoted text.
+func quotePrefix(depth int) string {
+	if depth <= 0 {
+		return ""
+	}
+	return strings.Repeat(">", depth) + " "
+}
+
+// NewFlowedBody creates a new text/plain Body with the text encoded by EncodeFlowed.
+func NewFlowedBody(text string) *Body {
+	b := NewBody()
+	b.setHeader("Content-Type", mime.FormatMediaType("text/plain", map[string]string{
+		"charset": "UTF-8",
+		"format":  "flowed",
+		"delsp":   "yes",
+	}))
+	content := []byte(EncodeFlowed(text))
+	// The trailing spaces of flowed lines are meaningful and legal in 7bit data, so quoted-printable
+	// is only needed for non-ASCII content or over-long lines.
+	if isShortASCII(content) {
+		b.setHeader("Content-Transfer-Encoding", "7bit")
+		b.content = content
+		return b
+	}
+	var buf bytes.Buffer
+	w := quotedprintable.NewWriter(&buf)
+	w.Write(content)
+	w.Close()
+	b.setHeader("Content-Transfer-Encoding", "quoted-printable")
+	b.content = buf.Bytes()
+	return b
+}
+
+// isShortASCII reports whether the content is ASCII without NUL, in lines of at most 998 octets.
+func isShortASCII(content []byte) bool {
+	for line := range bytes.Lines(content) {
+		line = bytes.TrimRight(line, "\r\n")
+		if len(line) > 998 {
+			return false
+		}
+		for _, c := range line {
+			if c >= 0x80 || c == 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// FlowedText returns the text of a text/plain Body with LF line endings, decoded by Text,
+// and reflowed by DecodeFlowed if it is format=flowed.
+func (b *Body) FlowedText() (text string, err error) {
+	if text, err = b.Text(); err != nil {
+		return
+	}
+	mediaType, params := b.mediaType()
+	if mediaType == "text/plain" && strings.EqualFold(params["format"], "flowed") {
+		return DecodeFlowed([]byte(text), strings.EqualFold(params["delsp"], "yes")), nil
+	}
+	return strings.ReplaceAll(text, "\r\n", "\n"), nil
+}
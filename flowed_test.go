@@ -0,0 +1,103 @@
+package rfc5322_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aethiopicuschan/rfc5322-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodeFlowed(t *testing.T) {
+	long := strings.Repeat("lorem ipsum ", 10)
+	testCases := []struct {
+		name     string
+		text     string
+		expected string
+	}{
+		{"Short", "Hello,\nworld  ", "Hello,\r\nworld"},
+		{
+			"Wrap",
+			strings.TrimSpace(long),
+			"lorem ipsum lorem ipsum lorem ipsum lorem ipsum lorem ipsum lorem ipsum  \r\n" +
+				"lorem ipsum lorem ipsum lorem ipsum lorem ipsum",
+		},
+		{
+			"LongWord",
+			strings.Repeat("x", 100),
+			strings.Repeat("x", 77) + " \r\n" + strings.Repeat("x", 23),
+		},
+		{
+			"Quoted",
+			"> > " + strings.TrimSpace(long) + "\n>\n> quoted",
+			">> lorem ipsum lorem ipsum lorem ipsum lorem ipsum lorem ipsum lorem ipsum  \r\n" +
+				">> lorem ipsum lorem ipsum lorem ipsum lorem ipsum\r\n" +
+				">\r\n" +
+				"> quoted",
+		},
+		{"Stuffing", "From here\n indented\nnot > quoted", " From here\r\n  indented\r\nnot > quoted"},
+		{"Signature", "Bye\n-- \nAlice", "Bye\r\n-- \r\nAlice"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			encoded := rfc5322.EncodeFlowed(tc.text)
+			assert.Equal(t, tc.expected, encoded)
+			for line := range strings.SplitSeq(encoded, "\r\n") {
+				assert.LessOrEqual(t, len([]rune(line)), 78)
+			}
+		})
+	}
+}
+
+func TestDecodeFlowed(t *testing.T) {
+	testCases := []struct {
+		name     string
+		content  string
+		delsp    bool
+		expected string
+	}{
+		{"Paragraph", "Hello \r\nworld\r\n\r\nNext", false, "Hello world\n\nNext\n"},
+		{"DelSp", "Hel \r\nlo  \r\nworld", true, "Hello world\n"},
+		{"Quoted", ">> Quoted \r\n>> text\r\n> Less \r\nPlain", false, ">> Quoted text\n> Less\nPlain\n"},
+		{"Stuffed", " From here\r\n >not quoted", false, "From here\n>not quoted\n"},
+		{"Signature", "Bye \r\n-- \r\nAlice", false, "Bye\n-- \nAlice\n"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.expected, rfc5322.DecodeFlowed([]byte(tc.content), tc.delsp))
+		})
+	}
+}
+
+func TestFlowedBody(t *testing.T) {
+	text := "Café " + strings.Repeat("très long paragraphe ", 8) + "fin.\n\n> " + strings.TrimSpace(strings.Repeat("cité ", 20)) + "\n-- \nAlice\n"
+	b := rfc5322.NewFlowedBody(text)
+	assert.Contains(t, b.String(), "Content-Type: text/plain; charset=UTF-8; delsp=yes; format=flowed\r\n")
+	assert.Contains(t, b.String(), "Content-Transfer-Encoding: quoted-printable\r\n")
+
+	parsed, err := rfc5322.ParseBody(strings.NewReader(b.String()))
+	assert.NoError(t, err)
+	decoded, err := parsed.FlowedText()
+	assert.NoError(t, err)
+	assert.Equal(t, text, decoded)
+
+	// An ASCII body is kept 7bit, with the trailing spaces of its flowed lines.
+	ascii := rfc5322.NewFlowedBody(strings.Repeat("a rather long paragraph ", 8))
+	assert.Contains(t, ascii.String(), "Content-Transfer-Encoding: 7bit\r\n")
+	assert.Contains(t, ascii.String(), "paragraph a  \r\n")
+	parsed, err = rfc5322.ParseBody(strings.NewReader(ascii.String()))
+	assert.NoError(t, err)
+	decoded, err = parsed.FlowedText()
+	assert.NoError(t, err)
+	assert.Equal(t, strings.TrimSpace(strings.Repeat("a rather long paragraph ", 8))+"\n", decoded)
+
+	plain := rfc5322.NewBody()
+	plain.SetContent([]byte("Hello \r\nworld"))
+	decoded, err = plain.FlowedText()
+	assert.NoError(t, err)
+	assert.Equal(t, "Hello \nworld", decoded)
+}
@@ -6,11 +6,11 @@ require (
 	github.com/moznion/go-optional v0.12.0
 	github.com/stretchr/testify v1.10.0
 	golang.org/x/net v0.38.0
+	golang.org/x/text v0.23.0
 )
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	golang.org/x/text v0.23.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
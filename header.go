@@ -2,7 +2,9 @@ package rfc5322
 
 import (
 	"fmt"
+	"iter"
 	"mime"
+	"slices"
 	"strings"
 
 	"github.com/moznion/go-optional"
@@ -26,6 +28,21 @@ type Header struct {
 	subject    optional.Option[string]
 	comments   optional.Option[string]
 	keywords   optional.Option[[]string]
+	autocrypt  optional.Option[Autocrypt]
+
+	authResults optional.Option[[]AuthenticationResults]
+
+	dispositionNotificationTo optional.Option[Addresses]
+
+	// list fields
+	listID              optional.Option[ListID]
+	listHelp            optional.Option[ListURIs]
+	listUnsubscribe     optional.Option[ListURIs]
+	listSubscribe       optional.Option[ListURIs]
+	listPost            optional.Option[ListURIs]
+	listOwner           optional.Option[ListURIs]
+	listArchive         optional.Option[ListURIs]
+	listUnsubscribePost bool
 
 	// resent fields
 	resentDate      optional.Option[Date]
@@ -37,8 +54,11 @@ type Header struct {
 	resentMessageID optional.Option[MessageID]
 	resentReplyTo   optional.Option[Address]
 
+	// trace fields, most recent first
+	trace optional.Option[[]field]
+
 	// extra fields
-	extra optional.Option[map[string]string]
+	extra optional.Option[[]field]
 }
 
 // NewHeader creates a new Header instance with the given date and from addresses.
@@ -139,6 +159,83 @@ func (h *Header) AddKeywords(keywords []string) *Header {
 	return h
 }
 
+// SetAutocrypt sets the Autocrypt field.
+func (h *Header) SetAutocrypt(autocrypt Autocrypt) *Header {
+	h.autocrypt = optional.Some(autocrypt)
+	return h
+}
+
+// AddAuthenticationResults adds an Authentication-Results field above the existing ones, as the most recent.
+func (h *Header) AddAuthenticationResults(results AuthenticationResults) *Header {
+	h.authResults = optional.Some(append([]AuthenticationResults{results}, h.authResults.TakeOr(nil)...))
+	return h
+}
+
+// appendAuthenticationResults adds an Authentication-Results field below the existing ones, as when parsing a header from top to bottom.
+func (h *Header) appendAuthenticationResults(results AuthenticationResults) {
+	h.authResults = optional.Some(append(h.authResults.TakeOr(nil), results))
+}
+
+// SetListID sets the List-Id field.
+func (h *Header) SetListID(id ListID) *Header {
+	h.listID = optional.Some(id)
+	return h
+}
+
+// SetListHelp sets the List-Help field.
+func (h *Header) SetListHelp(uris ListURIs) *Header {
+	h.listHelp = optional.Some(uris)
+	return h
+}
+
+// SetListUnsubscribe sets the List-Unsubscribe field.
+func (h *Header) SetListUnsubscribe(uris ListURIs) *Header {
+	h.listUnsubscribe = optional.Some(uris)
+	return h
+}
+
+// SetListUnsubscribePost sets the List-Unsubscribe-Post field for one-click unsubscription, as per RFC 8058.
+// The List-Unsubscribe field must then contain an https URI.
+func (h *Header) SetListUnsubscribePost() *Header {
+	h.listUnsubscribePost = true
+	return h
+}
+
+// SetListSubscribe sets the List-Subscribe field.
+func (h *Header) SetListSubscribe(uris ListURIs) *Header {
+	h.listSubscribe = optional.Some(uris)
+	return h
+}
+
+// SetListPost sets the List-Post field. If no URIs are given, the field is set to NO, meaning that posting is not allowed.
+func (h *Header) SetListPost(uris ListURIs) *Header {
+	h.listPost = optional.Some(uris)
+	return h
+}
+
+// SetListOwner sets the List-Owner field.
+func (h *Header) SetListOwner(uris ListURIs) *Header {
+	h.listOwner = optional.Some(uris)
+	return h
+}
+
+// SetListArchive sets the List-Archive field.
+func (h *Header) SetListArchive(uris ListURIs) *Header {
+	h.listArchive = optional.Some(uris)
+	return h
+}
+
+// AddDispositionNotificationTo adds an address to the Disposition-Notification-To field, requesting a read receipt as per RFC 8098.
+func (h *Header) AddDispositionNotificationTo(addr Address) *Header {
+	if h.dispositionNotificationTo.IsSome() {
+		old := h.dispositionNotificationTo.Unwrap()
+		h.dispositionNotificationTo = optional.Some(append(old, addr))
+	} else {
+		h.dispositionNotificationTo = optional.Some(Addresses{addr})
+	}
+	return h
+}
+
 func (h *Header) SetResentDate(date Date) *Header {
 	h.resentDate = optional.Some(date)
 	return h
@@ -199,197 +296,676 @@ func (h *Header) SetResentReplyTo(replyTo Address) *Header {
 	return h
 }
 
+// Clone returns a deep copy of the Header.
+func (h *Header) Clone() *Header {
+	c := *h
+	c.from = slices.Clone(h.from)
+	c.to = cloneOption(h.to)
+	c.cc = cloneOption(h.cc)
+	c.bcc = cloneOption(h.bcc)
+	c.references = cloneOption(h.references)
+	c.keywords = cloneOption(h.keywords)
+	c.authResults = cloneOption(h.authResults)
+	c.dispositionNotificationTo = cloneOption(h.dispositionNotificationTo)
+	c.listHelp = cloneOption(h.listHelp)
+	c.listUnsubscribe = cloneOption(h.listUnsubscribe)
+	c.listSubscribe = cloneOption(h.listSubscribe)
+	c.listPost = cloneOption(h.listPost)
+	c.listOwner = cloneOption(h.listOwner)
+	c.listArchive = cloneOption(h.listArchive)
+	c.resentFrom = cloneOption(h.resentFrom)
+	c.resentTo = cloneOption(h.resentTo)
+	c.resentCc = cloneOption(h.resentCc)
+	c.resentBcc = cloneOption(h.resentBcc)
+	c.trace = cloneOption(h.trace)
+	c.extra = cloneOption(h.extra)
+	return &c
+}
+
+// cloneOption returns a copy of the optional slice that does not share its backing array.
+func cloneOption[S ~[]E, E any](o optional.Option[S]) optional.Option[S] {
+	if o.IsNone() {
+		return o
+	}
+	return optional.Some(slices.Clone(o.Unwrap()))
+}
+
+// SetExtra sets an extra header field, replacing any existing fields with the same name.
 func (h *Header) SetExtra(key, value string) *Header {
 	if h.extra.IsSome() {
 		old := h.extra.Unwrap()
-		old[key] = value
-		h.extra = optional.Some(old)
+		fs := make([]field, 0, len(old))
+		set := false
+		for _, f := range old {
+			if !strings.EqualFold(f.name, key) {
+				fs = append(fs, f)
+			} else if !set {
+				fs = append(fs, field{name: key, value: value})
+				set = true
+			}
+		}
+		if !set {
+			fs = append(fs, field{name: key, value: value})
+		}
+		h.extra = optional.Some(fs)
+	} else {
+		h.extra = optional.Some([]field{{name: key, value: value}})
+	}
+	return h
+}
+
+// AddExtra adds an extra header field, keeping any existing fields with the same name.
+func (h *Header) AddExtra(key, value string) *Header {
+	if h.extra.IsSome() {
+		old := h.extra.Unwrap()
+		h.extra = optional.Some(append(old, field{name: key, value: value}))
 	} else {
-		h.extra = optional.Some(map[string]string{key: value})
+		h.extra = optional.Some([]field{{name: key, value: value}})
 	}
 	return h
 }
 
-// String returns the string representation of the Header.
-func (h Header) String() (s string, err error) {
-	var sb strings.Builder
+// PrependTrace adds a trace field, such as Received or an ARC field, on top of the header,
+// since trace fields are prepended by each hop as per RFC 5321 section 4.4.
+func (h *Header) PrependTrace(key, value string) *Header {
+	h.trace = optional.Some(append([]field{{name: key, value: value}}, h.trace.TakeOr(nil)...))
+	return h
+}
+
+// appendTrace adds a trace field below the existing trace fields, as when parsing a header from top to bottom.
+func (h *Header) appendTrace(key, value string) {
+	h.trace = optional.Some(append(h.trace.TakeOr(nil), field{name: key, value: value}))
+}
+
+// isResent reports whether the Header has a Resent block, which is any of the Resent-* fields.
+func (h *Header) isResent() bool {
+	return h.resentDate.IsSome() || h.resentFrom.IsSome() || h.resentSender.IsSome() || h.resentTo.IsSome() ||
+		h.resentCc.IsSome() || h.resentBcc.IsSome() || h.resentMessageID.IsSome() || h.resentReplyTo.IsSome()
+}
+
+// Date returns the Date field.
+func (h *Header) Date() Date {
+	return h.date
+}
+
+// From returns the From field.
+func (h *Header) From() Addresses {
+	return h.from
+}
+
+// Sender returns the Sender field.
+func (h *Header) Sender() optional.Option[Address] {
+	return h.sender
+}
+
+// To returns the To field.
+func (h *Header) To() optional.Option[Addresses] {
+	return h.to
+}
+
+// Cc returns the Cc field.
+func (h *Header) Cc() optional.Option[Addresses] {
+	return h.cc
+}
+
+// Bcc returns the Bcc field.
+func (h *Header) Bcc() optional.Option[Addresses] {
+	return h.bcc
+}
+
+// MessageID returns the Message-ID field.
+func (h *Header) MessageID() optional.Option[MessageID] {
+	return h.messageID
+}
+
+// ReplyTo returns the Reply-To field.
+func (h *Header) ReplyTo() optional.Option[Address] {
+	return h.replyTo
+}
+
+// InReplyTo returns the In-Reply-To field.
+func (h *Header) InReplyTo() optional.Option[string] {
+	return h.inReplyTo
+}
+
+// References returns the References field.
+func (h *Header) References() optional.Option[MessageIDs] {
+	return h.references
+}
+
+// Subject returns the Subject field.
+func (h *Header) Subject() optional.Option[string] {
+	return h.subject
+}
+
+// Comments returns the Comments field.
+func (h *Header) Comments() optional.Option[string] {
+	return h.comments
+}
+
+// Keywords returns the Keywords field.
+func (h *Header) Keywords() optional.Option[[]string] {
+	return h.keywords
+}
+
+// Autocrypt returns the Autocrypt field.
+func (h *Header) Autocrypt() optional.Option[Autocrypt] {
+	return h.autocrypt
+}
+
+// AuthenticationResults returns the Authentication-Results fields.
+func (h *Header) AuthenticationResults() optional.Option[[]AuthenticationResults] {
+	return h.authResults
+}
+
+// ListID returns the List-Id field.
+func (h *Header) ListID() optional.Option[ListID] {
+	return h.listID
+}
+
+// ListHelp returns the List-Help field.
+func (h *Header) ListHelp() optional.Option[ListURIs] {
+	return h.listHelp
+}
+
+// ListUnsubscribe returns the List-Unsubscribe field.
+func (h *Header) ListUnsubscribe() optional.Option[ListURIs] {
+	return h.listUnsubscribe
+}
+
+// ListUnsubscribePost reports whether the List-Unsubscribe-Post field is set for one-click unsubscription.
+func (h *Header) ListUnsubscribePost() bool {
+	return h.listUnsubscribePost
+}
+
+// ListSubscribe returns the List-Subscribe field.
+func (h *Header) ListSubscribe() optional.Option[ListURIs] {
+	return h.listSubscribe
+}
+
+// ListPost returns the List-Post field.
+func (h *Header) ListPost() optional.Option[ListURIs] {
+	return h.listPost
+}
+
+// ListOwner returns the List-Owner field.
+func (h *Header) ListOwner() optional.Option[ListURIs] {
+	return h.listOwner
+}
+
+// ListArchive returns the List-Archive field.
+func (h *Header) ListArchive() optional.Option[ListURIs] {
+	return h.listArchive
+}
+
+// DispositionNotificationTo returns the Disposition-Notification-To field.
+func (h *Header) DispositionNotificationTo() optional.Option[Addresses] {
+	return h.dispositionNotificationTo
+}
+
+// ResentDate returns the Resent-Date field.
+func (h *Header) ResentDate() optional.Option[Date] {
+	return h.resentDate
+}
+
+// ResentFrom returns the Resent-From field.
+func (h *Header) ResentFrom() optional.Option[Addresses] {
+	return h.resentFrom
+}
+
+// ResentSender returns the Resent-Sender field.
+func (h *Header) ResentSender() optional.Option[Address] {
+	return h.resentSender
+}
+
+// ResentTo returns the Resent-To field.
+func (h *Header) ResentTo() optional.Option[Addresses] {
+	return h.resentTo
+}
+
+// ResentCc returns the Resent-Cc field.
+func (h *Header) ResentCc() optional.Option[Addresses] {
+	return h.resentCc
+}
+
+// ResentBcc returns the Resent-Bcc field.
+func (h *Header) ResentBcc() optional.Option[Addresses] {
+	return h.resentBcc
+}
+
+// ResentMessageID returns the Resent-Message-ID field.
+func (h *Header) ResentMessageID() optional.Option[MessageID] {
+	return h.resentMessageID
+}
+
+// ResentReplyTo returns the Resent-Reply-To field.
+func (h *Header) ResentReplyTo() optional.Option[Address] {
+	return h.resentReplyTo
+}
+
+// Get returns the first value of the named field, or an empty string if it is not set.
+// The name is matched case-insensitively and the value is returned without encoding.
+func (h *Header) Get(name string) string {
+	for n, v := range h.Fields() {
+		if strings.EqualFold(n, name) {
+			return v
+		}
+	}
+	return ""
+}
+
+// Values returns all values of the named field in rendering order.
+// The name is matched case-insensitively and the values are returned without encoding.
+func (h *Header) Values(name string) []string {
+	values := make([]string, 0)
+	for n, v := range h.Fields() {
+		if strings.EqualFold(n, name) {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// Del removes all fields with the given name.
+// The required Date, From and MIME-Version fields cannot be removed.
+func (h *Header) Del(name string) *Header {
+	switch strings.ToLower(name) {
+	case "sender":
+		h.sender = optional.None[Address]()
+	case "to":
+		h.to = optional.None[Addresses]()
+	case "cc":
+		h.cc = optional.None[Addresses]()
+	case "bcc":
+		h.bcc = optional.None[Addresses]()
+	case "message-id":
+		h.messageID = optional.None[MessageID]()
+	case "reply-to":
+		h.replyTo = optional.None[Address]()
+	case "in-reply-to":
+		h.inReplyTo = optional.None[string]()
+	case "references":
+		h.references = optional.None[MessageIDs]()
+	case "subject":
+		h.subject = optional.None[string]()
+	case "comments":
+		h.comments = optional.None[string]()
+	case "keywords":
+		h.keywords = optional.None[[]string]()
+	case "autocrypt":
+		h.autocrypt = optional.None[Autocrypt]()
+	case "authentication-results":
+		h.authResults = optional.None[[]AuthenticationResults]()
+	case "list-id":
+		h.listID = optional.None[ListID]()
+	case "list-help":
+		h.listHelp = optional.None[ListURIs]()
+	case "list-unsubscribe":
+		h.listUnsubscribe = optional.None[ListURIs]()
+	case "list-unsubscribe-post":
+		h.listUnsubscribePost = false
+	case "list-subscribe":
+		h.listSubscribe = optional.None[ListURIs]()
+	case "list-post":
+		h.listPost = optional.None[ListURIs]()
+	case "list-owner":
+		h.listOwner = optional.None[ListURIs]()
+	case "list-archive":
+		h.listArchive = optional.None[ListURIs]()
+	case "disposition-notification-to":
+		h.dispositionNotificationTo = optional.None[Addresses]()
+	case "resent-date":
+		h.resentDate = optional.None[Date]()
+	case "resent-from":
+		h.resentFrom = optional.None[Addresses]()
+	case "resent-sender":
+		h.resentSender = optional.None[Address]()
+	case "resent-to":
+		h.resentTo = optional.None[Addresses]()
+	case "resent-cc":
+		h.resentCc = optional.None[Addresses]()
+	case "resent-bcc":
+		h.resentBcc = optional.None[Addresses]()
+	case "resent-message-id":
+		h.resentMessageID = optional.None[MessageID]()
+	case "resent-reply-to":
+		h.resentReplyTo = optional.None[Address]()
+	}
+	h.trace = withoutField(h.trace, name)
+	h.extra = withoutField(h.extra, name)
+	return h
+}
+
+// withoutField returns the optional fields without the fields with the given name.
+func withoutField(o optional.Option[[]field], name string) optional.Option[[]field] {
+	if o.IsNone() {
+		return o
+	}
+	fs := make([]field, 0)
+	for _, f := range o.Unwrap() {
+		if !strings.EqualFold(f.name, name) {
+			fs = append(fs, f)
+		}
+	}
+	if len(fs) == 0 {
+		return optional.None[[]field]()
+	}
+	return optional.Some(fs)
+}
+
+// Fields returns an iterator over all fields in rendering order.
+// The values are yielded without encoding.
+func (h *Header) Fields() iter.Seq2[string, string] {
+	return func(yield func(string, string) bool) {
+		fs, _ := h.fields(false)
+		for _, f := range fs {
+			if !yield(f.name, f.value) {
+				return
+			}
+		}
+	}
+}
+
+// field represents a single header field.
+type field struct {
+	name  string
+	value string
+}
+
+// fields returns the fields of the Header in rendering order.
+// If render is true, the values are encoded and validated for the output.
+func (h *Header) fields(render bool) (fs []field, err error) {
+	address := func(a Address) (string, error) {
+		if render {
+			return a.String()
+		}
+		return a.Value(), nil
+	}
+	addresses := func(a Addresses) (string, error) {
+		if render {
+			return a.String()
+		}
+		return a.Value(), nil
+	}
+	text := func(v string) (string, error) {
+		if !render {
+			return v, nil
+		}
+		if encode {
+			return mime.QEncoding.Encode("utf-8", v), nil
+		}
+		if !validFieldValue(v) {
+			return "", ErrorInvalidFieldValue
+		}
+		return v, nil
+	}
+	add := func(name, value string) {
+		fs = append(fs, field{name: name, value: value})
+	}
+
+	// trace fields
+	if h.trace.IsSome() {
+		for _, f := range h.trace.Unwrap() {
+			if render && !validFieldName(f.name) {
+				err = ErrorInvalidFieldName
+				return
+			}
+			if render && !validFieldValue(f.value) {
+				err = ErrorInvalidFieldValue
+				return
+			}
+			add(f.name, f.value)
+		}
+	}
+
+	// Authentication-Results is prepended like a trace field, as per RFC 8601 section 5.
+	if h.authResults.IsSome() {
+		for _, results := range h.authResults.Unwrap() {
+			value := results.String()
+			if render && !validFieldValue(value) {
+				err = ErrorInvalidFieldValue
+				return
+			}
+			if !render {
+				value = strings.ReplaceAll(value, "\r\n\t", " ")
+			}
+			add("Authentication-Results", value)
+		}
+	}
 
 	// minimum required fields
-	sb.WriteString("MIME-Version: 1.0\r\n")
-	sb.WriteString(fmt.Sprintf("Date: %s\r\n", h.date.String()))
-	addr, err := h.from.String()
+	add("MIME-Version", "1.0")
+	add("Date", h.date.String())
+	addr, err := addresses(h.from)
 	if err != nil {
 		return
 	}
-	sb.WriteString(fmt.Sprintf("From: %s\r\n", addr))
+	add("From", addr)
 
 	// If there are multiple addresses in the "From" field, include the "Sender" field
 	if len(h.from) > 1 {
 		if h.sender.IsSome() {
-			sender := h.sender.Unwrap()
-			addr, err = sender.String()
+			addr, err = address(h.sender.Unwrap())
 			if err != nil {
 				return
 			}
-			sb.WriteString(fmt.Sprintf("Sender: %s\r\n", addr))
-		} else {
+			add("Sender", addr)
+		} else if render {
 			err = ErrorNeedSender
 			return
 		}
 	}
 
-	// Check if "To", "Cc", or "Bcc" fields are set
-	if h.to.IsSome() || h.cc.IsSome() || h.bcc.IsSome() {
-		set := false
-		if h.to.IsSome() {
-			set = true
-			addr, err = h.to.Unwrap().String()
-			if err != nil {
-				return
-			}
-			sb.WriteString(fmt.Sprintf("To: %s\r\n", addr))
-		}
-		if h.cc.IsSome() {
-			set = true
-			addr, err = h.cc.Unwrap().String()
-			if err != nil {
-				return
-			}
-			sb.WriteString(fmt.Sprintf("Cc: %s\r\n", addr))
+	if h.to.IsSome() {
+		addr, err = addresses(h.to.Unwrap())
+		if err != nil {
+			return
 		}
-		if h.bcc.IsSome() {
-			set = true
-			addr, err = h.bcc.Unwrap().String()
-			if err != nil {
-				return
-			}
-			sb.WriteString(fmt.Sprintf("Bcc: %s\r\n", addr))
+		add("To", addr)
+	}
+	if h.cc.IsSome() {
+		addr, err = addresses(h.cc.Unwrap())
+		if err != nil {
+			return
 		}
-		if !set {
-			err = ErrorNeedToCcBcc
+		add("Cc", addr)
+	}
+	if h.bcc.IsSome() {
+		addr, err = addresses(h.bcc.Unwrap())
+		if err != nil {
 			return
 		}
+		add("Bcc", addr)
 	}
 
 	// Optional fields
 	if h.messageID.IsSome() {
 		mi := h.messageID.Unwrap()
-		sb.WriteString(fmt.Sprintf("Message-ID: %s\r\n", mi.String()))
+		add("Message-ID", mi.String())
 	}
 	if h.replyTo.IsSome() {
-		replyTo := h.replyTo.Unwrap()
-		addr, err = replyTo.String()
+		addr, err = address(h.replyTo.Unwrap())
+		if err != nil {
+			return
+		}
+		add("Reply-To", addr)
+	}
+	if h.dispositionNotificationTo.IsSome() {
+		addr, err = addresses(h.dispositionNotificationTo.Unwrap())
 		if err != nil {
 			return
 		}
-		sb.WriteString(fmt.Sprintf("Reply-To: %s\r\n", addr))
+		add("Disposition-Notification-To", addr)
 	}
 	if h.inReplyTo.IsSome() {
 		inReplyTo := h.inReplyTo.Unwrap()
-		sb.WriteString(fmt.Sprintf("In-Reply-To: %s\r\n", inReplyTo))
+		if render && !validFieldValue(inReplyTo) {
+			err = ErrorInvalidFieldValue
+			return
+		}
+		add("In-Reply-To", inReplyTo)
 	}
 	if h.references.IsSome() {
-		references := h.references.Unwrap()
-		sb.WriteString(fmt.Sprintf("References: %s\r\n", references.String()))
+		add("References", h.references.Unwrap().String())
 	}
+	var value string
 	if h.subject.IsSome() {
-		subject := h.subject.Unwrap()
-		if encode {
-			subject = mime.QEncoding.Encode("utf-8", subject)
+		value, err = text(h.subject.Unwrap())
+		if err != nil {
+			return
 		}
-		sb.WriteString(fmt.Sprintf("Subject: %s\r\n", subject))
+		add("Subject", value)
 	}
 	if h.comments.IsSome() {
-		comments := h.comments.Unwrap()
-		if encode {
-			comments = mime.QEncoding.Encode("utf-8", comments)
+		value, err = text(h.comments.Unwrap())
+		if err != nil {
+			return
 		}
-		sb.WriteString(fmt.Sprintf("Comments: %s\r\n", comments))
+		add("Comments", value)
 	}
 	if h.keywords.IsSome() {
-		keywords := h.keywords.Unwrap()
-		if encode {
-			for i, keyword := range keywords {
-				keywords[i] = mime.QEncoding.Encode("utf-8", keyword)
+		keywords := make([]string, 0, len(h.keywords.Unwrap()))
+		for _, keyword := range h.keywords.Unwrap() {
+			value, err = text(keyword)
+			if err != nil {
+				return
+			}
+			keywords = append(keywords, value)
+		}
+		add("Keywords", strings.Join(keywords, ", "))
+	}
+	if h.autocrypt.IsSome() {
+		autocrypt := h.autocrypt.Unwrap()
+		if render {
+			add("Autocrypt", autocrypt.String())
+		} else {
+			add("Autocrypt", autocrypt.Value())
+		}
+	}
+
+	// list fields
+	if h.listID.IsSome() {
+		listID := h.listID.Unwrap()
+		if render {
+			add("List-Id", listID.String())
+		} else {
+			add("List-Id", listID.Value())
+		}
+	}
+	// uris adds a list field, where only List-Post may be empty, meaning NO as per RFC 2369 section 3.4.
+	uris := func(name string, o optional.Option[ListURIs]) bool {
+		if o.IsNone() {
+			return true
+		}
+		switch {
+		case len(o.Unwrap()) == 0 && name == "List-Post":
+			add(name, "NO")
+		case len(o.Unwrap()) == 0:
+			if render {
+				err = ErrorEmptyListURIs
+				return false
 			}
+		case render:
+			add(name, o.Unwrap().String())
+		default:
+			add(name, o.Unwrap().Value())
+		}
+		return true
+	}
+	if !uris("List-Help", h.listHelp) || !uris("List-Unsubscribe", h.listUnsubscribe) {
+		return
+	}
+	if h.listUnsubscribePost {
+		if render && !h.listUnsubscribe.TakeOr(nil).hasHTTPS() {
+			err = ErrorNeedListUnsubscribeHTTPS
+			return
 		}
-		sb.WriteString(fmt.Sprintf("Keywords: %s\r\n", strings.Join(keywords, ", ")))
+		add("List-Unsubscribe-Post", oneClick)
+	}
+	if !uris("List-Subscribe", h.listSubscribe) || !uris("List-Post", h.listPost) ||
+		!uris("List-Owner", h.listOwner) || !uris("List-Archive", h.listArchive) {
+		return
 	}
 
+	// resent fields
 	if h.resentDate.IsSome() {
 		resentDate := h.resentDate.Unwrap()
-		sb.WriteString(fmt.Sprintf("Resent-Date: %s\r\n", resentDate.String()))
+		add("Resent-Date", resentDate.String())
 	}
-
 	if h.resentFrom.IsSome() {
-		resentFrom := h.resentFrom.Unwrap()
-		addr, err = resentFrom.String()
+		addr, err = addresses(h.resentFrom.Unwrap())
 		if err != nil {
 			return
 		}
-		sb.WriteString(fmt.Sprintf("Resent-From: %s\r\n", addr))
+		add("Resent-From", addr)
 	}
-
 	if h.resentSender.IsSome() {
-		resentSender := h.resentSender.Unwrap()
-		addr, err = resentSender.String()
+		addr, err = address(h.resentSender.Unwrap())
 		if err != nil {
 			return
 		}
-		sb.WriteString(fmt.Sprintf("Resent-Sender: %s\r\n", addr))
+		add("Resent-Sender", addr)
 	}
-
 	if h.resentTo.IsSome() {
-		resentTo := h.resentTo.Unwrap()
-		addr, err = resentTo.String()
+		addr, err = addresses(h.resentTo.Unwrap())
 		if err != nil {
 			return
 		}
-		sb.WriteString(fmt.Sprintf("Resent-To: %s\r\n", addr))
+		add("Resent-To", addr)
 	}
-
 	if h.resentCc.IsSome() {
-		resentCc := h.resentCc.Unwrap()
-		addr, err = resentCc.String()
+		addr, err = addresses(h.resentCc.Unwrap())
 		if err != nil {
 			return
 		}
-		sb.WriteString(fmt.Sprintf("Resent-Cc: %s\r\n", addr))
+		add("Resent-Cc", addr)
 	}
-
 	if h.resentBcc.IsSome() {
-		resentBcc := h.resentBcc.Unwrap()
-		addr, err = resentBcc.String()
+		addr, err = addresses(h.resentBcc.Unwrap())
 		if err != nil {
 			return
 		}
-		sb.WriteString(fmt.Sprintf("Resent-Bcc: %s\r\n", addr))
+		add("Resent-Bcc", addr)
 	}
-
 	if h.resentMessageID.IsSome() {
 		resentMessageID := h.resentMessageID.Unwrap()
-		sb.WriteString(fmt.Sprintf("Resent-Message-ID: %s\r\n", resentMessageID.String()))
+		add("Resent-Message-ID", resentMessageID.String())
 	}
-
 	if h.resentReplyTo.IsSome() {
-		resentReplyTo := h.resentReplyTo.Unwrap()
-		addr, err = resentReplyTo.String()
+		addr, err = address(h.resentReplyTo.Unwrap())
 		if err != nil {
 			return
 		}
-		sb.WriteString(fmt.Sprintf("Resent-Reply-To: %s\r\n", addr))
+		add("Resent-Reply-To", addr)
 	}
 
+	// extra fields
 	if h.extra.IsSome() {
-		extra := h.extra.Unwrap()
-		for key, value := range extra {
-			if encode {
-				value = mime.QEncoding.Encode("utf-8", value)
+		for _, f := range h.extra.Unwrap() {
+			if render && !validFieldName(f.name) {
+				err = ErrorInvalidFieldName
+				return
 			}
-			sb.WriteString(fmt.Sprintf("%s: %s\r\n", key, value))
+			value, err = text(f.value)
+			if err != nil {
+				return
+			}
+			add(f.name, value)
 		}
 	}
+	return
+}
 
+// String returns the string representation of the Header.
+func (h Header) String() (s string, err error) {
+	fs, err := h.fields(true)
+	if err != nil {
+		return
+	}
+	var sb strings.Builder
+	for _, f := range fs {
+		sb.WriteString(fmt.Sprintf("%s: %s\r\n", f.name, f.value))
+	}
 	s = sb.String()
 	return
 }
@@ -1,6 +1,7 @@
 package rfc5322_test
 
 import (
+	"strings"
 	"testing"
 	"time"
 
@@ -38,3 +39,143 @@ func TestHeader(t *testing.T) {
 		})
 	}
 }
+
+func TestHeaderInjection(t *testing.T) {
+	from, _ := rfc5322.NewAddress("example@example.com")
+	date := rfc5322.NewDate(time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC))
+	testCases := []struct {
+		name        string
+		setup       func(h *rfc5322.Header)
+		encode      bool
+		contains    string
+		expectedErr error
+	}{
+		{
+			name:     "subject with CRLF is encoded",
+			setup:    func(h *rfc5322.Header) { h.SetSubject("Hello\r\nBcc: evil@example.com") },
+			encode:   true,
+			contains: "Subject: =?utf-8?q?Hello=0D=0ABcc:_evil@example.com?=\r\n",
+		},
+		{
+			name:        "subject with CRLF without encoding",
+			setup:       func(h *rfc5322.Header) { h.SetSubject("Hello\r\nBcc: evil@example.com") },
+			encode:      false,
+			expectedErr: rfc5322.ErrorInvalidFieldValue,
+		},
+		{
+			name:     "folded subject without encoding",
+			setup:    func(h *rfc5322.Header) { h.SetSubject("Hello\r\n World") },
+			encode:   false,
+			contains: "Subject: Hello\r\n World\r\n",
+		},
+		{
+			name:        "in-reply-to with LF",
+			setup:       func(h *rfc5322.Header) { h.SetInReplyTo("<a@example.com>\nBcc: evil@example.com") },
+			encode:      true,
+			expectedErr: rfc5322.ErrorInvalidFieldValue,
+		},
+		{
+			name:        "extra with NUL without encoding",
+			setup:       func(h *rfc5322.Header) { h.SetExtra("X-Test", "a\x00b") },
+			encode:      false,
+			expectedErr: rfc5322.ErrorInvalidFieldValue,
+		},
+		{
+			name:        "extra with invalid name",
+			setup:       func(h *rfc5322.Header) { h.SetExtra("X-Test:\r\nBcc", "value") },
+			encode:      true,
+			expectedErr: rfc5322.ErrorInvalidFieldName,
+		},
+		{
+			name:     "valid extra",
+			setup:    func(h *rfc5322.Header) { h.SetExtra("X-Test", "value") },
+			encode:   false,
+			contains: "X-Test: value\r\n",
+		},
+	}
+
+	// Encoding is a package level setting, so these cases are not run in parallel.
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if !tc.encode {
+				rfc5322.DisableEncode()
+				defer rfc5322.EnableEncode()
+			}
+			header := rfc5322.NewHeader(*date, rfc5322.NewAddresses(*from))
+			tc.setup(header)
+			s, err := header.String()
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Contains(t, s, tc.contains)
+			}
+		})
+	}
+}
+
+func TestHeaderAccessors(t *testing.T) {
+	from, _ := rfc5322.NewAddressWithName("Alice", "alice@example.com")
+	to, _ := rfc5322.NewAddress("bob@example.com")
+	date := rfc5322.NewDate(time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC))
+	header := rfc5322.NewHeader(*date, rfc5322.NewAddresses(*from))
+	header.AddTo(*to).SetSubject("こんにちは")
+	header.AddExtra("X-Tag", "a").AddExtra("x-tag", "b").SetExtra("X-Mailer", "rfc5322-go")
+
+	assert.Equal(t, "Sun, 01 Oct 2023 12:00:00 +0000", header.Date().String())
+	assert.Equal(t, "Alice <alice@example.com>", header.From().Value())
+	assert.Equal(t, "bob@example.com", header.To().Unwrap().Value())
+	assert.True(t, header.Cc().IsNone())
+	assert.Equal(t, "こんにちは", header.Subject().Unwrap())
+
+	assert.Equal(t, "こんにちは", header.Get("subject"))
+	assert.Equal(t, "bob@example.com", header.Get("To"))
+	assert.Equal(t, "", header.Get("Cc"))
+	assert.Equal(t, []string{"a", "b"}, header.Values("X-Tag"))
+
+	names := make([]string, 0)
+	for name := range header.Fields() {
+		names = append(names, name)
+	}
+	assert.Equal(t, []string{"MIME-Version", "Date", "From", "To", "Subject", "X-Tag", "x-tag", "X-Mailer"}, names)
+
+	header.SetExtra("X-TAG", "c")
+	assert.Equal(t, []string{"c"}, header.Values("X-Tag"))
+
+	header.Del("Subject").Del("x-tag").Del("Date")
+	assert.True(t, header.Subject().IsNone())
+	assert.Empty(t, header.Values("X-Tag"))
+	assert.Equal(t, "Sun, 01 Oct 2023 12:00:00 +0000", header.Get("Date"))
+
+	s, err := header.String()
+	assert.NoError(t, err)
+	assert.Equal(t, "MIME-Version: 1.0\r\n"+
+		"Date: Sun, 01 Oct 2023 12:00:00 +0000\r\n"+
+		"From: Alice <alice@example.com>\r\n"+
+		"To: bob@example.com\r\n"+
+		"X-Mailer: rfc5322-go\r\n", s)
+}
+
+func TestPrependTrace(t *testing.T) {
+	header := newTestHeader(t).
+		PrependTrace("Received", "from a.example.com by b.example.com; Sun, 01 Oct 2023 12:00:00 +0000").
+		PrependTrace("Received", "from b.example.com by c.example.com; Sun, 01 Oct 2023 12:00:01 +0000")
+	s, err := header.String()
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(s, "Received: from b.example.com by c.example.com; Sun, 01 Oct 2023 12:00:01 +0000\r\n"+
+		"Received: from a.example.com by b.example.com; Sun, 01 Oct 2023 12:00:00 +0000\r\n"+
+		"MIME-Version: 1.0\r\n"))
+
+	parsed, err := rfc5322.Parse(strings.NewReader(s + "\r\n"))
+	assert.NoError(t, err)
+	assert.Len(t, parsed.Header().Values("Received"), 2)
+	rendered, err := parsed.Header().String()
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(rendered, "Received: from b.example.com"))
+
+	header.Del("Received")
+	assert.Empty(t, header.Values("Received"))
+
+	_, err = newTestHeader(t).PrependTrace("Received", "from a.example.com\r\nBcc: eve@example.com").String()
+	assert.ErrorIs(t, err, rfc5322.ErrorInvalidFieldValue)
+}
@@ -0,0 +1,333 @@
+package rfc5322
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// htmlText is the state of the conversion of HTML to plain text.
+type htmlText struct {
+	buf strings.Builder
+	// breaks is the number of pending line breaks before the next text.
+	breaks int
+	// trailing is the number of line breaks written since the last text.
+	trailing int
+	// lineStart reports whether the prefix of the current line is not written yet.
+	lineStart bool
+	// space reports whether a space is pending between words.
+	space    bool
+	pre      int
+	prefixes []string
+	// counters are the item numbers of the nested lists, or -1 for unordered lists.
+	counters []int
+	// cells are the cell counts of the rows of the nested tables.
+	cells []int
+	links []string
+}
+
+// HTMLToText converts the HTML content to readable plain text with LF line endings.
+// Headings are underlined, list items are marked and indented, table cells are separated with " | ",
+// blockquotes are prefixed with "> ", and links are numbered with their URLs listed as footnotes.
+func HTMLToText(content []byte) (text string, err error) {
+	doc, err := html.Parse(bytes.NewReader(content))
+	if err != nil {
+		return
+	}
+	c := &htmlText{lineStart: true}
+	c.node(doc)
+	text = strings.TrimSpace(c.buf.String())
+	if len(c.links) > 0 {
+		text += "\n"
+		for i, link := range c.links {
+			text += fmt.Sprintf("\n[%d] %s", i+1, link)
+		}
+	}
+	if text != "" {
+		text += "\n"
+	}
+	return
+}
+
+// block requests at least n line breaks before the next text.
+func (c *htmlText) block(n int) {
+	c.breaks = max(c.breaks, n-c.trailing)
+}
+
+// prefix returns the prefix of the current line.
+func (c *htmlText) prefix() string {
+	return strings.Join(c.prefixes, "")
+}
+
+// flush writes the pending line breaks and the prefix of the line.
+func (c *htmlText) flush() {
+	c.newlines()
+	if c.lineStart {
+		c.buf.WriteString(c.prefix())
+		c.lineStart = false
+		c.space = false
+	}
+	c.trailing = 0
+}
+
+// newlines writes the pending line breaks, where the empty lines have the prefix of the enclosing block.
+func (c *htmlText) newlines() {
+	if c.buf.Len() == 0 {
+		c.breaks = 0
+	}
+	if c.breaks > 0 {
+		for i := range c.breaks {
+			c.buf.WriteString("\n")
+			if i < c.breaks-1 {
+				c.buf.WriteString(strings.TrimRight(c.prefix(), " "))
+			}
+		}
+		c.trailing += c.breaks
+		c.breaks = 0
+		c.lineStart = true
+	}
+}
+
+// write writes text as is, after the pending line breaks.
+func (c *htmlText) write(s string) {
+	c.flush()
+	c.buf.WriteString(s)
+}
+
+// text writes the text of a text node, collapsing whitespace outside of preformatted text.
+func (c *htmlText) text(s string) {
+	if c.pre > 0 {
+		for i, line := range strings.Split(s, "\n") {
+			if i > 0 {
+				c.breaks++
+			}
+			if line != "" {
+				c.write(line)
+			}
+		}
+		return
+	}
+	if s != "" && strings.TrimLeft(s, " \t\r\n\f") != s {
+		c.space = true
+	}
+	for i, word := range strings.Fields(s) {
+		if i > 0 {
+			c.space = true
+		}
+		space := c.space && c.breaks == 0 && !c.lineStart
+		c.flush()
+		if space {
+			c.buf.WriteString(" ")
+		}
+		c.buf.WriteString(word)
+		c.space = false
+	}
+	if s != "" && strings.TrimRight(s, " \t\r\n\f") != s {
+		c.space = true
+	}
+}
+
+// children converts the children of the node.
+func (c *htmlText) children(n *html.Node) {
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		c.node(child)
+	}
+}
+
+// node converts the node and its children.
+func (c *htmlText) node(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		c.text(n.Data)
+		return
+	case html.DocumentNode:
+		c.children(n)
+		return
+	case html.ElementNode:
+	default:
+		return
+	}
+
+	switch n.DataAtom {
+	case atom.Head, atom.Script, atom.Style, atom.Template, atom.Noscript, atom.Title:
+	case atom.Br:
+		c.breaks++
+		c.space = false
+	case atom.Hr:
+		c.block(2)
+		c.write("----")
+		c.block(2)
+	case atom.H1, atom.H2:
+		c.block(2)
+		c.flush()
+		start := c.buf.Len()
+		c.children(n)
+		if width := utf8.RuneCountInString(c.buf.String()[start:]); width > 0 {
+			underline := "="
+			if n.DataAtom == atom.H2 {
+				underline = "-"
+			}
+			c.block(1)
+			c.write(strings.Repeat(underline, width))
+		}
+		c.block(2)
+	case atom.P, atom.H3, atom.H4, atom.H5, atom.H6, atom.Table, atom.Dl:
+		c.block(2)
+		c.children(n)
+		c.block(2)
+	case atom.Pre:
+		c.block(2)
+		c.pre++
+		c.children(n)
+		c.pre--
+		c.block(2)
+	case atom.Blockquote:
+		c.block(2)
+		c.newlines()
+		c.prefixes = append(c.prefixes, "> ")
+		c.children(n)
+		c.prefixes = c.prefixes[:len(c.prefixes)-1]
+		c.block(2)
+	case atom.Ul, atom.Ol:
+		counter := -1
+		if n.DataAtom == atom.Ol {
+			counter = 1
+			if start, err := strconv.Atoi(htmlAttribute(n, "start")); err == nil {
+				counter = start
+			}
+		}
+		// A nested list continues its item, and other lists are separated as paragraphs.
+		if len(c.counters) == 0 {
+			c.block(2)
+		} else {
+			c.block(1)
+		}
+		c.counters = append(c.counters, counter)
+		c.children(n)
+		c.counters = c.counters[:len(c.counters)-1]
+		if len(c.counters) == 0 {
+			c.block(2)
+		}
+	case atom.Li:
+		marker := "* "
+		if len(c.counters) > 0 && c.counters[len(c.counters)-1] >= 0 {
+			marker = strconv.Itoa(c.counters[len(c.counters)-1]) + ". "
+			c.counters[len(c.counters)-1]++
+		}
+		c.block(1)
+		c.write(marker)
+		c.prefixes = append(c.prefixes, strings.Repeat(" ", len(marker)))
+		c.children(n)
+		c.prefixes = c.prefixes[:len(c.prefixes)-1]
+		c.block(1)
+	case atom.Tr:
+		c.block(1)
+		c.cells = append(c.cells, 0)
+		c.children(n)
+		c.cells = c.cells[:len(c.cells)-1]
+		c.block(1)
+	case atom.Td, atom.Th:
+		if len(c.cells) > 0 {
+			if c.cells[len(c.cells)-1] > 0 {
+				c.write(" | ")
+				c.space = false
+			}
+			c.cells[len(c.cells)-1]++
+		}
+		c.children(n)
+	case atom.A:
+		start := c.buf.Len()
+		c.children(n)
+		href := strings.TrimSpace(htmlAttribute(n, "href"))
+		text := strings.TrimSpace(c.buf.String()[start:])
+		if !footnote(href, text) {
+			return
+		}
+		i := 0
+		for i < len(c.links) && c.links[i] != href {
+			i++
+		}
+		if i == len(c.links) {
+			c.links = append(c.links, href)
+		}
+		if text == "" {
+			c.text(href)
+		} else {
+			c.buf.WriteString(fmt.Sprintf(" [%d]", i+1))
+		}
+	case atom.Img:
+		if alt := htmlAttribute(n, "alt"); alt != "" {
+			c.text(alt)
+		}
+	case atom.Div, atom.Section, atom.Article, atom.Header, atom.Footer, atom.Nav, atom.Aside, atom.Main,
+		atom.Address, atom.Figure, atom.Figcaption, atom.Caption, atom.Thead, atom.Tbody, atom.Tfoot, atom.Dt, atom.Dd, atom.Center:
+		c.block(1)
+		c.children(n)
+		c.block(1)
+	default:
+		c.children(n)
+	}
+}
+
+// htmlAttribute returns the value of the attribute of the element, or an empty string if it is not set.
+func htmlAttribute(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Namespace == "" && strings.EqualFold(a.Key, key) {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// footnote reports whether the URL of a link is listed as a footnote.
+// Fragments, scripts, and URLs that are the same as the text of the link are not.
+func footnote(href, text string) bool {
+	lower := strings.ToLower(href)
+	switch {
+	case href == "", strings.HasPrefix(href, "#"), strings.HasPrefix(lower, "javascript:"):
+		return false
+	case href == text, strings.HasPrefix(lower, "mailto:") && href[len("mailto:"):] == text:
+		return false
+	}
+	return true
+}
+
+// TextAlternative returns a multipart/alternative Body with a text/plain part converted from the HTML Body by HTMLToText,
+// followed by the HTML Body as is, which is the preferred part.
+// The HTML Body is either text/html, or multipart/related with a text/html root part.
+// It returns ErrorNotHTML if the Body is neither.
+func TextAlternative(b *Body) (alternative *Body, err error) {
+	root := b
+	if b.ContentType() == "multipart/related" {
+		if len(b.parts) == 0 {
+			err = ErrorNotHTML
+			return
+		}
+		root = b.parts[0]
+	}
+	if root.ContentType() != "text/html" {
+		err = ErrorNotHTML
+		return
+	}
+	content, err := root.Text()
+	if err != nil {
+		return
+	}
+	text, err := HTMLToText([]byte(content))
+	if err != nil {
+		return
+	}
+
+	plain := NewBody()
+	plain.setHeader("Content-Type", "text/plain; charset=UTF-8")
+	plain.content = normalizeLineEndings([]byte(text))
+
+	alternative = newMultipart("multipart/alternative", nil)
+	alternative.parts = []*Body{plain.sevenBit(), b}
+	return
+}
@@ -0,0 +1,91 @@
+package rfc5322_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aethiopicuschan/rfc5322-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTMLToText(t *testing.T) {
+	testCases := []struct {
+		name     string
+		html     string
+		expected string
+	}{
+		{
+			"Paragraphs",
+			"<html><head><title>T</title><style>p{}</style></head><body><p>Hello,\n   <b>world</b>!</p><p>Second<br>line</p><script>alert(1)</script></body></html>",
+			"Hello, world!\n\nSecond\nline\n",
+		},
+		{
+			"Headings",
+			"<h1>Title</h1><h2>Café</h2><h3>Section</h3><p>Text</p>",
+			"Title\n=====\n\nCafé\n----\n\nSection\n\nText\n",
+		},
+		{
+			"Links",
+			`<p>See <a href="https://example.com/a">the docs</a>, <a href="https://example.com/b">more</a> and <a href="https://example.com/a">again</a>.</p>` +
+				`<p><a href="mailto:bob@example.com">bob@example.com</a> <a href="#top">top</a></p>`,
+			"See the docs [1], more [2] and again [1].\n\nbob@example.com top\n\n[1] https://example.com/a\n[2] https://example.com/b\n",
+		},
+		{
+			"Lists",
+			"<ul><li>One</li><li>Two<ol start=\"3\"><li>Three</li><li>Four</li></ol></li></ul><p>After</p>",
+			"* One\n* Two\n  3. Three\n  4. Four\n\nAfter\n",
+		},
+		{
+			"Table",
+			"<table><tr><th>Item</th><th>Price</th></tr><tr><td>Tea</td><td>$3</td></tr></table>",
+			"Item | Price\nTea | $3\n",
+		},
+		{
+			"Blockquote",
+			"<p>Wrote:</p><blockquote><p>First</p><p>Second</p></blockquote>",
+			"Wrote:\n\n> First\n>\n> Second\n",
+		},
+		{
+			"Preformatted",
+			"<pre>a  b\n\n  c</pre>",
+			"a  b\n\n  c\n",
+		},
+		{
+			"Image",
+			`<p><img src="cid:logo" alt="Example Inc."> News</p>`,
+			"Example Inc. News\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			text, err := rfc5322.HTMLToText([]byte(tc.html))
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, text)
+		})
+	}
+}
+
+func TestTextAlternative(t *testing.T) {
+	html := rfc5322.NewBody()
+	assert.NoError(t, html.SetHeader("Content-Type", "text/html; charset=UTF-8"))
+	html.SetContent([]byte("<p>Hello <a href=\"https://example.com\">there</a></p>"))
+
+	alternative, err := rfc5322.TextAlternative(html)
+	assert.NoError(t, err)
+	assert.Equal(t, "multipart/alternative", alternative.ContentType())
+	s := alternative.String()
+	assert.Contains(t, s, "Hello there [1]\r\n\r\n[1] https://example.com\r\n")
+	assert.Less(t, strings.Index(s, "text/plain"), strings.Index(s, "text/html"))
+
+	related := rfc5322.NewBody()
+	assert.NoError(t, related.SetHeader("Content-Type", "multipart/related; boundary=related"))
+	related.AddPart(html)
+	alternative, err = rfc5322.TextAlternative(related)
+	assert.NoError(t, err)
+	assert.Contains(t, alternative.String(), "Content-Type: multipart/related; boundary=related\r\n")
+
+	_, err = rfc5322.TextAlternative(rfc5322.NewBody())
+	assert.ErrorIs(t, err, rfc5322.ErrorNotHTML)
+}
@@ -0,0 +1,230 @@
+package rfc5322
+
+import (
+	"fmt"
+	"mime"
+	"net/mail"
+	"net/url"
+	"strings"
+
+	"github.com/moznion/go-optional"
+)
+
+// ListID represents the List-Id field, as per RFC 2919.
+type ListID struct {
+	description optional.Option[string]
+	id          string
+}
+
+// NewListID creates a new ListID instance with the given identifier, such as "list-label.example.com".
+func NewListID(id string) (l *ListID, err error) {
+	if !validListID(id) {
+		err = ErrorInvalidListID
+		return
+	}
+	l = &ListID{
+		description: optional.None[string](),
+		id:          id,
+	}
+	return
+}
+
+// NewListIDWithDescription creates a new ListID instance with the given description and identifier.
+func NewListIDWithDescription(description, id string) (l *ListID, err error) {
+	if description == "" || !validFieldValue(description) {
+		err = ErrorInvalidName
+		return
+	}
+	if l, err = NewListID(id); err != nil {
+		return
+	}
+	l.description = optional.Some(description)
+	return
+}
+
+// ID returns the identifier of the list.
+func (l ListID) ID() string {
+	return l.id
+}
+
+// Description returns the description of the list.
+func (l ListID) Description() optional.Option[string] {
+	return l.description
+}
+
+// Value returns the value of the ListID.
+func (l ListID) Value() string {
+	if l.description.IsSome() {
+		return fmt.Sprintf("%s <%s>", l.description.Unwrap(), l.id)
+	}
+	return "<" + l.id + ">"
+}
+
+// String returns the string representation of the ListID.
+func (l ListID) String() string {
+	if l.description.IsSome() && encode {
+		return fmt.Sprintf("%s <%s>", mime.QEncoding.Encode("utf-8", l.description.Unwrap()), l.id)
+	}
+	return l.Value()
+}
+
+// validListID reports whether the identifier is a dot-atom-text with at least two labels, as per RFC 2919 section 2.
+func validListID(id string) bool {
+	labels := strings.Split(id, ".")
+	if len(labels) < 2 {
+		return false
+	}
+	for _, label := range labels {
+		if label == "" {
+			return false
+		}
+		for _, c := range label {
+			if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.ContainsRune("!#$%&'*+-/=?^_`{|}~", c)) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// ListURI represents a URI of a List-* field, as per RFC 2369.
+// Only mailto and https URIs are accepted.
+type ListURI struct {
+	value string
+}
+
+// NewListURI creates a new ListURI instance with the given URI.
+func NewListURI(value string) (u *ListURI, err error) {
+	parsed, err := url.Parse(value)
+	if err != nil || !validFieldValue(value) || strings.ContainsAny(value, " \t<>") {
+		err = ErrorInvalidListURI
+		return
+	}
+	switch strings.ToLower(parsed.Scheme) {
+	case "mailto":
+		if _, e := mail.ParseAddress(parsed.Opaque); e != nil {
+			err = ErrorInvalidListURI
+			return
+		}
+	case "https":
+		if parsed.Host == "" {
+			err = ErrorInvalidListURI
+			return
+		}
+	default:
+		err = ErrorInvalidListURI
+		return
+	}
+	u = &ListURI{value: value}
+	return
+}
+
+// Value returns the URI.
+func (u ListURI) Value() string {
+	return u.value
+}
+
+// IsHTTPS reports whether the URI is an https URI.
+func (u ListURI) IsHTTPS() bool {
+	return strings.HasPrefix(strings.ToLower(u.value), "https:")
+}
+
+// String returns the URI enclosed in angle brackets.
+func (u ListURI) String() string {
+	return "<" + u.value + ">"
+}
+
+// ListURIs represents a slice of ListURI, in order of preference.
+type ListURIs []ListURI
+
+// NewListURIs creates a new ListURIs instance.
+func NewListURIs(uris ...ListURI) ListURIs {
+	return ListURIs(uris)
+}
+
+// Value returns the value of the ListURIs on a single line.
+func (l ListURIs) Value() string {
+	list := make([]string, 0, len(l))
+	for _, u := range l {
+		list = append(list, u.String())
+	}
+	return strings.Join(list, ", ")
+}
+
+// String returns the string representation of the ListURIs, with each URI on its own folded line.
+func (l ListURIs) String() string {
+	list := make([]string, 0, len(l))
+	for _, u := range l {
+		list = append(list, u.String())
+	}
+	return strings.Join(list, ",\r\n ")
+}
+
+// hasHTTPS reports whether one of the URIs is an https URI.
+func (l ListURIs) hasHTTPS() bool {
+	for _, u := range l {
+		if u.IsHTTPS() {
+			return true
+		}
+	}
+	return false
+}
+
+// oneClick is the value of the List-Unsubscribe-Post field, as per RFC 8058 section 3.1.
+const oneClick = "List-Unsubscribe=One-Click"
+
+// parseListID parses the value of a List-Id field.
+func parseListID(s string) (l ListID, err error) {
+	s = strings.TrimSpace(s)
+	start, end := strings.LastIndex(s, "<"), strings.LastIndex(s, ">")
+	if start < 0 || end < start {
+		err = ErrorInvalidListID
+		return
+	}
+	description := strings.Trim(strings.TrimSpace(s[:start]), `"`)
+	var parsed *ListID
+	if description != "" {
+		parsed, err = NewListIDWithDescription(decodeText(description), s[start+1:end])
+	} else {
+		parsed, err = NewListID(s[start+1 : end])
+	}
+	if err != nil {
+		return
+	}
+	l = *parsed
+	return
+}
+
+// parseListURIs parses the value of a List-* field made of URIs in angle brackets, ignoring comments.
+func parseListURIs(s string) (uris ListURIs, err error) {
+	p := &authParser{s: s}
+	uris = make(ListURIs, 0)
+	for p.cfws(); !p.eof(); p.cfws() {
+		if !p.consume('<') {
+			err = ErrorInvalidListURI
+			return
+		}
+		end := strings.IndexByte(p.s[p.pos:], '>')
+		if end < 0 {
+			err = ErrorInvalidListURI
+			return
+		}
+		// Whitespace in angle brackets is ignored, as per RFC 2369 section 2.
+		value := strings.Join(strings.Fields(p.s[p.pos:p.pos+end]), "")
+		p.pos += end + 1
+		var u *ListURI
+		if u, err = NewListURI(value); err != nil {
+			return
+		}
+		uris = append(uris, *u)
+		p.cfws()
+		if !p.eof() && !p.consume(',') {
+			err = ErrorInvalidListURI
+			return
+		}
+	}
+	if len(uris) == 0 {
+		err = ErrorInvalidListURI
+	}
+	return
+}
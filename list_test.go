@@ -0,0 +1,145 @@
+package rfc5322_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aethiopicuschan/rfc5322-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func mustListURIs(t *testing.T, values ...string) rfc5322.ListURIs {
+	t.Helper()
+	uris := rfc5322.NewListURIs()
+	for _, value := range values {
+		u, err := rfc5322.NewListURI(value)
+		assert.NoError(t, err)
+		uris = append(uris, *u)
+	}
+	return uris
+}
+
+func TestNewListURI(t *testing.T) {
+	testCases := []struct {
+		name  string
+		value string
+		err   error
+	}{
+		{"Mailto", "mailto:list-request@example.com?subject=unsubscribe", nil},
+		{"HTTPS", "https://example.com/unsubscribe?id=42", nil},
+		{"HTTP", "http://example.com/unsubscribe", rfc5322.ErrorInvalidListURI},
+		{"InvalidMailto", "mailto:not an address", rfc5322.ErrorInvalidListURI},
+		{"NoHost", "https:///unsubscribe", rfc5322.ErrorInvalidListURI},
+		{"AngleBracket", "https://example.com/>", rfc5322.ErrorInvalidListURI},
+		{"Injection", "https://example.com/\r\nBcc: eve@example.com", rfc5322.ErrorInvalidListURI},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			u, err := rfc5322.NewListURI(tc.value)
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, "<"+tc.value+">", u.String())
+		})
+	}
+}
+
+func TestNewListID(t *testing.T) {
+	id, err := rfc5322.NewListIDWithDescription("Weekly newsletter", "newsletter.example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, "Weekly newsletter <newsletter.example.com>", id.Value())
+
+	_, err = rfc5322.NewListID("localhost")
+	assert.ErrorIs(t, err, rfc5322.ErrorInvalidListID)
+	_, err = rfc5322.NewListID("bad..example.com")
+	assert.ErrorIs(t, err, rfc5322.ErrorInvalidListID)
+	_, err = rfc5322.NewListIDWithDescription("Bad\r\nBcc: eve@example.com", "newsletter.example.com")
+	assert.ErrorIs(t, err, rfc5322.ErrorInvalidName)
+}
+
+func TestListHeaders(t *testing.T) {
+	id, err := rfc5322.NewListIDWithDescription("Weekly newsletter", "newsletter.example.com")
+	assert.NoError(t, err)
+	header := newTestHeader(t).
+		SetListID(*id).
+		SetListUnsubscribe(mustListURIs(t, "https://example.com/unsubscribe?id=42", "mailto:unsubscribe@example.com")).
+		SetListUnsubscribePost().
+		SetListArchive(mustListURIs(t, "https://example.com/archive")).
+		SetListPost(rfc5322.NewListURIs())
+
+	s, err := rfc5322.NewEMail(header, rfc5322.NewBody()).String()
+	assert.NoError(t, err)
+	assert.Contains(t, s, "List-Id: Weekly newsletter <newsletter.example.com>\r\n")
+	assert.Contains(t, s, "List-Unsubscribe: <https://example.com/unsubscribe?id=42>,\r\n <mailto:unsubscribe@example.com>\r\n")
+	assert.Contains(t, s, "List-Unsubscribe-Post: List-Unsubscribe=One-Click\r\n")
+	assert.Contains(t, s, "List-Archive: <https://example.com/archive>\r\n")
+	assert.Contains(t, s, "List-Post: NO\r\n")
+	assert.Equal(t, "<https://example.com/unsubscribe?id=42>, <mailto:unsubscribe@example.com>", header.Get("List-Unsubscribe"))
+
+	parsed, err := rfc5322.Parse(strings.NewReader(s))
+	assert.NoError(t, err)
+	h := parsed.Header()
+	assert.Equal(t, "newsletter.example.com", h.ListID().Unwrap().ID())
+	assert.Equal(t, "Weekly newsletter", h.ListID().Unwrap().Description().Unwrap())
+	assert.Equal(t, header.ListUnsubscribe().Unwrap(), h.ListUnsubscribe().Unwrap())
+	assert.True(t, h.ListUnsubscribePost())
+	assert.Empty(t, h.ListPost().Unwrap())
+	assert.True(t, h.ListHelp().IsNone())
+
+	// One-click unsubscription needs an https URI.
+	header.SetListUnsubscribe(mustListURIs(t, "mailto:unsubscribe@example.com"))
+	_, err = header.String()
+	assert.ErrorIs(t, err, rfc5322.ErrorNeedListUnsubscribeHTTPS)
+}
+
+func TestListHeadersEmpty(t *testing.T) {
+	testCases := []struct {
+		name string
+		set  func(h *rfc5322.Header, uris rfc5322.ListURIs) *rfc5322.Header
+		err  error
+	}{
+		{"Post", (*rfc5322.Header).SetListPost, nil},
+		{"Help", (*rfc5322.Header).SetListHelp, rfc5322.ErrorEmptyListURIs},
+		{"Unsubscribe", (*rfc5322.Header).SetListUnsubscribe, rfc5322.ErrorEmptyListURIs},
+		{"Subscribe", (*rfc5322.Header).SetListSubscribe, rfc5322.ErrorEmptyListURIs},
+		{"Owner", (*rfc5322.Header).SetListOwner, rfc5322.ErrorEmptyListURIs},
+		{"Archive", (*rfc5322.Header).SetListArchive, rfc5322.ErrorEmptyListURIs},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			header := tc.set(newTestHeader(t), rfc5322.NewListURIs())
+			s, err := rfc5322.NewEMail(header, rfc5322.NewBody()).String()
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Contains(t, s, "List-"+tc.name+": NO\r\n")
+		})
+	}
+}
+
+func TestParseListHeaders(t *testing.T) {
+	message := "Date: Sun, 01 Oct 2023 12:00:00 +0000\r\n" +
+		"From: list@example.com\r\n" +
+		"List-Id: <list.example.com>\r\n" +
+		"List-Help: <mailto:list@example.com?subject=help> (List Instructions)\r\n" +
+		"List-Subscribe: < https://example.com/ subscribe >\r\n" +
+		"List-Owner: <ftp://example.com/owner>\r\n" +
+		"\r\n"
+	parsed, err := rfc5322.Parse(strings.NewReader(message))
+	assert.NoError(t, err)
+	h := parsed.Header()
+	assert.Equal(t, "list.example.com", h.ListID().Unwrap().ID())
+	assert.Equal(t, "mailto:list@example.com?subject=help", h.ListHelp().Unwrap()[0].Value())
+	assert.Equal(t, "https://example.com/subscribe", h.ListSubscribe().Unwrap()[0].Value())
+	// A field with an unsupported URI is kept as is.
+	assert.True(t, h.ListOwner().IsNone())
+	assert.Equal(t, "<ftp://example.com/owner>", h.Get("List-Owner"))
+}
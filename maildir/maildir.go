@@ -0,0 +1,254 @@
+// Package maildir delivers and reads rfc5322.EMail values in the Maildir format.
+//
+// See https://cr.yp.to/proto/maildir.html for the format.
+package maildir
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/aethiopicuschan/rfc5322-go"
+)
+
+var ErrorInvalidFlag = errors.New("invalid maildir flag")
+
+// Flag represents a flag of the "2," info suffix.
+type Flag rune
+
+const (
+	// FlagPassed means the message has been resent, forwarded or bounced.
+	FlagPassed Flag = 'P'
+	// FlagReplied means the message has been replied to.
+	FlagReplied Flag = 'R'
+	// FlagSeen means the message has been read.
+	FlagSeen Flag = 'S'
+	// FlagTrashed means the message has been moved to the trash.
+	FlagTrashed Flag = 'T'
+	// FlagDraft means the message is a draft.
+	FlagDraft Flag = 'D'
+	// FlagFlagged means the message has been flagged.
+	FlagFlagged Flag = 'F'
+)
+
+// infoSeparator separates the unique name from the info suffix.
+const infoSeparator = ":2,"
+
+// deliveries counts the deliveries of this process, to make the unique names unique.
+var deliveries atomic.Uint64
+
+// Maildir represents a Maildir directory with its tmp, new and cur subdirectories.
+type Maildir struct {
+	path string
+}
+
+// New creates a new Maildir for the directory at path.
+func New(path string) *Maildir {
+	return &Maildir{
+		path: path,
+	}
+}
+
+// Path returns the path of the directory.
+func (d *Maildir) Path() string {
+	return d.path
+}
+
+// Init creates the directory and its tmp, new and cur subdirectories if they do not exist.
+func (d *Maildir) Init() (err error) {
+	for _, sub := range []string{"tmp", "new", "cur"} {
+		if err = os.MkdirAll(filepath.Join(d.path, sub), 0o700); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// Deliver delivers the EMail into new, and returns the unique name of the message.
+// The message is written into tmp first and then moved, so readers never see a partial message.
+func (d *Maildir) Deliver(e *rfc5322.EMail) (key string, err error) {
+	s, err := e.String()
+	if err != nil {
+		return
+	}
+	// Messages in a Maildir use the local line ending convention, which is LF.
+	data := bytes.ReplaceAll([]byte(s), []byte("\r\n"), []byte("\n"))
+
+	key, err = uniqueName()
+	if err != nil {
+		return
+	}
+	tmp := filepath.Join(d.path, "tmp", key)
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			os.Remove(tmp)
+		}
+	}()
+	if _, err = f.Write(data); err != nil {
+		f.Close()
+		return
+	}
+	if err = f.Sync(); err != nil {
+		f.Close()
+		return
+	}
+	if err = f.Close(); err != nil {
+		return
+	}
+
+	dst := filepath.Join(d.path, "new", key)
+	// Link fails instead of overwriting an existing message.
+	if err = os.Link(tmp, dst); err != nil {
+		if errors.Is(err, os.ErrExist) {
+			return
+		}
+		// Some file systems do not support hard links.
+		err = os.Rename(tmp, dst)
+		return
+	}
+	err = os.Remove(tmp)
+	return
+}
+
+// uniqueName returns a unique name as per the Maildir specification:
+// the time, the microseconds, the process ID, a delivery counter and random bytes, and the host name.
+func uniqueName() (name string, err error) {
+	host, err := os.Hostname()
+	if err != nil {
+		return
+	}
+	host = strings.ReplaceAll(host, "/", `\057`)
+	host = strings.ReplaceAll(host, ":", `\072`)
+	random := make([]byte, 8)
+	if _, err = rand.Read(random); err != nil {
+		return
+	}
+	now := time.Now()
+	name = fmt.Sprintf("%d.M%dP%dQ%dR%s.%s",
+		now.Unix(), now.Nanosecond()/1000, os.Getpid(), deliveries.Add(1), hex.EncodeToString(random), host)
+	return
+}
+
+// Message represents a message in a Maildir.
+type Message struct {
+	// Key is the unique name of the message, without the info suffix.
+	Key string
+	// Path is the path of the message file.
+	Path string
+	// New reports whether the message is in new, that is, it has not been seen by a mail reader yet.
+	New bool
+	// Flags are the flags of the info suffix, in ASCII order.
+	Flags []Flag
+}
+
+// HasFlag reports whether the message has the flag.
+func (m Message) HasFlag(flag Flag) bool {
+	return slices.Contains(m.Flags, flag)
+}
+
+// EMail reads and parses the message.
+func (m Message) EMail() (e *rfc5322.EMail, err error) {
+	f, err := os.Open(m.Path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	return rfc5322.ReadEML(f)
+}
+
+// List returns the messages in new and cur, ordered by their unique names.
+// Files whose names begin with a dot are ignored, as per the Maildir specification.
+func (d *Maildir) List() (messages []Message, err error) {
+	messages = make([]Message, 0)
+	for _, sub := range []string{"new", "cur"} {
+		var entries []os.DirEntry
+		entries, err = os.ReadDir(filepath.Join(d.path, sub))
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+				continue
+			}
+			key, flags := parseName(entry.Name())
+			messages = append(messages, Message{
+				Key:   key,
+				Path:  filepath.Join(d.path, sub, entry.Name()),
+				New:   sub == "new",
+				Flags: flags,
+			})
+		}
+	}
+	sort.SliceStable(messages, func(i, j int) bool {
+		return messages[i].Key < messages[j].Key
+	})
+	return
+}
+
+// parseName splits a file name into the unique name and the flags of the "2," info suffix.
+// Experimental ("1,") and unknown info suffixes are kept as a part of the name.
+func parseName(name string) (key string, flags []Flag) {
+	i := strings.LastIndex(name, infoSeparator)
+	if i < 0 {
+		return name, nil
+	}
+	key = name[:i]
+	flags = make([]Flag, 0)
+	for _, r := range name[i+len(infoSeparator):] {
+		flags = append(flags, Flag(r))
+	}
+	slices.Sort(flags)
+	return key, slices.Compact(flags)
+}
+
+// SetFlags moves the message into cur with the given flags, and returns the moved message.
+func (d *Maildir) SetFlags(m Message, flags ...Flag) (moved Message, err error) {
+	suffix, err := formatFlags(flags)
+	if err != nil {
+		return
+	}
+	path := filepath.Join(d.path, "cur", m.Key+suffix)
+	if err = os.Rename(m.Path, path); err != nil {
+		return
+	}
+	_, parsed := parseName(filepath.Base(path))
+	moved = Message{
+		Key:   m.Key,
+		Path:  path,
+		New:   false,
+		Flags: parsed,
+	}
+	return
+}
+
+// formatFlags returns the info suffix for the flags.
+func formatFlags(flags []Flag) (s string, err error) {
+	flags = slices.Clone(flags)
+	for _, f := range flags {
+		if f < '!' || f > '~' || f == ',' || f == ':' || f == '/' {
+			err = ErrorInvalidFlag
+			return
+		}
+	}
+	slices.Sort(flags)
+	var sb strings.Builder
+	sb.WriteString(infoSeparator)
+	for _, f := range slices.Compact(flags) {
+		sb.WriteRune(rune(f))
+	}
+	s = sb.String()
+	return
+}
@@ -0,0 +1,99 @@
+package maildir_test
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aethiopicuschan/rfc5322-go"
+	"github.com/aethiopicuschan/rfc5322-go/maildir"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestEMail(t *testing.T, subject string) *rfc5322.EMail {
+	t.Helper()
+	from, err := rfc5322.NewAddress("alice@example.com")
+	assert.NoError(t, err)
+	header := rfc5322.NewHeader(*rfc5322.NewDate(time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)), rfc5322.NewAddresses(*from))
+	header.SetSubject(subject)
+	body := rfc5322.NewBody()
+	body.SetContent([]byte("Hello\r\n"))
+	return rfc5322.NewEMail(header, body)
+}
+
+func TestDeliver(t *testing.T) {
+	d := maildir.New(filepath.Join(t.TempDir(), "Maildir"))
+	assert.NoError(t, d.Init())
+
+	var wg sync.WaitGroup
+	keys := make(chan string, 50)
+	for range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			key, err := d.Deliver(newTestEMail(t, "Hello"))
+			assert.NoError(t, err)
+			keys <- key
+		}()
+	}
+	wg.Wait()
+	close(keys)
+
+	unique := make(map[string]struct{})
+	for key := range keys {
+		unique[key] = struct{}{}
+		data, err := os.ReadFile(filepath.Join(d.Path(), "new", key))
+		assert.NoError(t, err)
+		assert.Contains(t, string(data), "Subject: Hello\n\nHello\n")
+	}
+	assert.Len(t, unique, 50)
+
+	tmp, err := os.ReadDir(filepath.Join(d.Path(), "tmp"))
+	assert.NoError(t, err)
+	assert.Empty(t, tmp)
+}
+
+func TestList(t *testing.T) {
+	d := maildir.New(t.TempDir())
+	assert.NoError(t, d.Init())
+	key, err := d.Deliver(newTestEMail(t, "new message"))
+	assert.NoError(t, err)
+
+	data := "Date: Sun, 01 Oct 2023 12:00:00 +0000\nFrom: bob@example.com\nSubject: seen message\n\nHi\n"
+	assert.NoError(t, os.WriteFile(filepath.Join(d.Path(), "cur", "1.M1P1.host:2,SR"), []byte(data), 0o600))
+	assert.NoError(t, os.WriteFile(filepath.Join(d.Path(), "cur", ".hidden"), []byte(data), 0o600))
+
+	messages, err := d.List()
+	assert.NoError(t, err)
+	if !assert.Len(t, messages, 2) {
+		return
+	}
+
+	assert.Equal(t, "1.M1P1.host", messages[0].Key)
+	assert.False(t, messages[0].New)
+	assert.Equal(t, []maildir.Flag{maildir.FlagReplied, maildir.FlagSeen}, messages[0].Flags)
+	assert.True(t, messages[0].HasFlag(maildir.FlagSeen))
+	assert.False(t, messages[0].HasFlag(maildir.FlagTrashed))
+	email, err := messages[0].EMail()
+	assert.NoError(t, err)
+	assert.Equal(t, "seen message", email.Header().Subject().Unwrap())
+
+	assert.Equal(t, key, messages[1].Key)
+	assert.True(t, messages[1].New)
+	assert.Empty(t, messages[1].Flags)
+	email, err = messages[1].EMail()
+	assert.NoError(t, err)
+	s, err := email.String()
+	assert.NoError(t, err)
+	assert.Contains(t, s, "Subject: new message\r\n\r\nHello\r\n")
+
+	moved, err := d.SetFlags(messages[1], maildir.FlagSeen, maildir.FlagFlagged, maildir.FlagSeen)
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(d.Path(), "cur", key+":2,FS"), moved.Path)
+	assert.Equal(t, []maildir.Flag{maildir.FlagFlagged, maildir.FlagSeen}, moved.Flags)
+
+	_, err = d.SetFlags(moved, maildir.Flag(':'))
+	assert.ErrorIs(t, err, maildir.ErrorInvalidFlag)
+}
@@ -0,0 +1,87 @@
+// Package mbox reads and writes rfc5322.EMail values in the mbox format as per RFC 4155.
+//
+// The mboxo, mboxrd and mboxcl2 variants are supported. They only differ in how the
+// "From " lines in the messages are protected from being read as message separators.
+package mbox
+
+import (
+	"bytes"
+	"errors"
+	"regexp"
+	"time"
+)
+
+// Variant represents a variant of the mbox format.
+type Variant int
+
+const (
+	// MBOXO quotes lines beginning with "From " as ">From ". The quoting is not reversible.
+	MBOXO Variant = iota
+	// MBOXRD quotes lines beginning with any number of ">" followed by "From " with an additional ">".
+	MBOXRD
+	// MBOXCL2 does not quote lines, and adds a Content-Length field with the length of the message body.
+	MBOXCL2
+)
+
+var ErrorInvalidFormat = errors.New("invalid mbox format")
+
+// asctime is the time format of the From_ line.
+const asctime = "Mon Jan _2 15:04:05 2006"
+
+// defaultSender is used in the From_ line when the envelope sender is unknown.
+const defaultSender = "MAILER-DAEMON"
+
+// fromLine matches a line that begins with "From ", possibly quoted with ">".
+var fromLine = regexp.MustCompile(`^>*From `)
+
+// quote protects the lines of the message from being read as message separators.
+func quote(data []byte, variant Variant) []byte {
+	if variant == MBOXCL2 {
+		return data
+	}
+	var buf bytes.Buffer
+	for line := range bytes.Lines(data) {
+		switch variant {
+		case MBOXO:
+			if bytes.HasPrefix(line, []byte("From ")) {
+				buf.WriteByte('>')
+			}
+		case MBOXRD:
+			if fromLine.Match(line) {
+				buf.WriteByte('>')
+			}
+		}
+		buf.Write(line)
+	}
+	return buf.Bytes()
+}
+
+// unquote reverses quote.
+func unquote(data []byte, variant Variant) []byte {
+	if variant == MBOXCL2 {
+		return data
+	}
+	var buf bytes.Buffer
+	for line := range bytes.Lines(data) {
+		switch variant {
+		case MBOXO:
+			if bytes.HasPrefix(line, []byte(">From ")) {
+				line = line[1:]
+			}
+		case MBOXRD:
+			if line[0] == '>' && fromLine.Match(line) {
+				line = line[1:]
+			}
+		}
+		buf.Write(line)
+	}
+	return buf.Bytes()
+}
+
+// formatFromLine returns the From_ line that starts a message.
+func formatFromLine(sender string, t time.Time) string {
+	if sender == "" {
+		sender = defaultSender
+	}
+	return "From " + sender + " " + t.UTC().Format(asctime) + "\n"
+}
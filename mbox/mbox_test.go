@@ -0,0 +1,154 @@
+package mbox_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aethiopicuschan/rfc5322-go"
+	"github.com/aethiopicuschan/rfc5322-go/mbox"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestEMail(t *testing.T, subject, content string) *rfc5322.EMail {
+	t.Helper()
+	from, err := rfc5322.NewAddressWithName("Alice", "alice@example.com")
+	assert.NoError(t, err)
+	to, err := rfc5322.NewAddress("bob@example.com")
+	assert.NoError(t, err)
+	header := rfc5322.NewHeader(*rfc5322.NewDate(time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)), rfc5322.NewAddresses(*from))
+	header.AddTo(*to).SetSubject(subject)
+	body := rfc5322.NewBody()
+	body.SetContent([]byte(content))
+	return rfc5322.NewEMail(header, body)
+}
+
+func TestWriter(t *testing.T) {
+	content := "From the start\r\n>From quoted\r\nBye\r\n"
+	testCases := []struct {
+		name     string
+		variant  mbox.Variant
+		expected string
+	}{
+		{
+			name:    "mboxo",
+			variant: mbox.MBOXO,
+			expected: "From alice@example.com Sun Oct  1 12:00:00 2023\n" +
+				"MIME-Version: 1.0\n" +
+				"Date: Sun, 01 Oct 2023 12:00:00 +0000\n" +
+				"From: Alice <alice@example.com>\n" +
+				"To: bob@example.com\n" +
+				"Subject: Hello\n" +
+				"\n" +
+				">From the start\n" +
+				">From quoted\n" +
+				"Bye\n" +
+				"\n",
+		},
+		{
+			name:    "mboxrd",
+			variant: mbox.MBOXRD,
+			expected: "From alice@example.com Sun Oct  1 12:00:00 2023\n" +
+				"MIME-Version: 1.0\n" +
+				"Date: Sun, 01 Oct 2023 12:00:00 +0000\n" +
+				"From: Alice <alice@example.com>\n" +
+				"To: bob@example.com\n" +
+				"Subject: Hello\n" +
+				"\n" +
+				">From the start\n" +
+				">>From quoted\n" +
+				"Bye\n" +
+				"\n",
+		},
+		{
+			name:    "mboxcl2",
+			variant: mbox.MBOXCL2,
+			expected: "From alice@example.com Sun Oct  1 12:00:00 2023\n" +
+				"MIME-Version: 1.0\n" +
+				"Date: Sun, 01 Oct 2023 12:00:00 +0000\n" +
+				"From: Alice <alice@example.com>\n" +
+				"To: bob@example.com\n" +
+				"Subject: Hello\n" +
+				"Content-Length: 32\n" +
+				"\n" +
+				"From the start\n" +
+				">From quoted\n" +
+				"Bye\n" +
+				"\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			var buf bytes.Buffer
+			err := mbox.NewWriter(&buf, tc.variant).Write(newTestEMail(t, "Hello", content))
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, buf.String())
+		})
+	}
+}
+
+func TestReader(t *testing.T) {
+	contents := []string{
+		"From the start\r\n>From quoted\r\nBye\r\n",
+		"Second message\r\n\r\nFrom here\r\n",
+		"",
+	}
+	testCases := []struct {
+		name     string
+		variant  mbox.Variant
+		expected []string
+	}{
+		{
+			name:    "mboxo",
+			variant: mbox.MBOXO,
+			// The quoting of mboxo is not reversible.
+			expected: []string{
+				"From the start\r\nFrom quoted\r\nBye\r\n",
+				"Second message\r\n\r\nFrom here\r\n",
+				"",
+			},
+		},
+		{
+			name:     "mboxrd",
+			variant:  mbox.MBOXRD,
+			expected: contents,
+		},
+		{
+			name:     "mboxcl2",
+			variant:  mbox.MBOXCL2,
+			expected: contents,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			var buf bytes.Buffer
+			w := mbox.NewWriter(&buf, tc.variant)
+			for i, content := range contents {
+				assert.NoError(t, w.Write(newTestEMail(t, strings.Repeat("a", i+1), content)))
+			}
+
+			i := 0
+			for email, err := range mbox.NewReader(&buf, tc.variant).All() {
+				assert.NoError(t, err)
+				assert.Equal(t, strings.Repeat("a", i+1), email.Header().Subject().Unwrap())
+				s, err := email.String()
+				assert.NoError(t, err)
+				_, body, _ := strings.Cut(s, "\r\n\r\n")
+				assert.Equal(t, tc.expected[i], body)
+				i++
+			}
+			assert.Equal(t, len(contents), i)
+		})
+	}
+}
+
+func TestReaderInvalid(t *testing.T) {
+	r := mbox.NewReader(strings.NewReader("Subject: not an mbox\n\nHello\n"), mbox.MBOXRD)
+	_, err := r.Next()
+	assert.ErrorIs(t, err, mbox.ErrorInvalidFormat)
+}
@@ -0,0 +1,180 @@
+package mbox
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"iter"
+	"strconv"
+	"strings"
+
+	"github.com/aethiopicuschan/rfc5322-go"
+)
+
+// Reader reads EMail values from an mbox file.
+type Reader struct {
+	r       *bufio.Reader
+	variant Variant
+	// next is the From_ line of the next message, which has already been read.
+	next []byte
+	done bool
+}
+
+// NewReader creates a new Reader that reads the given variant from r.
+func NewReader(r io.Reader, variant Variant) *Reader {
+	return &Reader{
+		r:       bufio.NewReader(r),
+		variant: variant,
+	}
+}
+
+// readLine returns the next line including its line break, or io.EOF.
+func (r *Reader) readLine() (line []byte, err error) {
+	line, err = r.r.ReadBytes('\n')
+	if err == io.EOF && len(line) > 0 {
+		err = nil
+	}
+	return
+}
+
+// Next reads the next message. It returns io.EOF when there are no more messages.
+func (r *Reader) Next() (e *rfc5322.EMail, err error) {
+	if r.done {
+		return nil, io.EOF
+	}
+	if r.next == nil {
+		// Skip the empty lines before the first message.
+		for {
+			var line []byte
+			if line, err = r.readLine(); err != nil {
+				r.done = true
+				return
+			}
+			if len(bytes.TrimSpace(line)) == 0 {
+				continue
+			}
+			if !bytes.HasPrefix(line, []byte("From ")) {
+				r.done = true
+				return nil, ErrorInvalidFormat
+			}
+			break
+		}
+	}
+	r.next = nil
+
+	var data []byte
+	if r.variant == MBOXCL2 {
+		data, err = r.readCounted()
+	} else {
+		data, err = r.readUntilFromLine()
+	}
+	if err != nil {
+		r.done = true
+		return
+	}
+
+	return rfc5322.ReadEML(bytes.NewReader(unquote(data, r.variant)))
+}
+
+// All returns an iterator over the remaining messages.
+// The iteration stops after the first error.
+func (r *Reader) All() iter.Seq2[*rfc5322.EMail, error] {
+	return func(yield func(*rfc5322.EMail, error) bool) {
+		for {
+			e, err := r.Next()
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			if !yield(e, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+// readUntilFromLine reads a message up to the next From_ line, which is kept for the next message.
+func (r *Reader) readUntilFromLine() (data []byte, err error) {
+	for {
+		var line []byte
+		line, err = r.readLine()
+		if err == io.EOF {
+			err = nil
+			r.done = true
+			break
+		}
+		if err != nil {
+			return
+		}
+		if bytes.HasPrefix(line, []byte("From ")) {
+			r.next = line
+			break
+		}
+		data = append(data, line...)
+	}
+	// The empty line before the next message is a separator.
+	data = bytes.TrimSuffix(data, []byte("\n"))
+	if !bytes.HasSuffix(data, []byte("\n")) {
+		data = append(data, '\n')
+	}
+	return
+}
+
+// readCounted reads a message whose body length is given by its Content-Length field.
+func (r *Reader) readCounted() (data []byte, err error) {
+	length := -1
+	for {
+		var line []byte
+		if line, err = r.readLine(); err != nil {
+			if err == io.EOF {
+				err = ErrorInvalidFormat
+			}
+			return
+		}
+		name, value, ok := strings.Cut(string(line), ":")
+		if ok && strings.EqualFold(strings.TrimSpace(name), "Content-Length") {
+			if length, err = strconv.Atoi(strings.TrimSpace(value)); err != nil || length < 0 {
+				return nil, ErrorInvalidFormat
+			}
+			continue
+		}
+		data = append(data, line...)
+		if len(bytes.TrimRight(line, "\r\n")) == 0 {
+			break
+		}
+	}
+	if length < 0 {
+		// Without a Content-Length field, fall back to the From_ line separator.
+		var rest []byte
+		rest, err = r.readUntilFromLine()
+		data = append(data, rest...)
+		return
+	}
+
+	body := make([]byte, length)
+	if _, err = io.ReadFull(r.r, body); err != nil {
+		return nil, ErrorInvalidFormat
+	}
+	data = append(data, body...)
+
+	// Skip the separators up to the next From_ line.
+	for {
+		var line []byte
+		line, err = r.readLine()
+		if err == io.EOF {
+			err = nil
+			r.done = true
+			return
+		}
+		if err != nil {
+			return
+		}
+		if bytes.HasPrefix(line, []byte("From ")) {
+			r.next = line
+			return
+		}
+		if len(bytes.TrimSpace(line)) != 0 {
+			return nil, ErrorInvalidFormat
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package mbox
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/mail"
+	"time"
+
+	"github.com/aethiopicuschan/rfc5322-go"
+)
+
+// Writer writes EMail values to an mbox file.
+type Writer struct {
+	w       io.Writer
+	variant Variant
+}
+
+// NewWriter creates a new Writer that writes the given variant to w.
+func NewWriter(w io.Writer, variant Variant) *Writer {
+	return &Writer{
+		w:       w,
+		variant: variant,
+	}
+}
+
+// Write writes the EMail as a single message.
+// The From_ line is made of the envelope sender and the Date field of the EMail.
+func (w *Writer) Write(e *rfc5322.EMail) (err error) {
+	sender := defaultSender
+	if env, envErr := e.Envelope(); envErr == nil {
+		sender = env.From
+	} else if len(e.Header().From()) > 0 {
+		sender = e.Header().From()[0].Value()
+		if addr, parseErr := mail.ParseAddress(sender); parseErr == nil {
+			sender = addr.Address
+		}
+	}
+	t, err := mail.ParseDate(e.Header().Date().String())
+	if err != nil {
+		t = time.Now()
+	}
+	return w.WriteFrom(e, sender, t)
+}
+
+// WriteFrom writes the EMail as a single message with the given envelope sender and delivery time in the From_ line.
+func (w *Writer) WriteFrom(e *rfc5322.EMail, sender string, t time.Time) (err error) {
+	s, err := e.String()
+	if err != nil {
+		return
+	}
+	// mbox files use the local line ending convention, which is LF.
+	data := bytes.ReplaceAll([]byte(s), []byte("\r\n"), []byte("\n"))
+	if !bytes.HasSuffix(data, []byte("\n")) {
+		data = append(data, '\n')
+	}
+	if w.variant == MBOXCL2 {
+		data = addContentLength(data)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(formatFromLine(sender, t))
+	buf.Write(quote(data, w.variant))
+	// An empty line separates the messages.
+	buf.WriteByte('\n')
+	_, err = w.w.Write(buf.Bytes())
+	return
+}
+
+// addContentLength adds a Content-Length field with the length of the body at the end of the header.
+func addContentLength(data []byte) []byte {
+	header, body, _ := bytes.Cut(data, []byte("\n\n"))
+	header = append(header, '\n')
+	var buf bytes.Buffer
+	buf.Write(header)
+	buf.WriteString(fmt.Sprintf("Content-Length: %d\n\n", len(body)))
+	buf.Write(body)
+	return buf.Bytes()
+}
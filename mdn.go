@@ -0,0 +1,169 @@
+package rfc5322
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// MDNDisposition is the disposition type of a message disposition notification, as per RFC 8098 section 3.2.6.2.
+type MDNDisposition string
+
+const (
+	MDNDisplayed  MDNDisposition = "displayed"
+	MDNDeleted    MDNDisposition = "deleted"
+	MDNDispatched MDNDisposition = "dispatched"
+	MDNProcessed  MDNDisposition = "processed"
+)
+
+// MDN is a message disposition notification, as per RFC 8098.
+type MDN struct {
+	// Text is the human readable explanation of the notification.
+	Text string
+	// ReportingUA is the name of the user agent that generated the notification.
+	ReportingUA string
+	// OriginalRecipient and FinalRecipient are the rfc822 addresses of the recipient of the original message.
+	OriginalRecipient string
+	FinalRecipient    string
+	// OriginalMessageID is the Message-ID of the original message.
+	OriginalMessageID string
+	Disposition       MDNDisposition
+	// Automatic reports whether the disposition was performed and the notification sent without an explicit action of the user.
+	Automatic bool
+	// Original is the original message, or nil if it is not included.
+	Original *EMail
+	// HeadersOnly includes only the header of the original message.
+	HeadersOnly bool
+}
+
+// Body returns the multipart/report Body of the notification, with a message/disposition-notification part.
+func (m MDN) Body() (b *Body, err error) {
+	if m.FinalRecipient == "" {
+		err = ErrorInvalidMDN
+		return
+	}
+	switch m.Disposition {
+	case MDNDisplayed, MDNDeleted, MDNDispatched, MDNProcessed:
+	default:
+		err = ErrorInvalidMDN
+		return
+	}
+	for _, v := range []string{m.ReportingUA, m.OriginalRecipient, m.FinalRecipient, m.OriginalMessageID} {
+		if strings.ContainsAny(v, "\r\n\x00") {
+			err = ErrorInvalidFieldValue
+			return
+		}
+	}
+
+	var buf bytes.Buffer
+	writeField(&buf, "Reporting-UA", m.ReportingUA)
+	if m.OriginalRecipient != "" {
+		writeField(&buf, "Original-Recipient", "rfc822; "+m.OriginalRecipient)
+	}
+	writeField(&buf, "Final-Recipient", "rfc822; "+m.FinalRecipient)
+	writeField(&buf, "Original-Message-ID", m.OriginalMessageID)
+	mode := "manual-action/MDN-sent-manually"
+	if m.Automatic {
+		mode = "automatic-action/MDN-sent-automatically"
+	}
+	writeField(&buf, "Disposition", mode+"; "+string(m.Disposition))
+
+	report := NewBody()
+	report.setHeader("Content-Type", "message/disposition-notification")
+	report.content = buf.Bytes()
+	return newReport("disposition-notification", m.Text, report, m.Original, m.HeadersOnly)
+}
+
+// NewMDN creates a notification of the disposition of the received EMail by the recipient, addressed to its Disposition-Notification-To field.
+// The notification is sent manually, and includes the header of the received EMail.
+// It returns ErrorNoMDNRequest if the received EMail does not request a notification.
+func NewMDN(received *EMail, recipient Address, disposition MDNDisposition) (e *EMail, err error) {
+	h := received.header
+	if h.dispositionNotificationTo.IsNone() || len(h.dispositionNotificationTo.Unwrap()) == 0 {
+		err = ErrorNoMDNRequest
+		return
+	}
+	addrSpec, err := recipient.addrSpec()
+	if err != nil {
+		return
+	}
+
+	m := MDN{
+		FinalRecipient: addrSpec,
+		Disposition:    disposition,
+		Original:       received,
+		HeadersOnly:    true,
+	}
+	subject := h.subject.TakeOr("")
+	m.Text = fmt.Sprintf("The message sent on %s to %s with subject \"%s\" has been %s.\r\n", h.date.String(), addrSpec, subject, disposition)
+	if h.messageID.IsSome() {
+		m.OriginalMessageID = h.messageID.Unwrap().String()
+	}
+	b, err := m.Body()
+	if err != nil {
+		return
+	}
+
+	header := NewHeader(*NewDate(time.Now()), NewAddresses(recipient))
+	for _, to := range h.dispositionNotificationTo.Unwrap() {
+		header.AddTo(to)
+	}
+	if subject != "" {
+		header.SetSubject("Disposition notification: " + subject)
+	} else {
+		header.SetSubject("Disposition notification")
+	}
+	if h.messageID.IsSome() {
+		header.SetInReplyTo(m.OriginalMessageID)
+		header.AddReference(h.messageID.Unwrap())
+	}
+	e = NewEMail(header, b)
+	return
+}
+
+// ParseMDN parses the message disposition notification of the EMail.
+// The multipart/report Body with report-type=disposition-notification is looked up in the whole tree of the Body.
+func ParseMDN(e *EMail) (m *MDN, err error) {
+	report, ok := e.body.findReport("disposition-notification")
+	if !ok {
+		err = ErrorNotReport
+		return
+	}
+	text, machine, original, headersOnly, err := report.reportParts("message/disposition-notification")
+	if err != nil {
+		return
+	}
+	content, err := machine.decodedContent()
+	if err != nil {
+		return
+	}
+	blocks := parseFieldBlocks(content)
+	if len(blocks) == 0 {
+		err = ErrorInvalidMDN
+		return
+	}
+	fields := blocks[0]
+
+	// The disposition is of the form "action-mode/sending-mode; disposition-type/modifiers".
+	modes, disposition, ok := strings.Cut(fieldValue(fields, "Disposition"), ";")
+	if !ok {
+		err = ErrorInvalidMDN
+		return
+	}
+	actionMode, _, _ := strings.Cut(modes, "/")
+	dispositionType, _, _ := strings.Cut(disposition, "/")
+
+	m = &MDN{
+		Text:              text,
+		ReportingUA:       fieldValue(fields, "Reporting-UA"),
+		OriginalRecipient: typedValue(fields, "Original-Recipient"),
+		FinalRecipient:    typedValue(fields, "Final-Recipient"),
+		OriginalMessageID: fieldValue(fields, "Original-Message-ID"),
+		Disposition:       MDNDisposition(strings.ToLower(strings.TrimSpace(dispositionType))),
+		Automatic:         strings.EqualFold(strings.TrimSpace(actionMode), "automatic-action"),
+		Original:          original,
+		HeadersOnly:       headersOnly && original != nil,
+	}
+	return
+}
@@ -0,0 +1,89 @@
+package rfc5322_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aethiopicuschan/rfc5322-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMDN(t *testing.T) {
+	messageID, _ := rfc5322.NewMessageID("1", "example.com")
+	original := newTestOriginal(t)
+	original.Header().
+		SetMessageID(*messageID).
+		AddDispositionNotificationTo(mustAddress(t, "receipts@example.com"))
+	s, err := original.String()
+	assert.NoError(t, err)
+	assert.Contains(t, s, "Disposition-Notification-To: receipts@example.com\r\n")
+
+	// The recipient parses the request and replies with a notification.
+	received, err := rfc5322.Parse(strings.NewReader(s))
+	assert.NoError(t, err)
+	assert.Equal(t, "receipts@example.com", received.Header().DispositionNotificationTo().Unwrap().Value())
+	bob, _ := rfc5322.NewAddressWithName("Bob", "bob@example.net")
+	mdn, err := rfc5322.NewMDN(received, *bob, rfc5322.MDNDisplayed)
+	assert.NoError(t, err)
+	s, err = mdn.String()
+	assert.NoError(t, err)
+	assert.Contains(t, s, "From: Bob <bob@example.net>\r\n")
+	assert.Contains(t, s, "To: receipts@example.com\r\n")
+	assert.Contains(t, s, "Subject: Disposition notification: Lunch\r\n")
+	assert.Contains(t, s, "In-Reply-To: <1@example.com>\r\n")
+	assert.Contains(t, s, "report-type=disposition-notification")
+	assert.Contains(t, s, "Content-Type: message/disposition-notification\r\n\r\n"+
+		"Final-Recipient: rfc822; bob@example.net\r\n"+
+		"Original-Message-ID: <1@example.com>\r\n"+
+		"Disposition: manual-action/MDN-sent-manually; displayed\r\n")
+	assert.Contains(t, s, "Content-Type: text/rfc822-headers\r\n")
+	assert.NotContains(t, s, "Are you free on Friday?")
+
+	// The sender parses the notification.
+	parsed, err := rfc5322.Parse(strings.NewReader(s))
+	assert.NoError(t, err)
+	got, err := rfc5322.ParseMDN(parsed)
+	assert.NoError(t, err)
+	assert.Equal(t, "bob@example.net", got.FinalRecipient)
+	assert.Equal(t, "<1@example.com>", got.OriginalMessageID)
+	assert.Equal(t, rfc5322.MDNDisplayed, got.Disposition)
+	assert.False(t, got.Automatic)
+	assert.True(t, got.HeadersOnly)
+	assert.Equal(t, "Lunch", got.Original.Header().Get("Subject"))
+	assert.Contains(t, got.Text, "has been displayed")
+
+	_, err = rfc5322.NewMDN(newTestOriginal(t), *bob, rfc5322.MDNDisplayed)
+	assert.ErrorIs(t, err, rfc5322.ErrorNoMDNRequest)
+	_, err = rfc5322.NewMDN(received, *bob, "read")
+	assert.ErrorIs(t, err, rfc5322.ErrorInvalidMDN)
+	_, err = rfc5322.ParseMDN(received)
+	assert.ErrorIs(t, err, rfc5322.ErrorNotReport)
+}
+
+func TestParseMDN(t *testing.T) {
+	message := "Date: Sun, 01 Oct 2023 12:05:00 +0000\n" +
+		"From: bob@example.net\n" +
+		"Content-Type: multipart/report; report-type=disposition-notification; boundary=\"B\"\n" +
+		"\n" +
+		"--B\n" +
+		"Content-Type: text/plain\n" +
+		"\n" +
+		"Deleted without being read.\n" +
+		"--B\n" +
+		"Content-Type: message/disposition-notification\n" +
+		"\n" +
+		"Reporting-UA: mail.example.net; Example Mail 1.0\n" +
+		"Original-Recipient: rfc822;bob@example.net\n" +
+		"Final-Recipient: rfc822;bob@example.net\n" +
+		"Disposition: automatic-action/MDN-sent-automatically; deleted/error\n" +
+		"--B--\n"
+	parsed, err := rfc5322.Parse(strings.NewReader(message))
+	assert.NoError(t, err)
+	mdn, err := rfc5322.ParseMDN(parsed)
+	assert.NoError(t, err)
+	assert.Equal(t, "mail.example.net; Example Mail 1.0", mdn.ReportingUA)
+	assert.Equal(t, "bob@example.net", mdn.OriginalRecipient)
+	assert.Equal(t, rfc5322.MDNDeleted, mdn.Disposition)
+	assert.True(t, mdn.Automatic)
+	assert.Nil(t, mdn.Original)
+}
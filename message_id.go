@@ -25,7 +25,7 @@ func NewMessageID(left, right string) (m *MessageID, err error) {
 }
 
 // String returns the string representation of the MessageID.
-func (m *MessageID) String() string {
+func (m MessageID) String() string {
 	return fmt.Sprintf("<%s@%s>", m.left, m.right)
 }
 
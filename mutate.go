@@ -0,0 +1,162 @@
+package rfc5322
+
+import (
+	"mime"
+	"slices"
+	"strings"
+)
+
+// Get returns the first value of the named header field of the Body, or an empty string if it is not set.
+// The name is matched case-insensitively.
+func (b *Body) Get(name string) string {
+	value, _ := b.header(name)
+	return value
+}
+
+// Values returns all values of the named header field of the Body in order.
+// The name is matched case-insensitively.
+func (b *Body) Values(name string) []string {
+	values := make([]string, 0)
+	for _, f := range b.headers {
+		if strings.EqualFold(f.name, name) {
+			values = append(values, f.value)
+		}
+	}
+	return values
+}
+
+// Del removes all header fields of the Body with the given name.
+func (b *Body) Del(name string) *Body {
+	b.headers = slices.DeleteFunc(b.headers, func(f field) bool {
+		return strings.EqualFold(f.name, name)
+	})
+	b.touch()
+	return b
+}
+
+// RemovePart removes the part at the path in the tree of the Body.
+// It returns ErrorPartNotFound if there is no part at the path, or if the path is empty.
+func (b *Body) RemovePart(path BodyPath) error {
+	parent, i, err := b.mutateParent(path, false)
+	if err != nil {
+		return err
+	}
+	parent.parts = slices.Delete(parent.parts, i, i+1)
+	parent.touch()
+	return nil
+}
+
+// ReplacePart replaces the part at the path in the tree of the Body with the part.
+// It returns ErrorPartNotFound if there is no part at the path, or if the path is empty.
+func (b *Body) ReplacePart(path BodyPath, part *Body) error {
+	parent, i, err := b.mutateParent(path, false)
+	if err != nil {
+		return err
+	}
+	parent.parts[i] = part
+	parent.touch()
+	b.fixBoundaries(path[:len(path)-1], part)
+	return nil
+}
+
+// InsertPart inserts the part at the path in the tree of the Body, moving the part at the path and its following siblings forward.
+// The last index of the path may be the number of the parts of its parent to append the part.
+// It returns ErrorPartNotFound if the parent of the path is not a multipart Body in the tree, or if the path is empty.
+func (b *Body) InsertPart(path BodyPath, part *Body) error {
+	parent, i, err := b.mutateParent(path, true)
+	if err != nil {
+		return err
+	}
+	parent.parts = slices.Insert(parent.parts, i, part)
+	parent.touch()
+	b.fixBoundaries(path[:len(path)-1], part)
+	return nil
+}
+
+// MovePart moves the part at the path in the tree of the Body to the index among its siblings.
+// It returns ErrorPartNotFound if there is no part at the path or the index is out of range, or if the path is empty.
+func (b *Body) MovePart(path BodyPath, i int) error {
+	parent, j, err := b.mutateParent(path, false)
+	if err != nil {
+		return err
+	}
+	if i < 0 || i >= len(parent.parts) {
+		return ErrorPartNotFound
+	}
+	part := parent.parts[j]
+	parent.parts = slices.Insert(slices.Delete(parent.parts, j, j+1), i, part)
+	parent.touch()
+	return nil
+}
+
+// mutateParent returns the multipart parent of the path and the index of the path in it.
+// If insert is true, the index may be the number of the parts of the parent.
+func (b *Body) mutateParent(path BodyPath, insert bool) (parent *Body, i int, err error) {
+	if len(path) == 0 {
+		err = ErrorPartNotFound
+		return
+	}
+	parent, ok := b.Part(path[:len(path)-1])
+	if !ok || !parent.IsMultipart() {
+		err = ErrorPartNotFound
+		return
+	}
+	i = path[len(path)-1]
+	limit := len(parent.parts)
+	if insert {
+		limit++
+	}
+	if i < 0 || i >= limit {
+		err = ErrorPartNotFound
+		return
+	}
+	return
+}
+
+// fixBoundaries sets new boundaries on the multiparts in the tree of the added part that have none or one of an enclosing multipart,
+// and replaces the boundaries of the parent at the path and its ancestors if they occur in the serialization of the added part,
+// which would otherwise end a multipart early.
+func (b *Body) fixBoundaries(path BodyPath, part *Body) {
+	ancestors := make([]*Body, 0, len(path)+1)
+	boundaries := make([]string, 0, len(path)+1)
+	for n := range len(path) + 1 {
+		ancestor, _ := b.Part(path[:n])
+		ancestors = append(ancestors, ancestor)
+		boundaries = append(boundaries, ancestor.ensureBoundary())
+	}
+	part.assignBoundaries(boundaries)
+
+	s := part.String()
+	for _, ancestor := range slices.Backward(ancestors) {
+		if boundary := ancestor.ensureBoundary(); boundary != "" && strings.Contains(s, "--"+boundary) {
+			ancestor.setBoundary(randomBoundary())
+		}
+	}
+}
+
+// assignBoundaries sets new boundaries on the multiparts in the tree of the Body that have none or one of the enclosing boundaries.
+// Parts that are kept byte for byte are not changed.
+func (b *Body) assignBoundaries(enclosing []string) {
+	if !b.IsMultipart() || b.rawValid() {
+		return
+	}
+	_, params := b.mediaType()
+	boundary := params["boundary"]
+	if boundary == "" || slices.Contains(enclosing, boundary) {
+		boundary = randomBoundary()
+		b.setBoundary(boundary)
+	}
+	for _, part := range b.parts {
+		part.assignBoundaries(append(slices.Clip(enclosing), boundary))
+	}
+}
+
+// setBoundary sets the boundary parameter of the Content-Type field of a multipart Body.
+func (b *Body) setBoundary(boundary string) {
+	mediaType, params := b.mediaType()
+	params["boundary"] = boundary
+	if ct := mime.FormatMediaType(mediaType, params); ct != "" {
+		b.setHeader("Content-Type", ct)
+		b.touch()
+	}
+}
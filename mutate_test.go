@@ -0,0 +1,181 @@
+package rfc5322_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aethiopicuschan/rfc5322-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func parsedTypes(t *testing.T, b *rfc5322.Body) []string {
+	t.Helper()
+	parsed, err := rfc5322.ParseBody(strings.NewReader(b.String()))
+	assert.NoError(t, err)
+	types := make([]string, 0)
+	for path, part := range parsed.Walk() {
+		types = append(types, path.String()+" "+part.ContentType())
+	}
+	return types
+}
+
+func TestBodyHeaders(t *testing.T) {
+	b := rfc5322.NewBody()
+	assert.NoError(t, b.SetHeader("Content-Type", "text/plain"))
+	assert.NoError(t, b.SetHeader("X-Scan", "clean"))
+	assert.Equal(t, "text/plain", b.Get("content-type"))
+	assert.Equal(t, []string{"clean"}, b.Values("x-scan"))
+	assert.Empty(t, b.Values("X-Missing"))
+
+	b.Del("X-SCAN")
+	assert.Equal(t, "", b.Get("X-Scan"))
+	assert.NotContains(t, b.String(), "X-Scan")
+}
+
+func TestMutateParts(t *testing.T) {
+	notice := func() *rfc5322.Body {
+		b := rfc5322.NewBody()
+		assert.NoError(t, b.SetText("The attachment was removed.", ""))
+		return b
+	}
+
+	testCases := []struct {
+		name     string
+		mutate   func(b *rfc5322.Body) error
+		expected []string
+	}{
+		{
+			"Remove",
+			func(b *rfc5322.Body) error { return b.RemovePart(rfc5322.BodyPath{2}) },
+			[]string{" multipart/mixed", "1 multipart/alternative", "1.1 text/plain", "1.2 multipart/related", "1.2.1 text/html", "1.2.2 image/png", "2 text/plain"},
+		},
+		{
+			"RemoveNested",
+			func(b *rfc5322.Body) error { return b.RemovePart(rfc5322.BodyPath{0, 1, 1}) },
+			[]string{" multipart/mixed", "1 multipart/alternative", "1.1 text/plain", "1.2 multipart/related", "1.2.1 text/html", "2 text/plain", "3 application/pdf"},
+		},
+		{
+			"Replace",
+			func(b *rfc5322.Body) error { return b.ReplacePart(rfc5322.BodyPath{0}, notice()) },
+			[]string{" multipart/mixed", "1 text/plain", "2 text/plain", "3 application/pdf"},
+		},
+		{
+			"Insert",
+			func(b *rfc5322.Body) error { return b.InsertPart(rfc5322.BodyPath{1}, notice()) },
+			[]string{" multipart/mixed", "1 multipart/alternative", "1.1 text/plain", "1.2 multipart/related", "1.2.1 text/html", "1.2.2 image/png", "2 text/plain", "3 text/plain", "4 application/pdf"},
+		},
+		{
+			"Append",
+			func(b *rfc5322.Body) error { return b.InsertPart(rfc5322.BodyPath{0, 1, 2}, notice()) },
+			[]string{" multipart/mixed", "1 multipart/alternative", "1.1 text/plain", "1.2 multipart/related", "1.2.1 text/html", "1.2.2 image/png", "1.2.3 text/plain", "2 text/plain", "3 application/pdf"},
+		},
+		{
+			"Move",
+			func(b *rfc5322.Body) error { return b.MovePart(rfc5322.BodyPath{2}, 0) },
+			[]string{" multipart/mixed", "1 application/pdf", "2 multipart/alternative", "2.1 text/plain", "2.2 multipart/related", "2.2.1 text/html", "2.2.2 image/png", "3 text/plain"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			b, err := rfc5322.ParseBody(strings.NewReader(walkMessage))
+			assert.NoError(t, err)
+			assert.NoError(t, tc.mutate(b))
+			assert.Equal(t, tc.expected, parsedTypes(t, b))
+		})
+	}
+}
+
+func TestMutatePartsNotFound(t *testing.T) {
+	testCases := []struct {
+		name   string
+		mutate func(b *rfc5322.Body) error
+	}{
+		{"RemoveRoot", func(b *rfc5322.Body) error { return b.RemovePart(rfc5322.BodyPath{}) }},
+		{"RemoveOutOfRange", func(b *rfc5322.Body) error { return b.RemovePart(rfc5322.BodyPath{3}) }},
+		{"ReplaceMissingParent", func(b *rfc5322.Body) error { return b.ReplacePart(rfc5322.BodyPath{5, 0}, rfc5322.NewBody()) }},
+		{"InsertIntoLeaf", func(b *rfc5322.Body) error { return b.InsertPart(rfc5322.BodyPath{1, 0}, rfc5322.NewBody()) }},
+		{"MoveOutOfRange", func(b *rfc5322.Body) error { return b.MovePart(rfc5322.BodyPath{0}, 3) }},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			b, err := rfc5322.ParseBody(strings.NewReader(walkMessage))
+			assert.NoError(t, err)
+			assert.ErrorIs(t, tc.mutate(b), rfc5322.ErrorPartNotFound)
+		})
+	}
+}
+
+func TestMutatePartsBoundary(t *testing.T) {
+	b, err := rfc5322.ParseBody(strings.NewReader(walkMessage))
+	assert.NoError(t, err)
+	part := rfc5322.NewBody()
+	part.SetContent([]byte("--outer\r\n--rel--"))
+	assert.NoError(t, b.InsertPart(rfc5322.BodyPath{0, 1, 2}, part))
+
+	assert.NotContains(t, b.Get("Content-Type"), "outer")
+	related, ok := b.Part(rfc5322.BodyPath{0, 1})
+	assert.True(t, ok)
+	assert.NotContains(t, related.Get("Content-Type"), "rel;")
+	assert.Contains(t, related.Get("Content-Type"), `type="text/html"`)
+	assert.Equal(t, []string{" multipart/mixed", "1 multipart/alternative", "1.1 text/plain", "1.2 multipart/related", "1.2.1 text/html", "1.2.2 image/png", "1.2.3 text/plain", "2 text/plain", "3 application/pdf"}, parsedTypes(t, b))
+}
+
+func TestMutatePartsNestedBoundary(t *testing.T) {
+	b, err := rfc5322.ParseBody(strings.NewReader(walkMessage))
+	assert.NoError(t, err)
+	inner := rfc5322.NewBody()
+	assert.NoError(t, inner.SetHeader("Content-Type", "multipart/alternative"))
+	inner.AddPart(rfc5322.NewBody())
+	inner.AddPart(rfc5322.NewBody())
+	outer := rfc5322.NewBody()
+	assert.NoError(t, outer.SetHeader("Content-Type", "multipart/mixed"))
+	outer.AddPart(inner)
+	outer.AddPart(rfc5322.NewBody())
+	assert.NoError(t, b.InsertPart(rfc5322.BodyPath{3}, outer))
+
+	assert.NotEqual(t, outer.Get("Content-Type"), inner.Get("Content-Type"))
+	assert.Equal(t, []string{
+		" multipart/mixed", "1 multipart/alternative", "1.1 text/plain", "1.2 multipart/related", "1.2.1 text/html",
+		"1.2.2 image/png", "2 text/plain", "3 application/pdf", "4 multipart/mixed", "4.1 multipart/alternative",
+		"4.1.1 text/plain", "4.1.2 text/plain", "4.2 text/plain",
+	}, parsedTypes(t, b))
+}
+
+const signedMessage = "Content-Type: multipart/signed; protocol=\"application/pgp-signature\"; boundary=s\r\n" +
+	"\r\n" +
+	"--s\r\n" +
+	"Content-Type: multipart/mixed;  boundary=m\r\n" +
+	"\r\n" +
+	"--m\r\nContent-Type: text/plain\r\n\r\nHello\r\n" +
+	"--m\r\nContent-Type: application/x-msdownload\r\nX-Secret: 42\r\n\r\nMZ\r\n" +
+	"--m--\r\n" +
+	"--s\r\n" +
+	"Content-Type: application/pgp-signature\r\n" +
+	"\r\n" +
+	"signature\r\n" +
+	"--s--\r\n"
+
+func TestMutateSignedPart(t *testing.T) {
+	b, err := rfc5322.ParseBody(strings.NewReader(signedMessage))
+	assert.NoError(t, err)
+	assert.NoError(t, b.RemovePart(rfc5322.BodyPath{0, 1}))
+	assert.NotContains(t, b.String(), "MZ")
+	assert.Equal(t, []string{" multipart/signed", "1 multipart/mixed", "1.1 text/plain", "2 application/pgp-signature"}, parsedTypes(t, b))
+}
+
+func TestDelSignedDescendant(t *testing.T) {
+	b, err := rfc5322.ParseBody(strings.NewReader(signedMessage))
+	assert.NoError(t, err)
+	part, ok := b.Part(rfc5322.BodyPath{0, 1})
+	assert.True(t, ok)
+	assert.Contains(t, b.String(), "X-Secret: 42")
+	part.Del("X-Secret")
+
+	s := b.String()
+	assert.NotContains(t, s, "X-Secret")
+	assert.Contains(t, s, "Content-Type: application/x-msdownload\r\n\r\nMZ")
+}
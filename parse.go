@@ -0,0 +1,409 @@
+package rfc5322
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"net/mail"
+	"strings"
+
+	"github.com/moznion/go-optional"
+)
+
+// wordDecoder decodes the encoded-words of RFC 2047 in header fields, in any charset supported by lookupCharset.
+var wordDecoder = &mime.WordDecoder{CharsetReader: charsetReader}
+
+// Parse parses an RFC 5322 message into an EMail.
+// Both CRLF and LF line endings are accepted.
+// Fields that are not modeled by Header are kept as extra fields, and the MIME fields are kept in the Body.
+// Since Header only models a single Reply-To address, only the first address of the field is kept.
+func Parse(r io.Reader) (e *EMail, err error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return
+	}
+	fields, content := splitMessage(data)
+
+	header := &Header{}
+	body := NewBody()
+	hasDate := false
+	for _, f := range fields {
+		switch {
+		case strings.EqualFold(f.name, "date"):
+			hasDate = true
+		case strings.HasPrefix(strings.ToLower(f.name), "content-"):
+			body.setHeader(f.name, strings.TrimSpace(f.value))
+			continue
+		}
+		if err = header.parseField(f); err != nil {
+			return
+		}
+	}
+	if !hasDate {
+		err = ErrorNeedDate
+		return
+	}
+	if len(header.from) == 0 {
+		err = ErrorNeedFrom
+		return
+	}
+
+	if err = body.parseContent(content); err != nil {
+		return
+	}
+	e = NewEMail(header, body)
+	return
+}
+
+// parseField sets the parsed field in the Header.
+// Fields that are not modeled by Header are kept as extra fields.
+func (h *Header) parseField(f field) (err error) {
+	switch strings.ToLower(f.name) {
+	case "mime-version":
+		// MIME-Version is always written by Header.
+	case "date":
+		h.date = Date{value: strings.TrimSpace(f.value)}
+	case "from":
+		if h.from, err = parseAddresses(f.value); err != nil {
+			return
+		}
+	case "sender":
+		var addr Address
+		if addr, err = parseAddress(f.value); err != nil {
+			return
+		}
+		h.sender = optional.Some(addr)
+	case "to":
+		if h.to, err = parseOptionalAddresses(f.value); err != nil {
+			return
+		}
+	case "cc":
+		if h.cc, err = parseOptionalAddresses(f.value); err != nil {
+			return
+		}
+	case "bcc":
+		// Bcc may be empty as per RFC 5322 section 3.6.3.
+		if h.bcc, err = parseOptionalAddresses(f.value); err != nil {
+			return
+		}
+	case "reply-to":
+		var addr Address
+		if addr, err = parseAddress(f.value); err != nil {
+			return
+		}
+		h.replyTo = optional.Some(addr)
+	case "message-id":
+		if ids, ok := parseMessageIDs(f.value); ok && len(ids) == 1 {
+			h.messageID = optional.Some(ids[0])
+		} else {
+			h.AddExtra(f.name, strings.TrimSpace(f.value))
+		}
+	case "in-reply-to":
+		h.inReplyTo = optional.Some(strings.TrimSpace(f.value))
+	case "references":
+		if ids, ok := parseMessageIDs(f.value); ok && len(ids) > 0 {
+			h.references = optional.Some(ids)
+		} else {
+			h.AddExtra(f.name, strings.TrimSpace(f.value))
+		}
+	case "subject":
+		h.subject = optional.Some(decodeText(f.value))
+	case "comments":
+		h.comments = optional.Some(decodeText(f.value))
+	case "keywords":
+		for _, keyword := range strings.Split(f.value, ",") {
+			h.AddKeyword(decodeText(keyword))
+		}
+	case "resent-date":
+		h.resentDate = optional.Some(Date{value: strings.TrimSpace(f.value)})
+	case "resent-from":
+		if h.resentFrom, err = parseOptionalAddresses(f.value); err != nil {
+			return
+		}
+	case "resent-sender":
+		var addr Address
+		if addr, err = parseAddress(f.value); err != nil {
+			return
+		}
+		h.resentSender = optional.Some(addr)
+	case "resent-to":
+		if h.resentTo, err = parseOptionalAddresses(f.value); err != nil {
+			return
+		}
+	case "resent-cc":
+		if h.resentCc, err = parseOptionalAddresses(f.value); err != nil {
+			return
+		}
+	case "resent-bcc":
+		if h.resentBcc, err = parseOptionalAddresses(f.value); err != nil {
+			return
+		}
+	case "resent-message-id":
+		if ids, ok := parseMessageIDs(f.value); ok && len(ids) == 1 {
+			h.resentMessageID = optional.Some(ids[0])
+		} else {
+			h.AddExtra(f.name, strings.TrimSpace(f.value))
+		}
+	case "resent-reply-to":
+		var addr Address
+		if addr, err = parseAddress(f.value); err != nil {
+			return
+		}
+		h.resentReplyTo = optional.Some(addr)
+	case "autocrypt":
+		if a, e := ParseAutocrypt(f.value); e == nil {
+			h.autocrypt = optional.Some(a)
+		} else {
+			// An invalid Autocrypt field must be ignored by Autocrypt implementations, but it is kept as is.
+			h.AddExtra(f.name, strings.TrimSpace(f.value))
+		}
+	case "disposition-notification-to":
+		if h.dispositionNotificationTo, err = parseOptionalAddresses(f.value); err != nil {
+			return
+		}
+	case "list-id":
+		if id, e := parseListID(f.value); e == nil {
+			h.listID = optional.Some(id)
+		} else {
+			h.AddExtra(f.name, strings.TrimSpace(f.value))
+		}
+	case "list-help", "list-unsubscribe", "list-subscribe", "list-post", "list-owner", "list-archive":
+		var uris ListURIs
+		var e error
+		if name := strings.ToLower(f.name); name == "list-post" && strings.EqualFold(strings.TrimSpace(f.value), "NO") {
+			uris = ListURIs{}
+		} else if uris, e = parseListURIs(f.value); e != nil {
+			h.AddExtra(f.name, strings.TrimSpace(f.value))
+			break
+		}
+		switch strings.ToLower(f.name) {
+		case "list-help":
+			h.listHelp = optional.Some(uris)
+		case "list-unsubscribe":
+			h.listUnsubscribe = optional.Some(uris)
+		case "list-subscribe":
+			h.listSubscribe = optional.Some(uris)
+		case "list-post":
+			h.listPost = optional.Some(uris)
+		case "list-owner":
+			h.listOwner = optional.Some(uris)
+		case "list-archive":
+			h.listArchive = optional.Some(uris)
+		}
+	case "list-unsubscribe-post":
+		if strings.TrimSpace(f.value) == oneClick {
+			h.listUnsubscribePost = true
+		} else {
+			h.AddExtra(f.name, strings.TrimSpace(f.value))
+		}
+	case "received", "arc-seal", "arc-message-signature", "arc-authentication-results":
+		h.appendTrace(f.name, strings.TrimSpace(f.value))
+	case "authentication-results":
+		if results, e := ParseAuthenticationResults(f.value); e == nil {
+			h.appendAuthenticationResults(results)
+		} else {
+			h.AddExtra(f.name, strings.TrimSpace(f.value))
+		}
+	default:
+		h.AddExtra(f.name, decodeText(f.value))
+	}
+	return
+}
+
+// ParseBody parses a MIME entity, made of MIME header fields and content, into a Body.
+// Both CRLF and LF line endings are accepted.
+func ParseBody(r io.Reader) (b *Body, err error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return
+	}
+	fields, content := splitMessage(data)
+	b = NewBody()
+	for _, f := range fields {
+		b.setHeader(f.name, strings.TrimSpace(f.value))
+	}
+	if err = b.parseContent(content); err != nil {
+		b = nil
+	}
+	return
+}
+
+// parseContent sets the content of the Body, splitting it into parts if it is multipart.
+func (b *Body) parseContent(content []byte) (err error) {
+	if !b.IsMultipart() {
+		b.content = content
+		return
+	}
+	ct, _ := b.header("Content-Type")
+	_, params, err := mime.ParseMediaType(ct)
+	if err != nil || params["boundary"] == "" {
+		// Without a boundary, the content cannot be split into parts.
+		b.content = content
+		err = nil
+		return
+	}
+	preamble, parts := splitMultipart(content, params["boundary"])
+	b.content = preamble
+	// The signed content of multipart/signed must be kept byte for byte, as per RFC 1847 section 2.1.
+	signed := b.ContentType() == "multipart/signed"
+	for _, p := range parts {
+		var part *Body
+		if part, err = ParseBody(bytes.NewReader(p)); err != nil {
+			return
+		}
+		if signed {
+			part.raw = p
+		}
+		b.parts = append(b.parts, part)
+	}
+	return
+}
+
+// splitMessage splits a message into its unfolded header fields and its content.
+func splitMessage(data []byte) (fields []field, content []byte) {
+	for len(data) > 0 {
+		line, rest := cutLine(data)
+		if len(bytes.TrimRight(line, "\r\n")) == 0 {
+			// The empty line separates the header from the content.
+			return fields, rest
+		}
+		data = rest
+		if (line[0] == ' ' || line[0] == '\t') && len(fields) > 0 {
+			// Unfold the continuation line as per RFC 5322 section 2.2.3.
+			fields[len(fields)-1].value += string(bytes.TrimRight(line, "\r\n"))
+			continue
+		}
+		name, value, ok := strings.Cut(strings.TrimRight(string(line), "\r\n"), ":")
+		if !ok {
+			// Not a header field, so the content starts without an empty line.
+			return fields, append(line, data...)
+		}
+		fields = append(fields, field{name: strings.TrimSpace(name), value: strings.TrimPrefix(value, " ")})
+	}
+	return fields, nil
+}
+
+// splitMultipart splits the content of a multipart entity into its preamble and the raw parts, as per RFC 2046 section 5.1.1.
+func splitMultipart(content []byte, boundary string) (preamble []byte, parts [][]byte) {
+	delimiter := "--" + boundary
+	var current []byte
+	inPart := false
+	started := false
+	// trim removes the line break that belongs to the following delimiter.
+	trim := func(b []byte) []byte {
+		b = bytes.TrimSuffix(b, []byte("\n"))
+		return bytes.TrimSuffix(b, []byte("\r"))
+	}
+	for len(content) > 0 {
+		var line []byte
+		line, content = cutLine(content)
+		trimmed := strings.TrimRight(string(line), " \t\r\n")
+		if trimmed == delimiter || trimmed == delimiter+"--" {
+			if inPart {
+				parts = append(parts, trim(current))
+			} else if !started {
+				preamble = trim(current)
+			}
+			started = true
+			current = nil
+			inPart = trimmed == delimiter
+			if !inPart {
+				// The rest is the epilogue.
+				return
+			}
+			continue
+		}
+		current = append(current, line...)
+	}
+	if inPart {
+		parts = append(parts, current)
+	} else if !started {
+		preamble = current
+	}
+	return
+}
+
+// cutLine returns the first line including its line break, and the rest of the data.
+func cutLine(data []byte) (line, rest []byte) {
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		return data[:i+1], data[i+1:]
+	}
+	return data, nil
+}
+
+// decodeText decodes the encoded-words in an unstructured field body.
+func decodeText(s string) string {
+	s = strings.TrimSpace(s)
+	decoded, err := wordDecoder.DecodeHeader(s)
+	if err != nil {
+		return s
+	}
+	return decoded
+}
+
+// parseAddresses parses an address list.
+// Groups are flattened into their members, so the list may be empty.
+func parseAddresses(s string) (addrs Addresses, err error) {
+	if strings.TrimSpace(s) == "" {
+		return Addresses{}, nil
+	}
+	list, err := (&mail.AddressParser{WordDecoder: wordDecoder}).ParseList(s)
+	if err != nil {
+		err = ErrorInvalidAddress
+		return
+	}
+	addrs = make(Addresses, 0, len(list))
+	for _, a := range list {
+		if a.Name != "" {
+			addrs = append(addrs, Address{name: optional.Some(a.Name), value: a.Address})
+		} else {
+			addrs = append(addrs, Address{name: optional.None[string](), value: a.Address})
+		}
+	}
+	return
+}
+
+// parseAddress parses an address list that must contain a single address.
+func parseAddress(s string) (addr Address, err error) {
+	addrs, err := parseAddresses(s)
+	if err != nil {
+		return
+	}
+	if len(addrs) == 0 {
+		err = ErrorInvalidAddress
+		return
+	}
+	addr = addrs[0]
+	return
+}
+
+// parseOptionalAddresses parses an address list into an optional value, which is None if the list is empty.
+func parseOptionalAddresses(s string) (o optional.Option[Addresses], err error) {
+	addrs, err := parseAddresses(s)
+	if err != nil || len(addrs) == 0 {
+		return
+	}
+	o = optional.Some(addrs)
+	return
+}
+
+// parseMessageIDs parses a list of msg-id as per RFC 5322 section 3.6.4.
+func parseMessageIDs(s string) (ids MessageIDs, ok bool) {
+	s = strings.TrimSpace(s)
+	for s != "" {
+		if s[0] != '<' {
+			return nil, false
+		}
+		end := strings.IndexByte(s, '>')
+		if end < 0 {
+			return nil, false
+		}
+		left, right, found := strings.Cut(s[1:end], "@")
+		if !found || left == "" || right == "" {
+			return nil, false
+		}
+		ids = append(ids, MessageID{left: left, right: right})
+		s = strings.TrimLeft(s[end+1:], " \t\r\n")
+	}
+	return ids, true
+}
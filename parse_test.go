@@ -0,0 +1,162 @@
+package rfc5322_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aethiopicuschan/rfc5322-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		name        string
+		input       string
+		expected    string
+		expectedErr error
+	}{
+		{
+			name: "simple message",
+			input: "Date: Sun, 01 Oct 2023 12:00:00 +0000\r\n" +
+				"From: Alice <alice@example.com>\r\n" +
+				"To: bob@example.com, \"Carol\" <carol@example.com>\r\n" +
+				"Message-ID: <1234@example.com>\r\n" +
+				"Subject: =?utf-8?q?=E3=81=93=E3=82=93=E3=81=AB=E3=81=A1=E3=81=AF?=\r\n" +
+				"X-Mailer: test\r\n" +
+				"\r\n" +
+				"Hello\r\n",
+			expected: "MIME-Version: 1.0\r\n" +
+				"Date: Sun, 01 Oct 2023 12:00:00 +0000\r\n" +
+				"From: Alice <alice@example.com>\r\n" +
+				"To: bob@example.com, Carol <carol@example.com>\r\n" +
+				"Message-ID: <1234@example.com>\r\n" +
+				"Subject: =?utf-8?q?=E3=81=93=E3=82=93=E3=81=AB=E3=81=A1=E3=81=AF?=\r\n" +
+				"X-Mailer: test\r\n" +
+				"\r\n" +
+				"Hello\r\n",
+		},
+		{
+			name: "LF line endings and folded fields",
+			input: "Date: Sun, 01 Oct 2023 12:00:00 +0000\n" +
+				"From: alice@example.com\n" +
+				"To: undisclosed-recipients:;\n" +
+				"Subject: Hello\n" +
+				" World\n" +
+				"References: <a@example.com>\n" +
+				"\t<b@example.com>\n" +
+				"\n" +
+				"Hello\n",
+			expected: "MIME-Version: 1.0\r\n" +
+				"Date: Sun, 01 Oct 2023 12:00:00 +0000\r\n" +
+				"From: alice@example.com\r\n" +
+				"References: <a@example.com> <b@example.com>\r\n" +
+				"Subject: Hello World\r\n" +
+				"\r\n" +
+				"Hello\n",
+		},
+		{
+			name: "multipart",
+			input: "MIME-Version: 1.0\r\n" +
+				"Date: Sun, 01 Oct 2023 12:00:00 +0000\r\n" +
+				"From: alice@example.com\r\n" +
+				"Content-Type: multipart/mixed; boundary=\"outer\"\r\n" +
+				"\r\n" +
+				"This is a multi-part message in MIME format.\r\n" +
+				"--outer\r\n" +
+				"Content-Type: multipart/alternative; boundary=\"inner\"\r\n" +
+				"\r\n" +
+				"--inner\r\n" +
+				"Content-Type: text/plain\r\n" +
+				"\r\n" +
+				"Hi\r\n" +
+				"--inner\r\n" +
+				"Content-Type: text/html\r\n" +
+				"\r\n" +
+				"<p>Hi</p>\r\n" +
+				"--inner--\r\n" +
+				"\r\n" +
+				"--outer\r\n" +
+				"Content-Type: text/plain; name=\"a.txt\"\r\n" +
+				"Content-Disposition: attachment\r\n" +
+				"\r\n" +
+				"attachment\r\n" +
+				"--outer--\r\n" +
+				"epilogue\r\n",
+			expected: "MIME-Version: 1.0\r\n" +
+				"Date: Sun, 01 Oct 2023 12:00:00 +0000\r\n" +
+				"From: alice@example.com\r\n" +
+				"Content-Type: multipart/mixed; boundary=\"outer\"\r\n" +
+				"\r\n" +
+				"This is a multi-part message in MIME format.\r\n" +
+				"--outer\r\n" +
+				"Content-Type: multipart/alternative; boundary=\"inner\"\r\n" +
+				"\r\n" +
+				"--inner\r\n" +
+				"Content-Type: text/plain\r\n" +
+				"\r\n" +
+				"Hi\r\n" +
+				"--inner\r\n" +
+				"Content-Type: text/html\r\n" +
+				"\r\n" +
+				"<p>Hi</p>\r\n" +
+				"--inner--\r\n" +
+				"\r\n" +
+				"--outer\r\n" +
+				"Content-Type: text/plain; name=\"a.txt\"\r\n" +
+				"Content-Disposition: attachment\r\n" +
+				"\r\n" +
+				"attachment\r\n" +
+				"--outer--\r\n",
+		},
+		{
+			name:        "no date",
+			input:       "From: alice@example.com\r\n\r\nHello",
+			expectedErr: rfc5322.ErrorNeedDate,
+		},
+		{
+			name:        "no from",
+			input:       "Date: Sun, 01 Oct 2023 12:00:00 +0000\r\n\r\nHello",
+			expectedErr: rfc5322.ErrorNeedFrom,
+		},
+		{
+			name:        "invalid address",
+			input:       "Date: Sun, 01 Oct 2023 12:00:00 +0000\r\nFrom: alice\r\n\r\nHello",
+			expectedErr: rfc5322.ErrorInvalidAddress,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			email, err := rfc5322.Parse(strings.NewReader(tc.input))
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			s, err := email.String()
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, s)
+		})
+	}
+}
+
+func TestParseHeader(t *testing.T) {
+	email, err := rfc5322.Parse(strings.NewReader("Date: Sun, 01 Oct 2023 12:00:00 +0000\r\n" +
+		"From: =?utf-8?q?=E3=82=A2=E3=83=AA=E3=82=B9?= <alice@example.com>\r\n" +
+		"Bcc:\r\n" +
+		"Subject: =?utf-8?q?=E3=81=93=E3=82=93=E3=81=AB=E3=81=A1=E3=81=AF?=\r\n" +
+		"In-Reply-To: <1234@example.com>\r\n" +
+		"Keywords: a, b\r\n" +
+		"Message-ID: invalid\r\n" +
+		"\r\n"))
+	assert.NoError(t, err)
+	header := email.Header()
+	assert.Equal(t, "アリス <alice@example.com>", header.From().Value())
+	assert.True(t, header.Bcc().IsNone())
+	assert.Equal(t, "こんにちは", header.Subject().Unwrap())
+	assert.Equal(t, "<1234@example.com>", header.InReplyTo().Unwrap())
+	assert.Equal(t, []string{"a", "b"}, header.Keywords().Unwrap())
+	assert.True(t, header.MessageID().IsNone())
+	assert.Equal(t, "invalid", header.Get("Message-ID"))
+}
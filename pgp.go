@@ -0,0 +1,185 @@
+package rfc5322
+
+import (
+	"bytes"
+	"fmt"
+	"mime/quotedprintable"
+	"strings"
+)
+
+// PGPSigner creates detached OpenPGP signatures.
+type PGPSigner interface {
+	// Sign returns the ASCII-armored detached signature of the data,
+	// and the name of the hash algorithm used, such as "sha256".
+	Sign(data []byte) (signature []byte, hash string, err error)
+}
+
+// PGPVerifier verifies detached OpenPGP signatures.
+type PGPVerifier interface {
+	// Verify returns an error if the signature is not a valid signature of the data.
+	Verify(data, signature []byte) error
+}
+
+// PGPEncrypter encrypts data with OpenPGP.
+type PGPEncrypter interface {
+	// Encrypt returns the ASCII-armored encrypted message of the data.
+	Encrypt(data []byte) (encrypted []byte, err error)
+}
+
+// PGPDecrypter decrypts OpenPGP messages.
+type PGPDecrypter interface {
+	// Decrypt returns the data of the encrypted message.
+	Decrypt(encrypted []byte) (data []byte, err error)
+}
+
+// SignPGP signs the Body and returns a multipart/signed Body made of the Body and a detached
+// application/pgp-signature part, as per RFC 3156 section 5.
+// The parts of the Body that are not 7bit are encoded before signing, so that the signature survives transport.
+func SignPGP(b *Body, signer PGPSigner) (signed *Body, err error) {
+	content, data, err := canonicalBody(b.sevenBit())
+	if err != nil {
+		return
+	}
+	sig, hash, err := signer.Sign(data)
+	if err != nil {
+		return
+	}
+	if hash == "" {
+		err = ErrorUnsupportedAlgorithm
+		return
+	}
+
+	signature := NewBody()
+	signature.setHeader("Content-Type", `application/pgp-signature; name="signature.asc"`)
+	signature.setHeader("Content-Description", "OpenPGP digital signature")
+	signature.setHeader("Content-Disposition", `attachment; filename="signature.asc"`)
+	signature.content = normalizeLineEndings(sig)
+
+	signed = NewBody()
+	signed.setHeader("Content-Type", fmt.Sprintf(`multipart/signed; protocol="application/pgp-signature"; micalg=pgp-%s; boundary=%q`, strings.ToLower(hash), randomBoundary()))
+	signed.parts = []*Body{content, signature}
+	return
+}
+
+// EncryptPGP encrypts the Body and returns a multipart/encrypted Body, as per RFC 3156 section 4.
+// To sign and encrypt, pass the result of SignPGP.
+func EncryptPGP(b *Body, encrypter PGPEncrypter) (encrypted *Body, err error) {
+	_, data, err := canonicalBody(b)
+	if err != nil {
+		return
+	}
+	armored, err := encrypter.Encrypt(data)
+	if err != nil {
+		return
+	}
+
+	version := NewBody()
+	version.setHeader("Content-Type", "application/pgp-encrypted")
+	version.setHeader("Content-Description", "PGP/MIME version identification")
+	version.content = []byte("Version: 1\r\n")
+
+	message := NewBody()
+	message.setHeader("Content-Type", `application/octet-stream; name="encrypted.asc"`)
+	message.setHeader("Content-Description", "OpenPGP encrypted message")
+	message.setHeader("Content-Disposition", `inline; filename="encrypted.asc"`)
+	message.content = normalizeLineEndings(armored)
+
+	encrypted = NewBody()
+	encrypted.setHeader("Content-Type", fmt.Sprintf(`multipart/encrypted; protocol="application/pgp-encrypted"; boundary=%q`, randomBoundary()))
+	encrypted.parts = []*Body{version, message}
+	return
+}
+
+// VerifyPGP verifies a multipart/signed Body with an application/pgp-signature part, and returns the signed content.
+func VerifyPGP(b *Body, verifier PGPVerifier) (content *Body, err error) {
+	mediaType, params := b.mediaType()
+	if mediaType != "multipart/signed" || !strings.EqualFold(params["protocol"], "application/pgp-signature") || len(b.parts) != 2 {
+		err = ErrorNotSigned
+		return
+	}
+	signature, err := b.parts[1].decodedContent()
+	if err != nil {
+		return
+	}
+	// The signed content is the part exactly as it was transmitted, with CRLF line endings.
+	if err = verifier.Verify(normalizeLineEndings([]byte(b.parts[0].String())), signature); err != nil {
+		return
+	}
+	content = b.parts[0]
+	return
+}
+
+// DecryptPGP decrypts a multipart/encrypted Body with an application/pgp-encrypted part, and returns the decrypted content.
+// If the content is signed, it can be verified with VerifyPGP.
+func DecryptPGP(b *Body, decrypter PGPDecrypter) (content *Body, err error) {
+	mediaType, params := b.mediaType()
+	if mediaType != "multipart/encrypted" || !strings.EqualFold(params["protocol"], "application/pgp-encrypted") || len(b.parts) != 2 {
+		err = ErrorNotEncrypted
+		return
+	}
+	armored, err := b.parts[1].decodedContent()
+	if err != nil {
+		return
+	}
+	data, err := decrypter.Decrypt(armored)
+	if err != nil {
+		return
+	}
+	return ParseBody(bytes.NewReader(normalizeLineEndings(data)))
+}
+
+// sevenBit returns a copy of the Body where the parts that are not 7bit data, or that have trailing whitespace,
+// are encoded with quoted-printable for text and base64 for the other types, as required by RFC 3156 section 3.
+// The Body itself is not modified.
+func (b *Body) sevenBit() *Body {
+	if b.rawValid() {
+		return b
+	}
+	c := &Body{
+		headers: append([]field(nil), b.headers...),
+		content: b.content,
+		parts:   make([]*Body, 0, len(b.parts)),
+	}
+	if b.IsMultipart() {
+		for _, part := range b.parts {
+			c.parts = append(c.parts, part.sevenBit())
+		}
+		return c
+	}
+	cte, _ := b.header("Content-Transfer-Encoding")
+	switch strings.ToLower(strings.TrimSpace(cte)) {
+	case "base64", "quoted-printable":
+		return c
+	}
+	if isSevenBit(b.content) {
+		return c
+	}
+	if strings.HasPrefix(b.ContentType(), "text/") {
+		var buf bytes.Buffer
+		w := quotedprintable.NewWriter(&buf)
+		w.Write(b.content)
+		w.Close()
+		c.content = buf.Bytes()
+		c.setHeader("Content-Transfer-Encoding", "quoted-printable")
+	} else {
+		c.content = base64Lines(b.content)
+		c.setHeader("Content-Transfer-Encoding", "base64")
+	}
+	return c
+}
+
+// isSevenBit reports whether the content is 7bit data without trailing whitespace on its lines.
+func isSevenBit(content []byte) bool {
+	for line := range bytes.Lines(content) {
+		line = bytes.TrimRight(line, "\r\n")
+		for _, c := range line {
+			if c >= 0x80 || c == 0 {
+				return false
+			}
+		}
+		if len(line) > 0 && (line[len(line)-1] == ' ' || line[len(line)-1] == '\t') {
+			return false
+		}
+	}
+	return true
+}
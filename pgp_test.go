@@ -0,0 +1,120 @@
+package rfc5322_test
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aethiopicuschan/rfc5322-go"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakePGP is a fake OpenPGP backend that "signs" with a SHA-256 digest and "encrypts" with base64.
+type fakePGP struct {
+	signed []byte
+}
+
+func (f *fakePGP) Sign(data []byte) ([]byte, string, error) {
+	f.signed = data
+	sum := sha256.Sum256(data)
+	return []byte(armor("SIGNATURE", sum[:])), "SHA256", nil
+}
+
+func (f *fakePGP) Verify(data, signature []byte) error {
+	sum := sha256.Sum256(data)
+	if strings.ReplaceAll(string(signature), "\r\n", "\n") != armor("SIGNATURE", sum[:]) {
+		return errors.New("bad signature")
+	}
+	return nil
+}
+
+func (f *fakePGP) Encrypt(data []byte) ([]byte, error) {
+	return []byte(armor("MESSAGE", data)), nil
+}
+
+func (f *fakePGP) Decrypt(encrypted []byte) ([]byte, error) {
+	s := strings.ReplaceAll(string(encrypted), "\r\n", "\n")
+	s = strings.TrimPrefix(s, "-----BEGIN PGP MESSAGE-----\n\n")
+	s, _, _ = strings.Cut(s, "\n")
+	return base64.StdEncoding.DecodeString(s)
+}
+
+func armor(kind string, data []byte) string {
+	return "-----BEGIN PGP " + kind + "-----\n\n" + base64.StdEncoding.EncodeToString(data) + "\n-----END PGP " + kind + "-----\n"
+}
+
+func TestPGPSign(t *testing.T) {
+	body := rfc5322.NewBody()
+	body.SetHeader("Content-Type", "multipart/mixed")
+	plain := rfc5322.NewBody()
+	plain.SetHeader("Content-Type", "text/plain; charset=UTF-8")
+	plain.SetContent([]byte("Grüße \nFrom the team."))
+	attachment := rfc5322.NewBody()
+	attachment.SetHeader("Content-Type", "application/octet-stream")
+	attachment.SetContent([]byte{0xff, 0x00, 0x01})
+	body.AddPart(plain)
+	body.AddPart(attachment)
+
+	pgp := &fakePGP{}
+	signed, err := rfc5322.SignPGP(body, pgp)
+	assert.NoError(t, err)
+
+	// The signed data is 7bit with CRLF line endings.
+	for _, c := range pgp.signed {
+		assert.Less(t, c, byte(0x80))
+	}
+	assert.NotContains(t, strings.ReplaceAll(string(pgp.signed), "\r\n", ""), "\n")
+	assert.Contains(t, string(pgp.signed), "Content-Transfer-Encoding: quoted-printable\r\n\r\nGr=C3=BC=C3=9Fe=20\r\nFrom the team.")
+	assert.Contains(t, string(pgp.signed), "Content-Transfer-Encoding: base64\r\n\r\n/wAB")
+	// The original Body is not modified.
+	assert.NotContains(t, body.String(), "quoted-printable")
+
+	s, err := newTestSignedEMail(t, signed).String()
+	assert.NoError(t, err)
+	assert.Contains(t, s, "Content-Type: multipart/signed; protocol=\"application/pgp-signature\"; micalg=pgp-sha256; boundary=")
+	assert.Contains(t, s, "Content-Type: application/pgp-signature; name=\"signature.asc\"\r\n")
+
+	parsed, err := rfc5322.ReadEML(strings.NewReader(strings.ReplaceAll(s, "\r\n", "\n")))
+	assert.NoError(t, err)
+	content, err := rfc5322.VerifyPGP(parsed.Body(), pgp)
+	assert.NoError(t, err)
+	assert.Equal(t, "multipart/mixed", content.ContentType())
+
+	tampered, err := rfc5322.Parse(strings.NewReader(strings.Replace(s, "From the team.", "From the boss.", 1)))
+	assert.NoError(t, err)
+	_, err = rfc5322.VerifyPGP(tampered.Body(), pgp)
+	assert.Error(t, err)
+
+	_, err = rfc5322.VerifyPGP(body, pgp)
+	assert.ErrorIs(t, err, rfc5322.ErrorNotSigned)
+}
+
+func TestPGPEncrypt(t *testing.T) {
+	body := rfc5322.NewBody()
+	body.SetHeader("Content-Type", "text/plain; charset=UTF-8")
+	body.SetContent([]byte("Secret message"))
+
+	pgp := &fakePGP{}
+	signed, err := rfc5322.SignPGP(body, pgp)
+	assert.NoError(t, err)
+	encrypted, err := rfc5322.EncryptPGP(signed, pgp)
+	assert.NoError(t, err)
+	s, err := newTestSignedEMail(t, encrypted).String()
+	assert.NoError(t, err)
+	assert.Contains(t, s, "Content-Type: multipart/encrypted; protocol=\"application/pgp-encrypted\"; boundary=")
+	assert.Contains(t, s, "Content-Type: application/pgp-encrypted\r\nContent-Description: PGP/MIME version identification\r\n\r\nVersion: 1\r\n")
+	assert.NotContains(t, s, "Secret message")
+
+	parsed, err := rfc5322.Parse(strings.NewReader(s))
+	assert.NoError(t, err)
+	decrypted, err := rfc5322.DecryptPGP(parsed.Body(), pgp)
+	assert.NoError(t, err)
+	content, err := rfc5322.VerifyPGP(decrypted, pgp)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasSuffix(content.String(), "\r\n\r\nSecret message"))
+
+	_, err = rfc5322.DecryptPGP(body, pgp)
+	assert.ErrorIs(t, err, rfc5322.ErrorNotEncrypted)
+}
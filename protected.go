@@ -0,0 +1,111 @@
+package rfc5322
+
+import (
+	"mime"
+	"slices"
+	"strings"
+)
+
+// defaultProtectedFields are the fields protected by ProtectHeaders when no names are given.
+var defaultProtectedFields = []string{
+	"Date", "From", "Sender", "To", "Cc", "Reply-To", "Message-ID", "In-Reply-To", "References", "Subject", "Comments", "Keywords",
+}
+
+// ProtectHeaders returns a copy of the EMail whose Body carries a copy of the named fields, as protected headers
+// marked with protected-headers="v1" in its Content-Type field. The outer fields are kept as is.
+// If no names are given, the fields shown to the user are protected. Bcc is never copied, since it must not be revealed to the other recipients.
+// The returned Body is meant to be signed with SignPGP or SignSMIME. Use ProtectHeadersForEncryption for a message that will be encrypted.
+func (e *EMail) ProtectHeaders(names ...string) (protected *EMail, err error) {
+	return e.protectHeaders(false, names)
+}
+
+// ProtectHeadersForEncryption is like ProtectHeaders, but also replaces the outer Subject by "..." if Subject is protected,
+// so that it is only readable after decryption. The returned Body is meant to be encrypted with EncryptPGP or EncryptSMIME,
+// possibly after being signed.
+func (e *EMail) ProtectHeadersForEncryption(names ...string) (protected *EMail, err error) {
+	return e.protectHeaders(true, names)
+}
+
+// protectHeaders returns a copy of the EMail with the protected headers, replacing the outer Subject if obscure is true.
+func (e *EMail) protectHeaders(obscure bool, names []string) (protected *EMail, err error) {
+	if len(names) == 0 {
+		names = defaultProtectedFields
+	}
+	fs, err := e.header.fields(true)
+	if err != nil {
+		return
+	}
+
+	body := e.body.clone()
+	for _, f := range fs {
+		if f.name == "MIME-Version" || strings.EqualFold(f.name, "Bcc") || strings.EqualFold(f.name, "Resent-Bcc") {
+			continue
+		}
+		if !slices.ContainsFunc(names, func(name string) bool { return strings.EqualFold(name, f.name) }) {
+			continue
+		}
+		body.headers = append(body.headers, f)
+	}
+	mediaType, params := body.mediaType()
+	params["protected-headers"] = "v1"
+	body.setHeader("Content-Type", mime.FormatMediaType(mediaType, params))
+
+	header := e.header.Clone()
+	if obscure && header.subject.IsSome() && slices.ContainsFunc(names, func(name string) bool { return strings.EqualFold(name, "Subject") }) {
+		header.SetSubject("...")
+	}
+	protected = NewEMail(header, body)
+	return
+}
+
+// RestoreProtectedHeaders returns a copy of the EMail where the protected headers of the Body replace the outer fields,
+// as after decrypting a message made with ProtectHeaders. The protected headers are looked up in the Body,
+// or in the signed part of a multipart/signed Body, which is kept as is so that it can still be verified.
+// If the Body has no protected headers, the EMail is returned unchanged.
+func (e *EMail) RestoreProtectedHeaders() (restored *EMail, err error) {
+	inner := e.body
+	if inner.ContentType() == "multipart/signed" && len(inner.parts) > 0 {
+		inner = inner.parts[0]
+	}
+	if _, params := inner.mediaType(); params["protected-headers"] != "v1" {
+		return e, nil
+	}
+
+	header := e.header.Clone()
+	protected := make([]field, 0)
+	for _, f := range inner.headers {
+		if !strings.HasPrefix(strings.ToLower(f.name), "content-") {
+			protected = append(protected, f)
+		}
+	}
+	for _, f := range protected {
+		header.Del(f.name)
+	}
+	for _, f := range protected {
+		if err = header.parseField(f); err != nil {
+			return
+		}
+	}
+
+	body := e.body
+	if inner == e.body {
+		body = e.body.clone()
+		body.headers = slices.DeleteFunc(body.headers, func(f field) bool {
+			return !strings.HasPrefix(strings.ToLower(f.name), "content-")
+		})
+		mediaType, params := body.mediaType()
+		delete(params, "protected-headers")
+		body.setHeader("Content-Type", mime.FormatMediaType(mediaType, params))
+	}
+	restored = NewEMail(header, body)
+	return
+}
+
+// clone returns a copy of the Body that can have its header fields changed, sharing the content and the parts.
+func (b *Body) clone() *Body {
+	return &Body{
+		headers: slices.Clone(b.headers),
+		content: b.content,
+		parts:   slices.Clone(b.parts),
+	}
+}
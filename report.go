@@ -0,0 +1,141 @@
+package rfc5322
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"strings"
+	"time"
+)
+
+// newReport returns a multipart/report Body, as per RFC 6522, made of a human readable text part,
+// the machine readable report part, and the original message or its header if it is not nil.
+// The Bcc field of the original message is never returned.
+func newReport(reportType, text string, report *Body, original *EMail, headersOnly bool) (b *Body, err error) {
+	human := NewBody()
+	human.setHeader("Content-Type", "text/plain; charset=UTF-8")
+	human.setHeader("Content-Transfer-Encoding", "8bit")
+	human.content = normalizeLineEndings([]byte(text))
+
+	b = NewBody()
+	b.setHeader("Content-Type", mime.FormatMediaType("multipart/report", map[string]string{
+		"report-type": reportType,
+		"boundary":    randomBoundary(),
+	}))
+	b.parts = []*Body{human, report}
+
+	if original == nil {
+		return
+	}
+	var s string
+	if s, err = original.WithoutBcc().String(); err != nil {
+		return
+	}
+	returned := NewBody()
+	if headersOnly {
+		// The header ends with the first empty line.
+		if i := strings.Index(s, "\r\n\r\n"); i >= 0 {
+			s = s[:i+2]
+		}
+		returned.setHeader("Content-Type", "text/rfc822-headers")
+	} else {
+		returned.setHeader("Content-Type", "message/rfc822")
+	}
+	returned.content = []byte(s)
+	b.parts = append(b.parts, returned)
+	return
+}
+
+// findReport returns the first multipart/report Body of the report type in the tree of the Body, in depth-first order.
+func (b *Body) findReport(reportType string) (report *Body, ok bool) {
+	mediaType, params := b.mediaType()
+	if mediaType == "multipart/report" && strings.EqualFold(params["report-type"], reportType) {
+		return b, true
+	}
+	for _, part := range b.parts {
+		if report, ok = part.findReport(reportType); ok {
+			return
+		}
+	}
+	return
+}
+
+// reportParts returns the human readable text, the machine readable part of the media type and the original message of a report.
+// The original message is nil if it is not included or cannot be parsed, and headersOnly reports whether only its header is included.
+func (b *Body) reportParts(machineType string) (text string, machine *Body, original *EMail, headersOnly bool, err error) {
+	for _, part := range b.parts {
+		switch mediaType := part.ContentType(); {
+		case mediaType == machineType && machine == nil:
+			machine = part
+		case (mediaType == "message/rfc822" || mediaType == "text/rfc822-headers") && original == nil:
+			var content []byte
+			if content, err = part.decodedContent(); err != nil {
+				return
+			}
+			if headersOnly = mediaType == "text/rfc822-headers"; headersOnly {
+				content = append(bytes.TrimRight(content, "\r\n"), "\r\n\r\n"...)
+			}
+			original, _ = Parse(bytes.NewReader(content))
+		case strings.HasPrefix(mediaType, "text/") && text == "" && machine == nil:
+			var content []byte
+			if content, err = part.decodedContent(); err != nil {
+				return
+			}
+			text = string(content)
+		}
+	}
+	if machine == nil {
+		err = ErrorNotReport
+	}
+	return
+}
+
+// parseFieldBlocks parses content made of blocks of header fields separated by empty lines, such as a message/delivery-status part.
+func parseFieldBlocks(content []byte) (blocks [][]field) {
+	content = normalizeLineEndings(content)
+	for {
+		content = bytes.TrimLeft(content, "\r\n")
+		if len(content) == 0 {
+			return
+		}
+		var fields []field
+		fields, content = splitMessage(content)
+		if len(fields) == 0 {
+			return
+		}
+		blocks = append(blocks, fields)
+	}
+}
+
+// fieldValue returns the trimmed value of the field with the name, matching it case-insensitively.
+func fieldValue(fields []field, name string) string {
+	for _, f := range fields {
+		if strings.EqualFold(f.name, name) {
+			return strings.TrimSpace(f.value)
+		}
+	}
+	return ""
+}
+
+// typedValue returns the value of a field of the form "type; value", without its type.
+func typedValue(fields []field, name string) string {
+	v := fieldValue(fields, name)
+	if _, value, ok := strings.Cut(v, ";"); ok {
+		return strings.TrimSpace(value)
+	}
+	return v
+}
+
+// writeField writes a field to the report content if its value is not empty.
+func writeField(buf *bytes.Buffer, name, value string) {
+	if value != "" {
+		fmt.Fprintf(buf, "%s: %s\r\n", name, value)
+	}
+}
+
+// writeDate writes a date field to the report content if the time is not zero.
+func writeDate(buf *bytes.Buffer, name string, t time.Time) {
+	if !t.IsZero() {
+		writeField(buf, name, NewDate(t).String())
+	}
+}
@@ -0,0 +1,221 @@
+package rfc5322
+
+import (
+	"bytes"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// CIDResolver returns the URL to display the part with the Content-ID, without angle brackets, or false if it is not available.
+type CIDResolver func(contentID string) (url string, ok bool)
+
+// sanitizedElements are the elements kept by SanitizeHTML.
+var sanitizedElements = map[atom.Atom]bool{
+	atom.A: true, atom.Abbr: true, atom.Address: true, atom.Article: true, atom.Aside: true, atom.B: true,
+	atom.Blockquote: true, atom.Br: true, atom.Caption: true, atom.Center: true, atom.Cite: true, atom.Code: true,
+	atom.Col: true, atom.Colgroup: true, atom.Dd: true, atom.Del: true, atom.Div: true, atom.Dl: true, atom.Dt: true,
+	atom.Em: true, atom.Figcaption: true, atom.Figure: true, atom.Font: true, atom.Footer: true,
+	atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.Header: true, atom.Hr: true, atom.I: true, atom.Img: true, atom.Ins: true, atom.Kbd: true, atom.Li: true,
+	atom.Main: true, atom.Mark: true, atom.Nav: true, atom.Ol: true, atom.P: true, atom.Pre: true, atom.Q: true,
+	atom.S: true, atom.Section: true, atom.Small: true, atom.Span: true, atom.Strike: true, atom.Strong: true,
+	atom.Sub: true, atom.Sup: true, atom.Table: true, atom.Tbody: true, atom.Td: true, atom.Tfoot: true,
+	atom.Th: true, atom.Thead: true, atom.Time: true, atom.Tr: true, atom.U: true, atom.Ul: true,
+}
+
+// droppedElements are the elements removed by SanitizeHTML with their content.
+// The other elements that are not kept are replaced with their content.
+var droppedElements = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Iframe: true, atom.Frame: true, atom.Frameset: true,
+	atom.Object: true, atom.Embed: true, atom.Applet: true, atom.Noscript: true, atom.Template: true,
+	atom.Svg: true, atom.Math: true, atom.Head: true, atom.Title: true, atom.Link: true, atom.Meta: true,
+	atom.Base: true, atom.Input: true, atom.Button: true, atom.Select: true, atom.Textarea: true,
+	atom.Audio: true, atom.Video: true, atom.Source: true, atom.Track: true, atom.Picture: true,
+}
+
+// sanitizedAttributes are the attributes kept by SanitizeHTML, in addition to the checked href, src and style attributes.
+var sanitizedAttributes = map[string]bool{
+	"align": true, "alt": true, "bgcolor": true, "border": true, "cellpadding": true, "cellspacing": true,
+	"class": true, "color": true, "colspan": true, "datetime": true, "dir": true, "face": true, "height": true,
+	"lang": true, "rowspan": true, "size": true, "start": true, "title": true, "type": true, "valign": true, "width": true,
+}
+
+// SanitizeHTML sanitizes untrusted HTML content for display, returning the content of its body.
+// It keeps an allowlist of formatting elements and attributes, and removes scripts, event handlers, forms, embedded content
+// and remote content, including style sheets and CSS URLs.
+// Links are kept for the http, https and mailto schemes, and images are kept for data URLs of images and cid URLs,
+// which are rewritten to the URLs returned by the CIDResolver. The CIDResolver may be nil to remove all cid images.
+func SanitizeHTML(content []byte, resolve CIDResolver) (sanitized []byte, err error) {
+	doc, err := html.Parse(bytes.NewReader(content))
+	if err != nil {
+		return
+	}
+	s := htmlSanitizer{resolve: resolve}
+	var body *html.Node
+	for n := range doc.Descendants() {
+		if n.Type == html.ElementNode && n.DataAtom == atom.Body {
+			body = n
+			break
+		}
+	}
+	var buf bytes.Buffer
+	if body == nil {
+		return buf.Bytes(), nil
+	}
+	s.children(body)
+	for n := body.FirstChild; n != nil; n = n.NextSibling {
+		if err = html.Render(&buf, n); err != nil {
+			return
+		}
+	}
+	sanitized = buf.Bytes()
+	return
+}
+
+// SanitizeBodyHTML sanitizes the text/html parts in the tree of the Body by SanitizeHTML.
+func SanitizeBodyHTML(b *Body, resolve CIDResolver) error {
+	return b.transformHTML(func(content []byte) ([]byte, error) {
+		return SanitizeHTML(content, resolve)
+	})
+}
+
+// htmlSanitizer is the state of the sanitization of HTML.
+type htmlSanitizer struct {
+	resolve CIDResolver
+}
+
+// children sanitizes the children of the node.
+func (s htmlSanitizer) children(n *html.Node) {
+	for child := n.FirstChild; child != nil; {
+		next := child.NextSibling
+		s.node(child)
+		child = next
+	}
+}
+
+// node sanitizes the node, which is removed or replaced with its children if it is not allowed.
+func (s htmlSanitizer) node(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		return
+	case html.ElementNode:
+	default:
+		n.Parent.RemoveChild(n)
+		return
+	}
+	if n.Namespace != "" || droppedElements[n.DataAtom] {
+		n.Parent.RemoveChild(n)
+		return
+	}
+	s.children(n)
+	if !sanitizedElements[n.DataAtom] {
+		for n.FirstChild != nil {
+			child := n.FirstChild
+			n.RemoveChild(child)
+			n.Parent.InsertBefore(child, n)
+		}
+		n.Parent.RemoveChild(n)
+		return
+	}
+
+	attrs := make([]html.Attribute, 0, len(n.Attr))
+	for _, a := range n.Attr {
+		key := strings.ToLower(a.Key)
+		switch {
+		case a.Namespace != "":
+			continue
+		case key == "href" && n.DataAtom == atom.A:
+			if !safeLink(a.Val) {
+				continue
+			}
+		case key == "src" && n.DataAtom == atom.Img:
+			src, ok := s.imageSource(a.Val)
+			if !ok {
+				continue
+			}
+			a.Val = src
+		case key == "style":
+			if a.Val = sanitizeStyle(a.Val); a.Val == "" {
+				continue
+			}
+		case !sanitizedAttributes[key]:
+			continue
+		}
+		a.Key = key
+		attrs = append(attrs, a)
+	}
+	n.Attr = attrs
+
+	switch n.DataAtom {
+	case atom.A:
+		if htmlAttribute(n, "href") != "" {
+			setHTMLAttribute(n, "rel", "noopener noreferrer nofollow")
+		}
+	case atom.Img:
+		// An image without a source is replaced with its alternative text.
+		if htmlAttribute(n, "src") == "" {
+			if alt := htmlAttribute(n, "alt"); alt != "" {
+				n.Parent.InsertBefore(&html.Node{Type: html.TextNode, Data: alt}, n)
+			}
+			n.Parent.RemoveChild(n)
+		}
+	}
+}
+
+// safeLink reports whether the URL of a link is a fragment or has the http, https or mailto scheme.
+func safeLink(value string) bool {
+	value = strings.TrimSpace(value)
+	if strings.HasPrefix(value, "#") {
+		return true
+	}
+	u, err := url.Parse(value)
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https", "mailto":
+		return true
+	}
+	return false
+}
+
+// imageSource returns the source of an image, which is kept for data URLs of images and rewritten for cid URLs.
+func (s htmlSanitizer) imageSource(value string) (src string, ok bool) {
+	value = strings.TrimSpace(value)
+	lower := strings.ToLower(value)
+	switch {
+	case strings.HasPrefix(lower, "data:image/") && !strings.HasPrefix(lower, "data:image/svg"):
+		return value, true
+	case strings.HasPrefix(lower, "cid:") && s.resolve != nil:
+		// The Content-ID of a cid URL is URL encoded, as per RFC 2392.
+		id, err := url.PathUnescape(value[len("cid:"):])
+		if err != nil {
+			return
+		}
+		if src, ok = s.resolve(id); ok {
+			ok = safeLink(src) || strings.HasPrefix(strings.ToLower(src), "data:image/")
+		}
+	}
+	return
+}
+
+// sanitizeStyle removes the declarations of a style attribute that can load remote content, run code or overlay the page.
+func sanitizeStyle(style string) string {
+	var kept []string
+	for _, d := range parseDeclarations(style) {
+		value := strings.ToLower(d.value)
+		if strings.Contains(value, "url(") || strings.Contains(value, "expression(") || strings.Contains(value, "\\") ||
+			strings.Contains(value, "image-set(") || d.property == "behavior" || d.property == "-moz-binding" || d.property == "position" {
+			continue
+		}
+		declaration := d.property + ": " + d.value
+		if d.important {
+			declaration += " !important"
+		}
+		kept = append(kept, declaration)
+	}
+	return strings.Join(kept, "; ")
+}
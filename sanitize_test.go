@@ -0,0 +1,51 @@
+package rfc5322_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aethiopicuschan/rfc5322-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSanitizeHTML(t *testing.T) {
+	resolve := func(contentID string) (string, bool) {
+		if contentID == "logo@example.com" {
+			return "https://mail.example.net/parts/1", true
+		}
+		return "", false
+	}
+	testCases := []struct {
+		name     string
+		html     string
+		expected string
+	}{
+		{"Script", `<p onclick="steal()">Hi<script>steal()</script></p>`, `<p>Hi</p>`},
+		{"Style", `<style>body { background: url(https://tracker.example/) }</style><p style="color: red; background: url('https://tracker.example/'); position: fixed">Hi</p>`, `<p style="color: red">Hi</p>`},
+		{"Links", `<a href="https://example.com" target="_top">A</a><a href="javascript:steal()">B</a><a href="#top">C</a>`, `<a href="https://example.com" rel="noopener noreferrer nofollow">A</a><a>B</a><a href="#top" rel="noopener noreferrer nofollow">C</a>`},
+		{"RemoteImage", `<img src="https://tracker.example/pixel.gif" alt="Pixel" width="1">`, `Pixel`},
+		{"CIDImage", `<img src="cid:logo%40example.com" alt="Logo"><img src="cid:unknown@example.com">`, `<img src="https://mail.example.net/parts/1" alt="Logo"/>`},
+		{"DataImage", `<img src="data:image/png;base64,iVBORw0KGgo="><img src="data:image/svg+xml,<svg/>">`, `<img src="data:image/png;base64,iVBORw0KGgo="/>`},
+		{"Embedded", `<iframe src="https://example.com"></iframe><form action="https://example.com"><input name="q">Text</form><svg><script>x()</script></svg>`, `Text`},
+		{"Unknown", `<custom-element data-x="1"><b id="x">Bold</b></custom-element>`, `<b>Bold</b>`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			sanitized, err := rfc5322.SanitizeHTML([]byte(tc.html), resolve)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, string(sanitized))
+		})
+	}
+}
+
+func TestSanitizeBodyHTML(t *testing.T) {
+	html := rfc5322.NewBody()
+	assert.NoError(t, html.SetHeader("Content-Type", "text/html"))
+	assert.NoError(t, html.SetHeader("Content-Transfer-Encoding", "base64"))
+	html.SetContent([]byte("PGI+SGk8L2I+PHNjcmlwdD54KCk8L3NjcmlwdD4=")) // <b>Hi</b><script>x()</script>
+
+	assert.NoError(t, rfc5322.SanitizeBodyHTML(html, nil))
+	assert.True(t, strings.HasSuffix(html.String(), "\r\n\r\n<b>Hi</b>"))
+}
@@ -0,0 +1,143 @@
+package rfc5322
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/x509"
+	"fmt"
+	"mime"
+	"strings"
+	"time"
+)
+
+// SignSMIME signs the Body and returns a multipart/signed Body made of the Body and a detached
+// application/pkcs7-signature part, as per RFC 8551 section 3.5.3.
+// The certificate and the intermediates are included in the signature, so that recipients can verify it.
+func SignSMIME(b *Body, cert *x509.Certificate, key crypto.Signer, intermediates ...*x509.Certificate) (signed *Body, err error) {
+	content, data, err := canonicalBody(b)
+	if err != nil {
+		return
+	}
+	der, err := cmsSign(data, cert, key, intermediates, time.Now())
+	if err != nil {
+		return
+	}
+
+	signature := NewBody()
+	signature.setHeader("Content-Type", `application/pkcs7-signature; name="smime.p7s"`)
+	signature.setHeader("Content-Transfer-Encoding", "base64")
+	signature.setHeader("Content-Disposition", `attachment; filename="smime.p7s"`)
+	signature.content = base64Lines(der)
+
+	signed = NewBody()
+	signed.setHeader("Content-Type", fmt.Sprintf(`multipart/signed; protocol="application/pkcs7-signature"; micalg=sha-256; boundary=%q`, randomBoundary()))
+	signed.parts = []*Body{content, signature}
+	return
+}
+
+// EncryptSMIME encrypts the Body for the recipients and returns an application/pkcs7-mime Body
+// with enveloped data, as per RFC 8551 section 3.3.
+// The content is encrypted with AES-256-CBC, and the key is encrypted with the RSA keys of the recipients.
+func EncryptSMIME(b *Body, recipients ...*x509.Certificate) (encrypted *Body, err error) {
+	_, data, err := canonicalBody(b)
+	if err != nil {
+		return
+	}
+	der, err := cmsEncrypt(data, recipients)
+	if err != nil {
+		return
+	}
+
+	encrypted = NewBody()
+	encrypted.setHeader("Content-Type", `application/pkcs7-mime; smime-type=enveloped-data; name="smime.p7m"`)
+	encrypted.setHeader("Content-Transfer-Encoding", "base64")
+	encrypted.setHeader("Content-Disposition", `attachment; filename="smime.p7m"`)
+	encrypted.content = base64Lines(der)
+	return
+}
+
+// VerifySMIME verifies a signed Body, either multipart/signed with an application/pkcs7-signature part,
+// or application/pkcs7-mime with signed data, and returns the signed content and the certificates of the signers.
+// The chains of the certificates are verified with opts. If opts.KeyUsages is empty, the email protection usage is required,
+// and if opts.CurrentTime is zero, the current time is used, since the signing time is asserted by the signer.
+// Signatures with SHA-1 digests are rejected with ErrorUnsupportedAlgorithm.
+func VerifySMIME(b *Body, opts x509.VerifyOptions) (content *Body, signers []*x509.Certificate, err error) {
+	mediaType, params := b.mediaType()
+	switch {
+	case mediaType == "multipart/signed":
+		protocol := strings.ToLower(params["protocol"])
+		if (protocol != "application/pkcs7-signature" && protocol != "application/x-pkcs7-signature") || len(b.parts) != 2 {
+			err = ErrorNotSigned
+			return
+		}
+		var der []byte
+		if der, err = b.parts[1].decodedContent(); err != nil {
+			return
+		}
+		// The signed content is the part exactly as it was transmitted.
+		if _, signers, err = cmsVerify(der, []byte(b.parts[0].String()), opts); err != nil {
+			return
+		}
+		content = b.parts[0]
+	case isPKCS7MIME(mediaType) && strings.EqualFold(params["smime-type"], "signed-data"):
+		var der, data []byte
+		if der, err = b.decodedContent(); err != nil {
+			return
+		}
+		if data, signers, err = cmsVerify(der, nil, opts); err != nil {
+			return
+		}
+		content, err = ParseBody(bytes.NewReader(data))
+	default:
+		err = ErrorNotSigned
+	}
+	return
+}
+
+// DecryptSMIME decrypts an application/pkcs7-mime Body with enveloped data for the recipient with the certificate and key.
+func DecryptSMIME(b *Body, cert *x509.Certificate, key crypto.Decrypter) (content *Body, err error) {
+	mediaType, params := b.mediaType()
+	smimeType := strings.ToLower(params["smime-type"])
+	if !isPKCS7MIME(mediaType) || (smimeType != "" && smimeType != "enveloped-data") {
+		err = ErrorNotEncrypted
+		return
+	}
+	der, err := b.decodedContent()
+	if err != nil {
+		return
+	}
+	data, err := cmsDecrypt(der, cert, key)
+	if err != nil {
+		return
+	}
+	return ParseBody(bytes.NewReader(data))
+}
+
+// canonicalBody returns the canonical representation of the Body with CRLF line endings,
+// and a Body that is written exactly as the canonical representation.
+func canonicalBody(b *Body) (content *Body, data []byte, err error) {
+	data = normalizeLineEndings([]byte(b.String()))
+	if content, err = ParseBody(bytes.NewReader(data)); err != nil {
+		return
+	}
+	content.raw = data
+	return
+}
+
+// mediaType returns the media type and the parameters of the Content-Type field.
+func (b *Body) mediaType() (mediaType string, params map[string]string) {
+	ct, ok := b.header("Content-Type")
+	if !ok {
+		return "text/plain", map[string]string{}
+	}
+	mediaType, params, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return strings.ToLower(ct), map[string]string{}
+	}
+	return mediaType, params
+}
+
+// isPKCS7MIME reports whether the media type is application/pkcs7-mime, or its legacy name.
+func isPKCS7MIME(mediaType string) bool {
+	return mediaType == "application/pkcs7-mime" || mediaType == "application/x-pkcs7-mime"
+}
@@ -0,0 +1,158 @@
+package rfc5322_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aethiopicuschan/rfc5322-go"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestCertificate creates a certificate for the email address, issued by the parent, or self-signed if parent is nil.
+func newTestCertificate(t *testing.T, email string, key crypto.Signer, parent *x509.Certificate, parentKey crypto.Signer) *x509.Certificate {
+	t.Helper()
+	serial, _ := rand.Int(rand.Reader, big.NewInt(1<<62))
+	template := &x509.Certificate{
+		SerialNumber:   serial,
+		Subject:        pkix.Name{CommonName: email},
+		EmailAddresses: []string{email},
+		NotBefore:      time.Now().Add(-time.Hour),
+		NotAfter:       time.Now().Add(time.Hour),
+		KeyUsage:       x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:    []x509.ExtKeyUsage{x509.ExtKeyUsageEmailProtection},
+	}
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage |= x509.KeyUsageCertSign
+		parent = template
+		parentKey = key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, key.Public(), parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+func newTestSignedEMail(t *testing.T, body *rfc5322.Body) *rfc5322.EMail {
+	t.Helper()
+	from, _ := rfc5322.NewAddress("alice@example.com")
+	header := rfc5322.NewHeader(*rfc5322.NewDate(time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)), rfc5322.NewAddresses(*from))
+	return rfc5322.NewEMail(header, body)
+}
+
+func TestSMIMESign(t *testing.T) {
+	caKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	ca := newTestCertificate(t, "ca@example.com", caKey, nil, nil)
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	testCases := []struct {
+		name string
+		key  crypto.Signer
+	}{
+		{"RSA", rsaKey},
+		{"ECDSA", ecKey},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			cert := newTestCertificate(t, "alice@example.com", tc.key, ca, caKey)
+
+			// A nested multipart body, to check that the boundaries do not collide.
+			body := rfc5322.NewBody()
+			body.SetHeader("Content-Type", "multipart/alternative")
+			plain := rfc5322.NewBody()
+			plain.SetHeader("Content-Type", "text/plain; charset=UTF-8")
+			plain.SetContent([]byte("Hi Bob!\nThis line has an LF line ending."))
+			html := rfc5322.NewBody()
+			html.SetHeader("Content-Type", "text/html; charset=UTF-8")
+			html.SetContent([]byte("<p>Hi Bob!</p>"))
+			body.AddPart(plain)
+			body.AddPart(html)
+
+			signed, err := rfc5322.SignSMIME(body, cert, tc.key)
+			assert.NoError(t, err)
+			s, err := newTestSignedEMail(t, signed).String()
+			assert.NoError(t, err)
+			assert.Contains(t, s, "Content-Type: multipart/signed; protocol=\"application/pkcs7-signature\"; micalg=sha-256; boundary=")
+
+			// Verify the signature of the parsed message, as a recipient would.
+			parsed, err := rfc5322.ReadEML(strings.NewReader(strings.ReplaceAll(s, "\r\n", "\n")))
+			assert.NoError(t, err)
+			content, signers, err := rfc5322.VerifySMIME(parsed.Body(), x509.VerifyOptions{Roots: roots})
+			assert.NoError(t, err)
+			assert.Equal(t, "multipart/alternative", content.ContentType())
+			if assert.Len(t, signers, 1) {
+				assert.True(t, signers[0].Equal(cert))
+			}
+
+			// A certificate that has expired at the verification time is rejected.
+			_, _, err = rfc5322.VerifySMIME(parsed.Body(), x509.VerifyOptions{Roots: roots, CurrentTime: time.Now().Add(2 * time.Hour)})
+			assert.Error(t, err)
+
+			// An untrusted signer is rejected.
+			_, _, err = rfc5322.VerifySMIME(parsed.Body(), x509.VerifyOptions{Roots: x509.NewCertPool()})
+			assert.Error(t, err)
+
+			// A modified message is rejected.
+			tampered, err := rfc5322.Parse(strings.NewReader(strings.Replace(s, "Hi Bob!", "Hi Eve!", 1)))
+			assert.NoError(t, err)
+			_, _, err = rfc5322.VerifySMIME(tampered.Body(), x509.VerifyOptions{Roots: roots})
+			assert.ErrorIs(t, err, rfc5322.ErrorInvalidSignature)
+		})
+	}
+}
+
+func TestSMIMEVerifyNotSigned(t *testing.T) {
+	_, _, err := rfc5322.VerifySMIME(rfc5322.NewBody(), x509.VerifyOptions{})
+	assert.ErrorIs(t, err, rfc5322.ErrorNotSigned)
+}
+
+func TestSMIMEEncrypt(t *testing.T) {
+	caKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	ca := newTestCertificate(t, "ca@example.com", caKey, nil, nil)
+	bobKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	bob := newTestCertificate(t, "bob@example.com", bobKey, ca, caKey)
+	carolKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	carol := newTestCertificate(t, "carol@example.com", carolKey, ca, caKey)
+
+	body := rfc5322.NewBody()
+	body.SetHeader("Content-Type", "text/plain; charset=UTF-8")
+	body.SetContent([]byte("Secret message"))
+	encrypted, err := rfc5322.EncryptSMIME(body, bob)
+	assert.NoError(t, err)
+	s, err := newTestSignedEMail(t, encrypted).String()
+	assert.NoError(t, err)
+	assert.Contains(t, s, "Content-Type: application/pkcs7-mime; smime-type=enveloped-data; name=\"smime.p7m\"\r\n")
+	assert.NotContains(t, s, "Secret message")
+
+	parsed, err := rfc5322.Parse(strings.NewReader(s))
+	assert.NoError(t, err)
+	content, err := rfc5322.DecryptSMIME(parsed.Body(), bob, bobKey)
+	assert.NoError(t, err)
+	assert.Equal(t, "Content-Type: text/plain; charset=UTF-8\r\n\r\nSecret message", content.String())
+
+	_, err = rfc5322.DecryptSMIME(parsed.Body(), carol, carolKey)
+	assert.ErrorIs(t, err, rfc5322.ErrorNoRecipient)
+
+	_, err = rfc5322.DecryptSMIME(body, bob, bobKey)
+	assert.ErrorIs(t, err, rfc5322.ErrorNotEncrypted)
+}
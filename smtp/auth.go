@@ -0,0 +1,177 @@
+package smtp
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// ServerInfo records information about an SMTP server.
+type ServerInfo struct {
+	// Name is the host name of the server.
+	Name string
+	// TLS reports whether the connection is secured with TLS.
+	TLS bool
+	// Auth is the list of advertised authentication mechanisms.
+	Auth []string
+}
+
+// Auth is implemented by an SMTP authentication mechanism as per RFC 4954.
+type Auth interface {
+	// Start begins an authentication with a server.
+	// It returns the name of the mechanism and optionally an initial response.
+	Start(server *ServerInfo) (mechanism string, response []byte, err error)
+	// Next continues the authentication with a challenge from the server.
+	// If more is true, the server expects a response.
+	Next(challenge []byte, more bool) (response []byte, err error)
+}
+
+// plainAuth implements the PLAIN mechanism as per RFC 4616.
+type plainAuth struct {
+	identity string
+	username string
+	password string
+}
+
+// PlainAuth returns an Auth that implements the PLAIN mechanism.
+// It refuses to send credentials over an unencrypted connection unless the server is on localhost.
+func PlainAuth(identity, username, password string) Auth {
+	return &plainAuth{
+		identity: identity,
+		username: username,
+		password: password,
+	}
+}
+
+func (a *plainAuth) Start(server *ServerInfo) (string, []byte, error) {
+	if err := requireTLS(server); err != nil {
+		return "", nil, err
+	}
+	resp := []byte(a.identity + "\x00" + a.username + "\x00" + a.password)
+	return "PLAIN", resp, nil
+}
+
+func (a *plainAuth) Next(challenge []byte, more bool) ([]byte, error) {
+	if more {
+		return nil, errors.New("unexpected server challenge")
+	}
+	return nil, nil
+}
+
+// loginAuth implements the obsolete but widely deployed LOGIN mechanism.
+type loginAuth struct {
+	username string
+	password string
+	step     int
+}
+
+// LoginAuth returns an Auth that implements the LOGIN mechanism.
+// It refuses to send credentials over an unencrypted connection unless the server is on localhost.
+func LoginAuth(username, password string) Auth {
+	return &loginAuth{
+		username: username,
+		password: password,
+	}
+}
+
+func (a *loginAuth) Start(server *ServerInfo) (string, []byte, error) {
+	if err := requireTLS(server); err != nil {
+		return "", nil, err
+	}
+	a.step = 0
+	return "LOGIN", nil, nil
+}
+
+func (a *loginAuth) Next(challenge []byte, more bool) ([]byte, error) {
+	if !more {
+		return nil, nil
+	}
+	a.step++
+	switch a.step {
+	case 1:
+		return []byte(a.username), nil
+	case 2:
+		return []byte(a.password), nil
+	}
+	return nil, fmt.Errorf("unexpected server challenge: %q", challenge)
+}
+
+// cramMD5Auth implements the CRAM-MD5 mechanism as per RFC 2195.
+type cramMD5Auth struct {
+	username string
+	secret   string
+}
+
+// CRAMMD5Auth returns an Auth that implements the CRAM-MD5 mechanism.
+// The secret is never sent to the server, so it may be used over an unencrypted connection.
+func CRAMMD5Auth(username, secret string) Auth {
+	return &cramMD5Auth{
+		username: username,
+		secret:   secret,
+	}
+}
+
+func (a *cramMD5Auth) Start(server *ServerInfo) (string, []byte, error) {
+	return "CRAM-MD5", nil, nil
+}
+
+func (a *cramMD5Auth) Next(challenge []byte, more bool) ([]byte, error) {
+	if !more {
+		return nil, nil
+	}
+	mac := hmac.New(md5.New, []byte(a.secret))
+	mac.Write(challenge)
+	return []byte(a.username + " " + hex.EncodeToString(mac.Sum(nil))), nil
+}
+
+// xoauth2Auth implements the XOAUTH2 mechanism used by Google and Microsoft.
+type xoauth2Auth struct {
+	username string
+	token    string
+}
+
+// XOAuth2Auth returns an Auth that implements the XOAUTH2 mechanism with an OAuth 2.0 access token.
+// It refuses to send the token over an unencrypted connection unless the server is on localhost.
+func XOAuth2Auth(username, token string) Auth {
+	return &xoauth2Auth{
+		username: username,
+		token:    token,
+	}
+}
+
+func (a *xoauth2Auth) Start(server *ServerInfo) (string, []byte, error) {
+	if err := requireTLS(server); err != nil {
+		return "", nil, err
+	}
+	resp := []byte("user=" + a.username + "\x01auth=Bearer " + a.token + "\x01\x01")
+	return "XOAUTH2", resp, nil
+}
+
+func (a *xoauth2Auth) Next(challenge []byte, more bool) ([]byte, error) {
+	if more {
+		// The server sends an error as a challenge, and expects an empty response before the final reply.
+		return []byte{}, nil
+	}
+	return nil, nil
+}
+
+// requireTLS returns an error if credentials would be sent in the clear to a remote server.
+func requireTLS(server *ServerInfo) error {
+	if server.TLS || isLocalhost(server.Name) {
+		return nil
+	}
+	return ErrorUnencryptedConnection
+}
+
+// isLocalhost reports whether the host is a loopback address.
+func isLocalhost(host string) bool {
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
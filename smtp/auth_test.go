@@ -0,0 +1,85 @@
+package smtp_test
+
+import (
+	"testing"
+
+	"github.com/aethiopicuschan/rfc5322-go/smtp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuth(t *testing.T) {
+	testCases := []struct {
+		name       string
+		auth       smtp.Auth
+		server     smtp.ServerInfo
+		mechanism  string
+		initial    []byte
+		challenges []string
+		responses  []string
+		expected   error
+	}{
+		{
+			name:      "PLAIN",
+			auth:      smtp.PlainAuth("", "user", "pass"),
+			server:    smtp.ServerInfo{Name: "smtp.example.com", TLS: true},
+			mechanism: "PLAIN",
+			initial:   []byte("\x00user\x00pass"),
+		},
+		{
+			name:     "PLAIN without TLS",
+			auth:     smtp.PlainAuth("", "user", "pass"),
+			server:   smtp.ServerInfo{Name: "smtp.example.com"},
+			expected: smtp.ErrorUnencryptedConnection,
+		},
+		{
+			name:      "PLAIN without TLS on localhost",
+			auth:      smtp.PlainAuth("", "user", "pass"),
+			server:    smtp.ServerInfo{Name: "localhost"},
+			mechanism: "PLAIN",
+			initial:   []byte("\x00user\x00pass"),
+		},
+		{
+			name:       "LOGIN",
+			auth:       smtp.LoginAuth("user", "pass"),
+			server:     smtp.ServerInfo{Name: "smtp.example.com", TLS: true},
+			mechanism:  "LOGIN",
+			challenges: []string{"Username:", "Password:"},
+			responses:  []string{"user", "pass"},
+		},
+		{
+			// The example in RFC 2195 section 2.
+			name:       "CRAM-MD5",
+			auth:       smtp.CRAMMD5Auth("tim", "tanstaaftanstaaf"),
+			server:     smtp.ServerInfo{Name: "smtp.example.com"},
+			mechanism:  "CRAM-MD5",
+			challenges: []string{"<1896.697170952@postoffice.reston.mci.net>"},
+			responses:  []string{"tim b913a602c7eda7a495b4e6e7334d3890"},
+		},
+		{
+			name:      "XOAUTH2",
+			auth:      smtp.XOAuth2Auth("user@example.com", "token"),
+			server:    smtp.ServerInfo{Name: "smtp.example.com", TLS: true},
+			mechanism: "XOAUTH2",
+			initial:   []byte("user=user@example.com\x01auth=Bearer token\x01\x01"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			mechanism, initial, err := tc.auth.Start(&tc.server)
+			if tc.expected != nil {
+				assert.ErrorIs(t, err, tc.expected)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.mechanism, mechanism)
+			assert.Equal(t, tc.initial, initial)
+			for i, challenge := range tc.challenges {
+				resp, err := tc.auth.Next([]byte(challenge), true)
+				assert.NoError(t, err)
+				assert.Equal(t, tc.responses[i], string(resp))
+			}
+		})
+	}
+}
@@ -0,0 +1,252 @@
+package smtp
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/aethiopicuschan/rfc5322-go"
+)
+
+// Result represents the result of sending a single EMail with a BulkSender.
+type Result struct {
+	// EMail is the message that was sent.
+	EMail *rfc5322.EMail
+	// Recipients is the final result for each envelope recipient.
+	// A recipient with a zero Code could not be attempted because of Err.
+	Recipients []RecipientResult
+	// Attempts is the number of delivery attempts.
+	Attempts int
+	// Err is the error that prevented the delivery to some recipients, if any.
+	Err error
+}
+
+// OK reports whether the message was accepted for every recipient.
+func (r Result) OK() bool {
+	if r.Err != nil {
+		return false
+	}
+	for _, rcpt := range r.Recipients {
+		if !rcpt.OK() {
+			return false
+		}
+	}
+	return true
+}
+
+// BulkSender sends many EMail values over a bounded pool of reused connections.
+// Recipients rejected with a transient (4xx) reply are retried with exponential backoff.
+type BulkSender struct {
+	client      *Client
+	concurrency int
+	maxRetries  int
+	backoff     time.Duration
+	maxBackoff  time.Duration
+	maxMessages int
+}
+
+// NewBulkSender creates a new BulkSender that connects with the Client.
+func NewBulkSender(client *Client) *BulkSender {
+	return &BulkSender{
+		client:      client,
+		concurrency: 4,
+		maxRetries:  3,
+		backoff:     time.Second,
+		maxBackoff:  time.Minute,
+	}
+}
+
+// SetConcurrency sets the maximum number of simultaneous connections. The default is 4.
+func (b *BulkSender) SetConcurrency(n int) *BulkSender {
+	b.concurrency = max(n, 1)
+	return b
+}
+
+// SetMaxRetries sets the maximum number of retries after a transient failure. The default is 3.
+func (b *BulkSender) SetMaxRetries(n int) *BulkSender {
+	b.maxRetries = max(n, 0)
+	return b
+}
+
+// SetBackoff sets the delay before the first retry and the maximum delay.
+// The delay is doubled for each retry. The defaults are one second and one minute.
+func (b *BulkSender) SetBackoff(initial, maximum time.Duration) *BulkSender {
+	b.backoff = initial
+	b.maxBackoff = maximum
+	return b
+}
+
+// SetMaxMessagesPerConnection sets the number of messages sent over a connection before it is renewed.
+// Zero, the default, means no limit.
+func (b *BulkSender) SetMaxMessagesPerConnection(n int) *BulkSender {
+	b.maxMessages = max(n, 0)
+	return b
+}
+
+// Send sends the EMail values and returns a Result for each of them, in the same order.
+// If the context is done, the remaining messages are not sent and their Err is the context error.
+func (b *BulkSender) Send(ctx context.Context, emails []*rfc5322.EMail) []Result {
+	results := make([]Result, len(emails))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range min(b.concurrency, len(emails)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w := &worker{sender: b}
+			defer w.close()
+			for i := range jobs {
+				results[i] = w.deliver(ctx, emails[i])
+			}
+		}()
+	}
+	for i := range emails {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return results
+}
+
+// worker owns a single connection of the pool.
+type worker struct {
+	sender *BulkSender
+	s      *session
+	sent   int
+}
+
+// session returns the current connection, connecting if needed.
+func (w *worker) session(ctx context.Context) (s *session, err error) {
+	if w.s != nil && w.sender.maxMessages > 0 && w.sent >= w.sender.maxMessages {
+		w.close()
+	}
+	if w.s == nil {
+		if w.s, err = w.sender.client.dial(ctx); err != nil {
+			return
+		}
+		w.sent = 0
+	}
+	w.s.watch(ctx)
+	return w.s, nil
+}
+
+// close ends the current connection, if any.
+func (w *worker) close() {
+	if w.s != nil {
+		w.s.quit()
+		w.s.close()
+		w.s = nil
+	}
+}
+
+// drop discards the current connection without a QUIT, after a connection failure.
+func (w *worker) drop() {
+	if w.s != nil {
+		w.s.close()
+		w.s = nil
+	}
+}
+
+// deliver sends a single EMail, retrying the recipients with transient failures.
+func (w *worker) deliver(ctx context.Context, email *rfc5322.EMail) (res Result) {
+	res.EMail = email
+	env, err := email.Envelope()
+	if err != nil {
+		res.Err = err
+		return
+	}
+	data, err := email.WithoutBcc().String()
+	if err != nil {
+		res.Err = err
+		return
+	}
+
+	// final holds the result for each envelope recipient, and pending the indexes still to be attempted.
+	final := make([]RecipientResult, len(env.To))
+	pending := make([]int, len(env.To))
+	for i, rcpt := range env.To {
+		final[i].Recipient = rcpt
+		pending[i] = i
+	}
+	delay := w.sender.backoff
+	for {
+		if err = ctx.Err(); err != nil {
+			break
+		}
+		res.Attempts++
+		to := make([]string, len(pending))
+		for i, p := range pending {
+			to[i] = env.To[p]
+		}
+
+		var results []RecipientResult
+		var s *session
+		s, err = w.session(ctx)
+		if err == nil {
+			results, err = s.send(rfc5322.Envelope{From: env.From, To: to}, []byte(data))
+			w.sent++
+		}
+		if err != nil {
+			err = contextError(ctx, err)
+			var smtpErr *Error
+			if !errors.As(err, &smtpErr) {
+				// The replies received before a connection failure do not tell whether the message was delivered.
+				results = nil
+				w.drop()
+			} else if smtpErr.Code == 421 {
+				w.drop()
+			} else if w.s != nil && w.s.reset() != nil {
+				w.drop()
+			}
+		}
+
+		retry := make([]int, 0)
+		for i, p := range pending {
+			if i < len(results) && results[i].Code != 0 {
+				final[p] = results[i]
+				if results[i].Temporary() {
+					retry = append(retry, p)
+				}
+			} else if retryable(err) {
+				retry = append(retry, p)
+			}
+		}
+		pending = retry
+		if len(pending) == 0 || res.Attempts > w.sender.maxRetries || (err != nil && !retryable(err)) {
+			break
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+		case <-timer.C:
+		}
+		delay = min(delay*2, w.sender.maxBackoff)
+	}
+	res.Recipients = final
+	res.Err = err
+	return
+}
+
+// retryable reports whether a delivery that failed with the error may succeed later.
+func retryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	var smtpErr *Error
+	if errors.As(err, &smtpErr) {
+		return smtpErr.Temporary()
+	}
+	switch {
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded),
+		errors.Is(err, ErrorStartTLSNotSupported), errors.Is(err, ErrorAuthNotSupported),
+		errors.Is(err, ErrorAuthMechanismNotSupported), errors.Is(err, ErrorUnencryptedConnection),
+		errors.Is(err, ErrorMessageTooLarge), errors.Is(err, Error8BitMIMENotSupported),
+		errors.Is(err, ErrorSMTPUTF8NotSupported), errors.Is(err, ErrorInvalidAddr):
+		return false
+	}
+	// Connection failures are considered transient.
+	return true
+}
@@ -0,0 +1,115 @@
+package smtp_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aethiopicuschan/rfc5322-go"
+	"github.com/aethiopicuschan/rfc5322-go/smtp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBulkSender(t *testing.T) {
+	var mu sync.Mutex
+	attempts := make(map[string]int)
+	server := &fakeServer{
+		Extensions: []string{"PIPELINING"},
+		RcptReply: func(rcpt string) string {
+			mu.Lock()
+			defer mu.Unlock()
+			attempts[rcpt]++
+			switch rcpt {
+			case "greylisted@example.com":
+				if attempts[rcpt] == 1 {
+					return "451 4.7.1 Greylisted, try again later"
+				}
+			case "full@example.com":
+				return "452 4.2.2 Mailbox full"
+			case "nobody@example.com":
+				return "550 5.1.1 No such user"
+			}
+			return ""
+		},
+	}
+	addr := server.start(t)
+	client := smtp.NewClient(addr).SetSecurity(smtp.Plaintext)
+	sender := smtp.NewBulkSender(client).
+		SetConcurrency(3).
+		SetMaxRetries(2).
+		SetBackoff(time.Millisecond, 10*time.Millisecond)
+
+	emails := make([]*rfc5322.EMail, 0)
+	for i := range 20 {
+		emails = append(emails, newTestEMail(t, fmt.Sprintf("Hi #%d", i), fmt.Sprintf("user%d@example.com", i)))
+	}
+	emails = append(emails,
+		newTestEMail(t, "Hi!", "bob@example.com", "greylisted@example.com"),
+		newTestEMail(t, "Hi!", "bob@example.com", "nobody@example.com"),
+		newTestEMail(t, "Hi!", "full@example.com"),
+	)
+
+	results := sender.Send(context.Background(), emails)
+	assert.Len(t, results, len(emails))
+	for i := range 20 {
+		assert.True(t, results[i].OK())
+		assert.Equal(t, 1, results[i].Attempts)
+		assert.Same(t, emails[i], results[i].EMail)
+		assert.Equal(t, fmt.Sprintf("user%d@example.com", i), results[i].Recipients[0].Recipient)
+	}
+
+	greylisted := results[20]
+	assert.True(t, greylisted.OK())
+	assert.Equal(t, 2, greylisted.Attempts)
+	assert.Equal(t, 250, greylisted.Recipients[1].Code)
+
+	rejected := results[21]
+	assert.False(t, rejected.OK())
+	assert.Equal(t, 1, rejected.Attempts)
+	assert.Equal(t, 250, rejected.Recipients[0].Code)
+	assert.Equal(t, smtp.RecipientResult{Recipient: "nobody@example.com", Code: 550, EnhancedCode: "5.1.1", Message: "No such user"}, rejected.Recipients[1])
+
+	full := results[22]
+	assert.False(t, full.OK())
+	assert.Equal(t, 3, full.Attempts)
+	assert.True(t, full.Recipients[0].Temporary())
+	assert.Equal(t, 452, full.Recipients[0].Code)
+
+	// The greylisted recipient is retried in a separate transaction, and the connections are reused.
+	assert.Len(t, server.Messages(), 23)
+	assert.LessOrEqual(t, server.Connections(), 3)
+}
+
+func TestBulkSenderMaxMessagesPerConnection(t *testing.T) {
+	server := &fakeServer{}
+	addr := server.start(t)
+	sender := smtp.NewBulkSender(smtp.NewClient(addr).SetSecurity(smtp.Plaintext)).
+		SetConcurrency(1).
+		SetMaxMessagesPerConnection(2)
+
+	emails := make([]*rfc5322.EMail, 0)
+	for range 5 {
+		emails = append(emails, newTestEMail(t, "Hi!", "bob@example.com"))
+	}
+	for _, r := range sender.Send(context.Background(), emails) {
+		assert.True(t, r.OK())
+	}
+	assert.Equal(t, 3, server.Connections())
+}
+
+func TestBulkSenderConnectionFailure(t *testing.T) {
+	server := &fakeServer{}
+	addr := server.start(t)
+	server.ln.Close()
+	sender := smtp.NewBulkSender(smtp.NewClient(addr).SetSecurity(smtp.Plaintext)).
+		SetMaxRetries(1).
+		SetBackoff(time.Millisecond, time.Millisecond)
+
+	results := sender.Send(context.Background(), []*rfc5322.EMail{newTestEMail(t, "Hi!", "bob@example.com")})
+	assert.False(t, results[0].OK())
+	assert.Error(t, results[0].Err)
+	assert.Equal(t, 2, results[0].Attempts)
+	assert.Equal(t, 0, results[0].Recipients[0].Code)
+}
@@ -0,0 +1,165 @@
+// Package smtp provides an SMTP submission client for rfc5322.EMail values.
+package smtp
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+
+	"github.com/aethiopicuschan/rfc5322-go"
+)
+
+// Security represents how the connection to the server is secured.
+type Security int
+
+const (
+	// StartTLS upgrades the connection with the STARTTLS command, and fails if the server does not support it.
+	StartTLS Security = iota
+	// ImplicitTLS uses TLS from the start of the connection, usually on port 465.
+	ImplicitTLS
+	// Plaintext never uses TLS. It should only be used for local testing.
+	Plaintext
+)
+
+// Client sends EMail values to an SMTP submission server.
+type Client struct {
+	addr      string
+	security  Security
+	tlsConfig *tls.Config
+	auth      Auth
+	localName string
+	dialer    net.Dialer
+}
+
+// NewClient creates a new Client for the server at addr in the form "host:port".
+func NewClient(addr string) *Client {
+	return &Client{
+		addr:      addr,
+		security:  StartTLS,
+		localName: "localhost",
+	}
+}
+
+// SetSecurity sets how the connection to the server is secured. The default is StartTLS.
+func (c *Client) SetSecurity(security Security) *Client {
+	c.security = security
+	return c
+}
+
+// SetTLSConfig sets the TLS configuration.
+// If the ServerName is empty, the host of the server address is used.
+func (c *Client) SetTLSConfig(config *tls.Config) *Client {
+	c.tlsConfig = config
+	return c
+}
+
+// SetAuth sets the authentication mechanism.
+func (c *Client) SetAuth(auth Auth) *Client {
+	c.auth = auth
+	return c
+}
+
+// SetLocalName sets the host name sent with the EHLO command. The default is "localhost".
+func (c *Client) SetLocalName(name string) *Client {
+	c.localName = name
+	return c
+}
+
+// Send sends the EMail.
+// The envelope is derived from the Header with EMail.Envelope, and the Bcc field is removed from the transmitted message.
+// If the server rejects some of the recipients, a *RecipientError is returned after delivering to the others.
+func (c *Client) Send(ctx context.Context, email *rfc5322.EMail) (err error) {
+	env, err := email.Envelope()
+	if err != nil {
+		return
+	}
+	data, err := email.WithoutBcc().String()
+	if err != nil {
+		return
+	}
+
+	s, err := c.dial(ctx)
+	if err != nil {
+		return
+	}
+	defer s.close()
+
+	results, err := s.send(env, []byte(data))
+	if err != nil {
+		return contextError(ctx, err)
+	}
+	s.quit()
+
+	rejected := make([]RecipientResult, 0)
+	for _, r := range results {
+		if !r.OK() {
+			rejected = append(rejected, r)
+		}
+	}
+	if len(rejected) > 0 {
+		err = &RecipientError{Rejected: rejected}
+	}
+	return
+}
+
+// dial connects to the server and returns a session ready for mail transactions.
+// The returned session watches the context until it is closed or watches another one.
+func (c *Client) dial(ctx context.Context) (s *session, err error) {
+	host, _, err := net.SplitHostPort(c.addr)
+	if err != nil {
+		err = ErrorInvalidAddr
+		return
+	}
+	config := &tls.Config{}
+	if c.tlsConfig != nil {
+		config = c.tlsConfig.Clone()
+	}
+	if config.ServerName == "" {
+		config.ServerName = host
+	}
+
+	var conn net.Conn
+	if c.security == ImplicitTLS {
+		d := tls.Dialer{NetDialer: &c.dialer, Config: config}
+		conn, err = d.DialContext(ctx, "tcp", c.addr)
+	} else {
+		conn, err = c.dialer.DialContext(ctx, "tcp", c.addr)
+	}
+	if err != nil {
+		return
+	}
+
+	s = newSession(conn, host, c.security == ImplicitTLS)
+	s.watch(ctx)
+	defer func() {
+		if err != nil {
+			s.close()
+			s = nil
+			err = contextError(ctx, err)
+		}
+	}()
+
+	if err = s.greet(c.localName); err != nil {
+		return
+	}
+	if c.security == StartTLS {
+		if err = s.startTLS(config, c.localName); err != nil {
+			return
+		}
+	}
+	if c.auth != nil {
+		if err = s.authenticate(c.auth); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// contextError returns the error of the context if it is done, since it is the cause of any I/O error.
+func contextError(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil && !errors.Is(err, ctxErr) {
+		return ctxErr
+	}
+	return err
+}
@@ -0,0 +1,257 @@
+package smtp_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aethiopicuschan/rfc5322-go"
+	"github.com/aethiopicuschan/rfc5322-go/smtp"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestEMail(t *testing.T, content string, to ...string) *rfc5322.EMail {
+	t.Helper()
+	from, err := rfc5322.NewAddressWithName("Alice", "alice@example.com")
+	assert.NoError(t, err)
+	header := rfc5322.NewHeader(*rfc5322.NewDate(time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)), rfc5322.NewAddresses(*from))
+	for _, addr := range to {
+		a, err := rfc5322.NewAddress(addr)
+		assert.NoError(t, err)
+		header.AddTo(*a)
+	}
+	header.SetSubject("Hello")
+	body := rfc5322.NewBody()
+	body.SetContent([]byte(content))
+	return rfc5322.NewEMail(header, body)
+}
+
+func TestSend(t *testing.T) {
+	serverTLS, clientTLS := newTLSConfigs(t)
+	testCases := []struct {
+		name     string
+		server   *fakeServer
+		client   func(addr string) *smtp.Client
+		email    func(t *testing.T) *rfc5322.EMail
+		params   string
+		to       []string
+		expected error
+	}{
+		{
+			name:   "plaintext",
+			server: &fakeServer{Extensions: []string{"SIZE 10240"}},
+			client: func(addr string) *smtp.Client {
+				return smtp.NewClient(addr).SetSecurity(smtp.Plaintext)
+			},
+			email:  func(t *testing.T) *rfc5322.EMail { return newTestEMail(t, "Hi!", "bob@example.com") },
+			params: "SIZE=",
+			to:     []string{"bob@example.com"},
+		},
+		{
+			name:   "STARTTLS with PLAIN",
+			server: &fakeServer{TLSConfig: serverTLS, Extensions: []string{"AUTH PLAIN LOGIN"}, Username: "alice", Password: "secret"},
+			client: func(addr string) *smtp.Client {
+				return smtp.NewClient(addr).SetTLSConfig(clientTLS).SetAuth(smtp.PlainAuth("", "alice", "secret"))
+			},
+			email: func(t *testing.T) *rfc5322.EMail { return newTestEMail(t, "Hi!", "bob@example.com") },
+			to:    []string{"bob@example.com"},
+		},
+		{
+			name:   "implicit TLS with LOGIN",
+			server: &fakeServer{TLSConfig: serverTLS, ImplicitTLS: true, Extensions: []string{"AUTH LOGIN"}, Username: "alice", Password: "secret"},
+			client: func(addr string) *smtp.Client {
+				return smtp.NewClient(addr).SetSecurity(smtp.ImplicitTLS).SetTLSConfig(clientTLS).SetAuth(smtp.LoginAuth("alice", "secret"))
+			},
+			email: func(t *testing.T) *rfc5322.EMail { return newTestEMail(t, "Hi!", "bob@example.com") },
+			to:    []string{"bob@example.com"},
+		},
+		{
+			name:   "CRAM-MD5",
+			server: &fakeServer{Extensions: []string{"AUTH CRAM-MD5"}, Username: "alice", Password: "secret"},
+			client: func(addr string) *smtp.Client {
+				return smtp.NewClient(addr).SetSecurity(smtp.Plaintext).SetAuth(smtp.CRAMMD5Auth("alice", "secret"))
+			},
+			email: func(t *testing.T) *rfc5322.EMail { return newTestEMail(t, "Hi!", "bob@example.com") },
+			to:    []string{"bob@example.com"},
+		},
+		{
+			name:   "XOAUTH2",
+			server: &fakeServer{TLSConfig: serverTLS, Extensions: []string{"AUTH XOAUTH2"}, Username: "alice", Password: "token"},
+			client: func(addr string) *smtp.Client {
+				return smtp.NewClient(addr).SetTLSConfig(clientTLS).SetAuth(smtp.XOAuth2Auth("alice", "token"))
+			},
+			email: func(t *testing.T) *rfc5322.EMail { return newTestEMail(t, "Hi!", "bob@example.com") },
+			to:    []string{"bob@example.com"},
+		},
+		{
+			name:   "XOAUTH2 with invalid token",
+			server: &fakeServer{TLSConfig: serverTLS, Extensions: []string{"AUTH XOAUTH2"}, Username: "alice", Password: "token"},
+			client: func(addr string) *smtp.Client {
+				return smtp.NewClient(addr).SetTLSConfig(clientTLS).SetAuth(smtp.XOAuth2Auth("alice", "expired"))
+			},
+			email:    func(t *testing.T) *rfc5322.EMail { return newTestEMail(t, "Hi!", "bob@example.com") },
+			expected: &smtp.Error{Code: 535, EnhancedCode: "5.7.8", Message: "Authentication credentials invalid"},
+		},
+		{
+			name:   "STARTTLS not supported",
+			server: &fakeServer{},
+			client: func(addr string) *smtp.Client {
+				return smtp.NewClient(addr)
+			},
+			email:    func(t *testing.T) *rfc5322.EMail { return newTestEMail(t, "Hi!", "bob@example.com") },
+			expected: smtp.ErrorStartTLSNotSupported,
+		},
+		{
+			name:   "8BITMIME",
+			server: &fakeServer{Extensions: []string{"8BITMIME"}},
+			client: func(addr string) *smtp.Client {
+				return smtp.NewClient(addr).SetSecurity(smtp.Plaintext)
+			},
+			email:  func(t *testing.T) *rfc5322.EMail { return newTestEMail(t, "こんにちは", "bob@example.com") },
+			params: "BODY=8BITMIME",
+			to:     []string{"bob@example.com"},
+		},
+		{
+			name:   "8BITMIME not supported",
+			server: &fakeServer{},
+			client: func(addr string) *smtp.Client {
+				return smtp.NewClient(addr).SetSecurity(smtp.Plaintext)
+			},
+			email:    func(t *testing.T) *rfc5322.EMail { return newTestEMail(t, "こんにちは", "bob@example.com") },
+			expected: smtp.Error8BitMIMENotSupported,
+		},
+		{
+			name:   "SMTPUTF8",
+			server: &fakeServer{Extensions: []string{"8BITMIME", "SMTPUTF8"}},
+			client: func(addr string) *smtp.Client {
+				return smtp.NewClient(addr).SetSecurity(smtp.Plaintext)
+			},
+			email:  func(t *testing.T) *rfc5322.EMail { return newTestEMail(t, "Hi!", "ボブ@example.com") },
+			params: "SMTPUTF8",
+			to:     []string{"ボブ@example.com"},
+		},
+		{
+			name:   "internationalized domain without SMTPUTF8",
+			server: &fakeServer{},
+			client: func(addr string) *smtp.Client {
+				return smtp.NewClient(addr).SetSecurity(smtp.Plaintext)
+			},
+			email: func(t *testing.T) *rfc5322.EMail { return newTestEMail(t, "Hi!", "bob@例え.jp") },
+			to:    []string{"bob@xn--r8jz45g.jp"},
+		},
+		{
+			name:   "internationalized local part without SMTPUTF8",
+			server: &fakeServer{},
+			client: func(addr string) *smtp.Client {
+				return smtp.NewClient(addr).SetSecurity(smtp.Plaintext)
+			},
+			email:    func(t *testing.T) *rfc5322.EMail { return newTestEMail(t, "Hi!", "ボブ@example.com") },
+			expected: smtp.ErrorSMTPUTF8NotSupported,
+		},
+		{
+			name:   "message too large",
+			server: &fakeServer{Extensions: []string{"SIZE 100"}},
+			client: func(addr string) *smtp.Client {
+				return smtp.NewClient(addr).SetSecurity(smtp.Plaintext)
+			},
+			email:    func(t *testing.T) *rfc5322.EMail { return newTestEMail(t, strings.Repeat("a", 100), "bob@example.com") },
+			expected: smtp.ErrorMessageTooLarge,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			addr := tc.server.start(t)
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			err := tc.client(addr).Send(ctx, tc.email(t))
+			if tc.to == nil {
+				assert.Error(t, err)
+				if tc.expected != nil {
+					assert.Equal(t, tc.expected, err)
+				}
+				assert.Empty(t, tc.server.Messages())
+				return
+			}
+			assert.NoError(t, err)
+			messages := tc.server.Messages()
+			if assert.Len(t, messages, 1) {
+				assert.Equal(t, "alice@example.com", messages[0].From)
+				assert.Equal(t, tc.to, messages[0].To)
+				assert.Contains(t, messages[0].Params, tc.params)
+				assert.Contains(t, messages[0].Data, "Subject: Hello\r\n")
+			}
+		})
+	}
+}
+
+func TestSendBcc(t *testing.T) {
+	server := &fakeServer{Extensions: []string{"PIPELINING"}}
+	addr := server.start(t)
+	email := newTestEMail(t, "Hi!", "bob@example.com")
+	carol, err := rfc5322.NewAddress("carol@example.com")
+	assert.NoError(t, err)
+	email.Header().AddBcc(*carol)
+
+	err = smtp.NewClient(addr).SetSecurity(smtp.Plaintext).Send(context.Background(), email)
+	assert.NoError(t, err)
+	messages := server.Messages()
+	if assert.Len(t, messages, 1) {
+		assert.Equal(t, []string{"bob@example.com", "carol@example.com"}, messages[0].To)
+		assert.NotContains(t, messages[0].Data, "carol@example.com")
+	}
+}
+
+func TestSendRejectedRecipient(t *testing.T) {
+	for _, pipelining := range []bool{true, false} {
+		server := &fakeServer{
+			RcptReply: func(rcpt string) string {
+				if rcpt == "nobody@example.com" {
+					return "550 5.1.1 No such user"
+				}
+				return ""
+			},
+		}
+		if pipelining {
+			server.Extensions = []string{"PIPELINING"}
+		}
+		addr := server.start(t)
+		client := smtp.NewClient(addr).SetSecurity(smtp.Plaintext)
+
+		err := client.Send(context.Background(), newTestEMail(t, "Hi!", "bob@example.com", "nobody@example.com"))
+		assert.Equal(t, &smtp.RecipientError{Rejected: []smtp.RecipientResult{
+			{Recipient: "nobody@example.com", Code: 550, EnhancedCode: "5.1.1", Message: "No such user"},
+		}}, err)
+		messages := server.Messages()
+		if assert.Len(t, messages, 1) {
+			assert.Equal(t, []string{"bob@example.com"}, messages[0].To)
+		}
+
+		err = client.Send(context.Background(), newTestEMail(t, "Hi!", "nobody@example.com"))
+		var rcptErr *smtp.RecipientError
+		assert.ErrorAs(t, err, &rcptErr)
+		assert.Len(t, server.Messages(), 1)
+	}
+}
+
+func TestSendDotStuffing(t *testing.T) {
+	server := &fakeServer{}
+	addr := server.start(t)
+	err := smtp.NewClient(addr).SetSecurity(smtp.Plaintext).Send(context.Background(), newTestEMail(t, "Hi!\r\n.\r\n.Bye", "bob@example.com"))
+	assert.NoError(t, err)
+	messages := server.Messages()
+	if assert.Len(t, messages, 1) {
+		assert.True(t, strings.HasSuffix(messages[0].Data, "\r\nHi!\r\n.\r\n.Bye\r\n"))
+	}
+}
+
+func TestSendCanceled(t *testing.T) {
+	server := &fakeServer{}
+	addr := server.start(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := smtp.NewClient(addr).SetSecurity(smtp.Plaintext).Send(ctx, newTestEMail(t, "Hi!", "bob@example.com"))
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,105 @@
+package smtp
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var ErrorStartTLSNotSupported = errors.New("server does not support STARTTLS")
+var ErrorAuthNotSupported = errors.New("server does not support authentication")
+var ErrorAuthMechanismNotSupported = errors.New("server does not support the authentication mechanism")
+var ErrorUnencryptedConnection = errors.New("unencrypted connection")
+var ErrorMessageTooLarge = errors.New("message exceeds the server size limit")
+var Error8BitMIMENotSupported = errors.New("server does not support 8BITMIME")
+var ErrorSMTPUTF8NotSupported = errors.New("server does not support SMTPUTF8")
+var ErrorInvalidAddr = errors.New("invalid server address")
+
+// Error represents an error reply from the SMTP server.
+type Error struct {
+	// Code is the three-digit reply code.
+	Code int
+	// EnhancedCode is the enhanced status code as per RFC 3463, if any.
+	EnhancedCode string
+	// Message is the text of the reply, without the enhanced status code.
+	Message string
+}
+
+// newError creates a new Error from a reply code and text.
+func newError(code int, msg string) *Error {
+	e := &Error{Code: code, Message: msg}
+	if i := strings.IndexByte(msg, ' '); i > 0 && isEnhancedCode(msg[:i]) {
+		e.EnhancedCode = msg[:i]
+		e.Message = msg[i+1:]
+	}
+	return e
+}
+
+// isEnhancedCode reports whether s has the form class.subject.detail.
+func isEnhancedCode(s string) bool {
+	parts := strings.Split(s, ".")
+	if len(parts) != 3 {
+		return false
+	}
+	if parts[0] != "2" && parts[0] != "4" && parts[0] != "5" {
+		return false
+	}
+	for _, p := range parts[1:] {
+		if n, err := strconv.Atoi(p); err != nil || n < 0 || n > 999 {
+			return false
+		}
+	}
+	return true
+}
+
+// Error returns the string representation of the Error.
+func (e *Error) Error() string {
+	if e.EnhancedCode != "" {
+		return fmt.Sprintf("smtp: %d %s %s", e.Code, e.EnhancedCode, e.Message)
+	}
+	return fmt.Sprintf("smtp: %d %s", e.Code, e.Message)
+}
+
+// Temporary reports whether the error is a transient (4xx) failure that may succeed if retried.
+func (e *Error) Temporary() bool {
+	return e.Code >= 400 && e.Code < 500
+}
+
+// RecipientResult represents the result of the delivery to a single recipient.
+type RecipientResult struct {
+	// Recipient is the forward-path given in the RCPT TO command.
+	Recipient string
+	// Code is the reply code for the recipient.
+	// It is the RCPT TO reply if the recipient was rejected, or the final reply to the message data otherwise.
+	Code int
+	// EnhancedCode is the enhanced status code of the reply, if any.
+	EnhancedCode string
+	// Message is the text of the reply.
+	Message string
+}
+
+// OK reports whether the message was accepted for the recipient.
+func (r RecipientResult) OK() bool {
+	return r.Code >= 200 && r.Code < 300
+}
+
+// Temporary reports whether the recipient failed with a transient (4xx) reply.
+func (r RecipientResult) Temporary() bool {
+	return r.Code >= 400 && r.Code < 500
+}
+
+// RecipientError is returned when the server rejects some of the recipients.
+// The message has been delivered to the recipients that are not listed in Rejected.
+type RecipientError struct {
+	Rejected []RecipientResult
+}
+
+// Error returns the string representation of the RecipientError.
+func (e *RecipientError) Error() string {
+	list := make([]string, 0, len(e.Rejected))
+	for _, r := range e.Rejected {
+		list = append(list, fmt.Sprintf("%s (%d %s)", r.Recipient, r.Code, r.Message))
+	}
+	return "smtp: recipients rejected: " + strings.Join(list, ", ")
+}
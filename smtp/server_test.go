@@ -0,0 +1,270 @@
+package smtp_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/hex"
+	"math/big"
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeMessage is a message received by the fakeServer.
+type fakeMessage struct {
+	From   string
+	Params string
+	To     []string
+	Data   string
+}
+
+// fakeServer is an in-process SMTP server for testing.
+type fakeServer struct {
+	// Extensions are advertised in reply to EHLO. STARTTLS is added when TLSConfig is set.
+	Extensions []string
+	// TLSConfig enables STARTTLS, or implicit TLS if ImplicitTLS is set.
+	TLSConfig   *tls.Config
+	ImplicitTLS bool
+	// Username and Password are the accepted credentials. The password is also the XOAUTH2 token.
+	Username string
+	Password string
+	// RcptReply returns the reply to a RCPT TO command, or an empty string to accept it.
+	RcptReply func(rcpt string) string
+
+	ln          net.Listener
+	mu          sync.Mutex
+	messages    []fakeMessage
+	connections int
+	wg          sync.WaitGroup
+}
+
+// start starts the server on a random local port and stops it at the end of the test.
+func (s *fakeServer) start(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.ImplicitTLS {
+		ln = tls.NewListener(ln, s.TLSConfig)
+	}
+	s.ln = ln
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.connections++
+			s.mu.Unlock()
+			s.wg.Add(1)
+			go func() {
+				defer s.wg.Done()
+				s.serve(conn)
+			}()
+		}
+	}()
+	t.Cleanup(func() {
+		ln.Close()
+	})
+	return ln.Addr().String()
+}
+
+// Messages returns the messages received so far.
+func (s *fakeServer) Messages() []fakeMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]fakeMessage(nil), s.messages...)
+}
+
+// Connections returns the number of accepted connections.
+func (s *fakeServer) Connections() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.connections
+}
+
+func (s *fakeServer) serve(conn net.Conn) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+	text := textproto.NewConn(conn)
+	isTLS := s.ImplicitTLS
+	var msg *fakeMessage
+	reply := func(format string, args ...any) {
+		text.PrintfLine(format, args...)
+	}
+
+	reply("220 fake.example.com ESMTP")
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO":
+			ext := append([]string(nil), s.Extensions...)
+			if s.TLSConfig != nil && !isTLS {
+				ext = append(ext, "STARTTLS")
+			}
+			lines := append([]string{"fake.example.com"}, ext...)
+			for i, l := range lines {
+				if i == len(lines)-1 {
+					reply("250 %s", l)
+				} else {
+					reply("250-%s", l)
+				}
+			}
+		case "HELO", "NOOP":
+			reply("250 OK")
+		case "STARTTLS":
+			if s.TLSConfig == nil || isTLS {
+				reply("502 5.5.1 Not supported")
+				continue
+			}
+			reply("220 2.0.0 Ready to start TLS")
+			tlsConn := tls.Server(conn, s.TLSConfig)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+			conn = tlsConn
+			text = textproto.NewConn(conn)
+			isTLS = true
+		case "AUTH":
+			if s.authenticate(text, arg) {
+				reply("235 2.7.0 Authentication successful")
+			} else {
+				reply("535 5.7.8 Authentication credentials invalid")
+			}
+		case "MAIL":
+			addr, params, _ := strings.Cut(strings.TrimPrefix(arg, "FROM:<"), ">")
+			msg = &fakeMessage{From: addr, Params: strings.TrimSpace(params)}
+			reply("250 2.1.0 OK")
+		case "RCPT":
+			if msg == nil {
+				reply("503 5.5.1 Need MAIL")
+				continue
+			}
+			addr := strings.TrimSuffix(strings.TrimPrefix(arg, "TO:<"), ">")
+			if s.RcptReply != nil {
+				if r := s.RcptReply(addr); r != "" {
+					reply("%s", r)
+					continue
+				}
+			}
+			msg.To = append(msg.To, addr)
+			reply("250 2.1.5 OK")
+		case "DATA":
+			if msg == nil || len(msg.To) == 0 {
+				reply("554 5.5.1 No valid recipients")
+				continue
+			}
+			reply("354 Start mail input")
+			lines := make([]string, 0)
+			for {
+				l, err := text.ReadLine()
+				if err != nil {
+					return
+				}
+				if l == "." {
+					break
+				}
+				lines = append(lines, strings.TrimPrefix(l, "."))
+			}
+			msg.Data = strings.Join(lines, "\r\n") + "\r\n"
+			s.mu.Lock()
+			s.messages = append(s.messages, *msg)
+			s.mu.Unlock()
+			msg = nil
+			reply("250 2.0.0 OK queued")
+		case "RSET":
+			msg = nil
+			reply("250 2.0.0 OK")
+		case "QUIT":
+			reply("221 2.0.0 Bye")
+			return
+		default:
+			reply("500 5.5.2 Unknown command")
+		}
+	}
+}
+
+func (s *fakeServer) authenticate(text *textproto.Conn, arg string) bool {
+	mechanism, initial, _ := strings.Cut(arg, " ")
+	challenge := func(c string) string {
+		text.PrintfLine("334 %s", base64.StdEncoding.EncodeToString([]byte(c)))
+		line, _ := text.ReadLine()
+		b, _ := base64.StdEncoding.DecodeString(line)
+		return string(b)
+	}
+	decode := func(s string) string {
+		b, _ := base64.StdEncoding.DecodeString(s)
+		return string(b)
+	}
+	switch strings.ToUpper(mechanism) {
+	case "PLAIN":
+		return decode(initial) == "\x00"+s.Username+"\x00"+s.Password
+	case "LOGIN":
+		return challenge("Username:") == s.Username && challenge("Password:") == s.Password
+	case "CRAM-MD5":
+		c := "<1896.697170952@fake.example.com>"
+		mac := hmac.New(md5.New, []byte(s.Password))
+		mac.Write([]byte(c))
+		return challenge(c) == s.Username+" "+hex.EncodeToString(mac.Sum(nil))
+	case "XOAUTH2":
+		if decode(initial) == "user="+s.Username+"\x01auth=Bearer "+s.Password+"\x01\x01" {
+			return true
+		}
+		challenge(`{"status":"401"}`)
+		return false
+	}
+	return false
+}
+
+// newTLSConfigs returns a server configuration with a self-signed certificate for 127.0.0.1,
+// and a client configuration that trusts it.
+func newTLSConfigs(t *testing.T) (server *tls.Config, client *tls.Config) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "fake.example.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		IsCA:         true,
+
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	server = &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}}
+	client = &tls.Config{RootCAs: pool}
+	return
+}
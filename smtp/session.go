@@ -0,0 +1,436 @@
+package smtp
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/textproto"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aethiopicuschan/rfc5322-go"
+	"golang.org/x/net/idna"
+)
+
+// session represents a single connection to an SMTP server.
+type session struct {
+	conn net.Conn
+	text *textproto.Conn
+	host string
+	tls  bool
+	ext  map[string]string
+	auth []string
+	stop func() bool
+}
+
+// newSession creates a new session on an established connection.
+func newSession(conn net.Conn, host string, isTLS bool) *session {
+	return &session{
+		conn: conn,
+		text: textproto.NewConn(conn),
+		host: host,
+		tls:  isTLS,
+		ext:  make(map[string]string),
+	}
+}
+
+// watch makes blocking operations on the session return when the context is done.
+func (s *session) watch(ctx context.Context) {
+	s.unwatch()
+	if deadline, ok := ctx.Deadline(); ok {
+		s.conn.SetDeadline(deadline)
+	} else {
+		s.conn.SetDeadline(time.Time{})
+	}
+	s.stop = context.AfterFunc(ctx, func() {
+		// Unblock any pending read or write.
+		s.conn.SetDeadline(time.Unix(1, 0))
+	})
+}
+
+// unwatch stops watching the context given to watch.
+func (s *session) unwatch() {
+	if s.stop != nil {
+		s.stop()
+		s.stop = nil
+	}
+}
+
+// readReply reads a reply from the server and converts a mismatching reply into an Error.
+func (s *session) readReply(expect int) (code int, msg string, err error) {
+	code, msg, err = s.text.ReadResponse(expect)
+	var tpErr *textproto.Error
+	if errors.As(err, &tpErr) {
+		err = newError(tpErr.Code, tpErr.Msg)
+	}
+	return
+}
+
+// cmd sends a command and reads the reply.
+func (s *session) cmd(expect int, format string, args ...any) (code int, msg string, err error) {
+	if err = s.text.PrintfLine(format, args...); err != nil {
+		return
+	}
+	return s.readReply(expect)
+}
+
+// has reports whether the server advertised the extension.
+func (s *session) has(ext string) bool {
+	_, ok := s.ext[ext]
+	return ok
+}
+
+// greet reads the greeting and introduces the client with EHLO, falling back to HELO.
+func (s *session) greet(localName string) (err error) {
+	if _, _, err = s.readReply(220); err != nil {
+		return
+	}
+	return s.hello(localName)
+}
+
+// hello sends EHLO (or HELO for servers without ESMTP) and records the advertised extensions.
+func (s *session) hello(localName string) (err error) {
+	s.ext = make(map[string]string)
+	s.auth = nil
+	_, msg, err := s.cmd(250, "EHLO %s", localName)
+	if err != nil {
+		var smtpErr *Error
+		if errors.As(err, &smtpErr) && smtpErr.Code >= 500 {
+			_, _, err = s.cmd(250, "HELO %s", localName)
+		}
+		return
+	}
+	lines := strings.Split(msg, "\n")
+	for _, line := range lines[1:] {
+		keyword, param, _ := strings.Cut(line, " ")
+		keyword = strings.ToUpper(keyword)
+		s.ext[keyword] = param
+		if keyword == "AUTH" {
+			s.auth = strings.Fields(param)
+		}
+	}
+	return
+}
+
+// startTLS upgrades the connection with the STARTTLS command as per RFC 3207.
+func (s *session) startTLS(config *tls.Config, localName string) (err error) {
+	if !s.has("STARTTLS") {
+		return ErrorStartTLSNotSupported
+	}
+	if _, _, err = s.cmd(220, "STARTTLS"); err != nil {
+		return
+	}
+	conn := tls.Client(s.conn, config)
+	if err = conn.Handshake(); err != nil {
+		return
+	}
+	s.conn = conn
+	s.text = textproto.NewConn(conn)
+	s.tls = true
+	// The client must discard the knowledge obtained before the TLS negotiation.
+	return s.hello(localName)
+}
+
+// authenticate performs the SMTP authentication as per RFC 4954.
+func (s *session) authenticate(a Auth) (err error) {
+	if !s.has("AUTH") {
+		return ErrorAuthNotSupported
+	}
+	mechanism, resp, err := a.Start(&ServerInfo{Name: s.host, TLS: s.tls, Auth: s.auth})
+	if err != nil {
+		return
+	}
+	if !slices.ContainsFunc(s.auth, func(m string) bool { return strings.EqualFold(m, mechanism) }) {
+		return ErrorAuthMechanismNotSupported
+	}
+	line := "AUTH " + mechanism
+	if resp != nil {
+		encoded := base64.StdEncoding.EncodeToString(resp)
+		if encoded == "" {
+			encoded = "="
+		}
+		line += " " + encoded
+	}
+	code, msg, err := s.cmd(0, "%s", line)
+	for err == nil {
+		switch code {
+		case 235:
+			_, err = a.Next([]byte(msg), false)
+			return
+		case 334:
+			var challenge []byte
+			challenge, err = base64.StdEncoding.DecodeString(msg)
+			if err != nil {
+				// Cancel the authentication exchange.
+				s.cmd(0, "*")
+				return
+			}
+			resp, err = a.Next(challenge, true)
+			if err != nil {
+				s.cmd(0, "*")
+				return
+			}
+			code, msg, err = s.cmd(0, "%s", base64.StdEncoding.EncodeToString(resp))
+		default:
+			err = newError(code, msg)
+		}
+	}
+	return
+}
+
+// prepare returns the envelope addresses and MAIL FROM parameters suitable for the server.
+func (s *session) prepare(env rfc5322.Envelope, data []byte) (from string, to []string, params string, err error) {
+	smtputf8 := false
+	convert := func(addr string) (string, error) {
+		if isASCII(addr) {
+			return addr, nil
+		}
+		if s.has("SMTPUTF8") {
+			smtputf8 = true
+			return addr, nil
+		}
+		// Without SMTPUTF8, only an internationalized domain can be sent as an A-label.
+		i := strings.LastIndex(addr, "@")
+		if i < 0 || !isASCII(addr[:i]) {
+			return "", ErrorSMTPUTF8NotSupported
+		}
+		domain, err := idna.Lookup.ToASCII(addr[i+1:])
+		if err != nil {
+			return "", err
+		}
+		return addr[:i+1] + domain, nil
+	}
+
+	if from, err = convert(env.From); err != nil {
+		return
+	}
+	for _, rcpt := range env.To {
+		var addr string
+		if addr, err = convert(rcpt); err != nil {
+			return
+		}
+		to = append(to, addr)
+	}
+
+	header, _, _ := strings.Cut(string(data), "\r\n\r\n")
+	if !isASCII(header) {
+		if !s.has("SMTPUTF8") {
+			err = ErrorSMTPUTF8NotSupported
+			return
+		}
+		smtputf8 = true
+	}
+	if size, ok := s.ext["SIZE"]; ok {
+		if limit, _ := strconv.Atoi(size); limit > 0 && len(data) > limit {
+			err = ErrorMessageTooLarge
+			return
+		}
+		params += fmt.Sprintf(" SIZE=%d", len(data))
+	}
+	if !isASCII(string(data)) {
+		if !s.has("8BITMIME") {
+			err = Error8BitMIMENotSupported
+			return
+		}
+		params += " BODY=8BITMIME"
+	}
+	if smtputf8 {
+		params += " SMTPUTF8"
+	}
+	return
+}
+
+// send performs a mail transaction and returns the result for each recipient.
+// The error is non-nil if the message was not delivered to any recipient because of
+// a MAIL FROM or DATA failure, or because of a connection failure.
+func (s *session) send(env rfc5322.Envelope, data []byte) (results []RecipientResult, err error) {
+	from, to, params, err := s.prepare(env, data)
+	if err != nil {
+		return
+	}
+	results = make([]RecipientResult, len(to))
+	for i, rcpt := range to {
+		results[i].Recipient = rcpt
+	}
+	set := func(i int, reply *Error) {
+		results[i].Code = reply.Code
+		results[i].EnhancedCode = reply.EnhancedCode
+		results[i].Message = reply.Message
+	}
+	// fail records the reply that failed the whole transaction for every recipient not rejected yet.
+	fail := func(e error) {
+		var smtpErr *Error
+		if errors.As(e, &smtpErr) {
+			for i := range results {
+				if results[i].OK() || results[i].Code == 0 {
+					set(i, smtpErr)
+				}
+			}
+		}
+		err = e
+	}
+	record := func(i int, code int, msg string, e error) {
+		var smtpErr *Error
+		if !errors.As(e, &smtpErr) {
+			smtpErr = newError(code, msg)
+		}
+		set(i, smtpErr)
+	}
+
+	accepted := 0
+	var dataErr error
+	if s.has("PIPELINING") {
+		// As per RFC 2920, the whole transaction up to DATA is sent without waiting for the replies.
+		if err = s.text.PrintfLine("MAIL FROM:<%s>%s", from, params); err != nil {
+			return
+		}
+		for _, rcpt := range to {
+			if err = s.text.PrintfLine("RCPT TO:<%s>", rcpt); err != nil {
+				return
+			}
+		}
+		if err = s.text.PrintfLine("DATA"); err != nil {
+			return
+		}
+		_, _, mailErr := s.readReply(25)
+		for i := range to {
+			code, msg, e := s.readReply(25)
+			if e != nil && !isReplyError(e) {
+				err = e
+				return
+			}
+			record(i, code, msg, e)
+			if e == nil {
+				accepted++
+			}
+		}
+		_, _, dataErr = s.readReply(354)
+		if dataErr != nil && !isReplyError(dataErr) {
+			err = dataErr
+			return
+		}
+		if mailErr != nil {
+			if !isReplyError(mailErr) {
+				err = mailErr
+				return
+			}
+			for i := range results {
+				results[i].Code = 0
+			}
+			fail(mailErr)
+			if dataErr == nil {
+				// Abort the unexpected data phase with an empty message.
+				s.abortData()
+			}
+			return
+		}
+		if accepted == 0 {
+			if dataErr == nil {
+				s.abortData()
+			}
+			s.reset()
+			return
+		}
+	} else {
+		if _, _, err = s.cmd(25, "MAIL FROM:<%s>%s", from, params); err != nil {
+			if isReplyError(err) {
+				fail(err)
+			}
+			return
+		}
+		for i, rcpt := range to {
+			code, msg, e := s.cmd(25, "RCPT TO:<%s>", rcpt)
+			if e != nil && !isReplyError(e) {
+				err = e
+				return
+			}
+			record(i, code, msg, e)
+			if e == nil {
+				accepted++
+			}
+		}
+		if accepted == 0 {
+			s.reset()
+			return
+		}
+		_, _, dataErr = s.cmd(354, "DATA")
+	}
+	if dataErr != nil {
+		if isReplyError(dataErr) {
+			fail(dataErr)
+			s.reset()
+		} else {
+			err = dataErr
+		}
+		return
+	}
+
+	w := s.text.DotWriter()
+	if _, err = w.Write(data); err != nil {
+		return
+	}
+	if err = w.Close(); err != nil {
+		return
+	}
+	code, msg, e := s.readReply(25)
+	if e != nil {
+		if isReplyError(e) {
+			fail(e)
+		} else {
+			err = e
+		}
+		return
+	}
+	for i := range results {
+		if results[i].OK() {
+			set(i, newError(code, msg))
+		}
+	}
+	return
+}
+
+// abortData ends a data phase that should not have been entered by sending an empty message.
+func (s *session) abortData() {
+	if err := s.text.PrintfLine("."); err == nil {
+		s.readReply(0)
+	}
+}
+
+// reset aborts the current mail transaction.
+func (s *session) reset() (err error) {
+	_, _, err = s.cmd(250, "RSET")
+	return
+}
+
+// quit ends the session politely.
+func (s *session) quit() (err error) {
+	_, _, err = s.cmd(221, "QUIT")
+	return
+}
+
+// close closes the underlying connection.
+func (s *session) close() error {
+	s.unwatch()
+	return s.text.Close()
+}
+
+// isReplyError reports whether the error is an error reply from the server.
+func isReplyError(err error) bool {
+	var smtpErr *Error
+	return errors.As(err, &smtpErr)
+}
+
+// isASCII reports whether s only contains US-ASCII characters.
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,237 @@
+package rfc5322
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	htmltemplate "html/template"
+	"mime"
+	"strings"
+	texttemplate "text/template"
+)
+
+// templateFile is a file that templates can reference by name.
+type templateFile struct {
+	name        string
+	contentType string
+	data        []byte
+}
+
+// Template renders an EMail from subject, text and HTML templates and data.
+// The text and HTML templates can reference the files added to the Template by name:
+// {{inline "logo.png"}} returns the cid URL of an inline file and {{attach "invoice.pdf"}} attaches a file and returns its name.
+// Only the referenced files are included in the rendered EMail.
+type Template struct {
+	subject *texttemplate.Template
+	text    *texttemplate.Template
+	html    *htmltemplate.Template
+	files   []templateFile
+}
+
+// NewTemplate creates a new Template with the templates, where an empty text or HTML template omits the part.
+// It returns ErrorInvalidTemplate if both the text and the HTML templates are empty.
+func NewTemplate(subject, text, html string) (t *Template, err error) {
+	return NewTemplateWithFuncs(nil, subject, text, html)
+}
+
+// NewTemplateWithFuncs creates a new Template with additional functions, such as for translation.
+func NewTemplateWithFuncs(funcs map[string]any, subject, text, html string) (t *Template, err error) {
+	if text == "" && html == "" {
+		err = ErrorInvalidTemplate
+		return
+	}
+	// The file functions are replaced by each rendering, and are defined only for parsing.
+	files := map[string]any{
+		"inline": func(string) (htmltemplate.URL, error) { return "", nil },
+		"attach": func(string) (string, error) { return "", nil },
+	}
+	t = &Template{}
+	if t.subject, err = texttemplate.New("subject").Funcs(funcs).Parse(subject); err != nil {
+		return nil, err
+	}
+	if text != "" {
+		if t.text, err = texttemplate.New("text").Funcs(files).Funcs(funcs).Parse(text); err != nil {
+			return nil, err
+		}
+	}
+	if html != "" {
+		if t.html, err = htmltemplate.New("html").Funcs(files).Funcs(funcs).Parse(html); err != nil {
+			return nil, err
+		}
+	}
+	return
+}
+
+// AddFile adds a file that the templates can reference by name, replacing a file with the same name.
+func (t *Template) AddFile(name, contentType string, data []byte) *Template {
+	for i, f := range t.files {
+		if f.name == name {
+			t.files[i] = templateFile{name, contentType, data}
+			return t
+		}
+	}
+	t.files = append(t.files, templateFile{name, contentType, data})
+	return t
+}
+
+// file returns the file with the name.
+func (t *Template) file(name string) (f templateFile, err error) {
+	for _, f = range t.files {
+		if f.name == name {
+			return
+		}
+	}
+	err = ErrorUnknownTemplateFile
+	return
+}
+
+// templateRendering is the state of a rendering, with the referenced files in order of reference.
+type templateRendering struct {
+	t           *Template
+	inlines     []templateFile
+	contentIDs  map[string]string
+	attachments []templateFile
+	attached    map[string]bool
+}
+
+// inline returns the cid URL of the inline file, adding it to the rendering.
+// The URL is trusted by html/template, which would otherwise reject the cid scheme.
+func (r *templateRendering) inline(name string) (htmltemplate.URL, error) {
+	if id, ok := r.contentIDs[name]; ok {
+		return htmltemplate.URL("cid:" + id), nil
+	}
+	f, err := r.t.file(name)
+	if err != nil {
+		return "", err
+	}
+	b := make([]byte, 16)
+	if _, err = rand.Read(b); err != nil {
+		return "", err
+	}
+	id := hex.EncodeToString(b) + "@rfc5322-go"
+	r.contentIDs[name] = id
+	r.inlines = append(r.inlines, f)
+	return htmltemplate.URL("cid:" + id), nil
+}
+
+// attach returns the name of the file, adding it to the attachments of the rendering.
+func (r *templateRendering) attach(name string) (string, error) {
+	f, err := r.t.file(name)
+	if err != nil {
+		return "", err
+	}
+	if !r.attached[name] {
+		r.attached[name] = true
+		r.attachments = append(r.attachments, f)
+	}
+	return name, nil
+}
+
+// Render renders the templates with the data into an EMail with a copy of the Header, whose Subject is set to the rendered subject.
+// The whitespace of the rendered subject, including line breaks, is collapsed into single spaces.
+// The Body is multipart/alternative if there are both text and HTML parts, with the HTML part in a multipart/related Body
+// if it references inline files, and in a multipart/mixed Body if the templates attach files.
+func (t *Template) Render(header *Header, data any) (e *EMail, err error) {
+	r := &templateRendering{
+		t:          t,
+		contentIDs: map[string]string{},
+		attached:   map[string]bool{},
+	}
+	files := map[string]any{
+		"inline": r.inline,
+		"attach": r.attach,
+	}
+
+	var buf bytes.Buffer
+	if err = t.subject.Execute(&buf, data); err != nil {
+		return
+	}
+	subject := strings.Join(strings.Fields(buf.String()), " ")
+
+	var alternatives []*Body
+	if t.text != nil {
+		var text *texttemplate.Template
+		if text, err = t.text.Clone(); err != nil {
+			return
+		}
+		buf.Reset()
+		if err = text.Funcs(files).Execute(&buf, data); err != nil {
+			return
+		}
+		part := NewBody()
+		part.setHeader("Content-Type", "text/plain; charset=UTF-8")
+		part.content = normalizeLineEndings(bytes.Clone(buf.Bytes()))
+		alternatives = append(alternatives, part)
+	}
+	if t.html != nil {
+		var html *htmltemplate.Template
+		if html, err = t.html.Clone(); err != nil {
+			return
+		}
+		buf.Reset()
+		if err = html.Funcs(files).Execute(&buf, data); err != nil {
+			return
+		}
+		part := NewBody()
+		part.setHeader("Content-Type", "text/html; charset=UTF-8")
+		part.content = normalizeLineEndings(bytes.Clone(buf.Bytes()))
+		if len(r.inlines) > 0 {
+			related := newMultipart("multipart/related", map[string]string{"type": "text/html"})
+			related.parts = append(related.parts, part)
+			for _, f := range r.inlines {
+				inline := newFilePart("inline", f)
+				inline.setHeader("Content-ID", "<"+r.contentIDs[f.name]+">")
+				related.parts = append(related.parts, inline)
+			}
+			part = related
+		}
+		alternatives = append(alternatives, part)
+	}
+
+	body := alternatives[0]
+	if len(alternatives) > 1 {
+		body = newMultipart("multipart/alternative", nil)
+		body.parts = alternatives
+	}
+	if len(r.attachments) > 0 {
+		mixed := newMultipart("multipart/mixed", nil)
+		mixed.parts = append(mixed.parts, body)
+		for _, f := range r.attachments {
+			mixed.parts = append(mixed.parts, newFilePart("attachment", f))
+		}
+		body = mixed
+	}
+
+	h := header.Clone()
+	h.SetSubject(subject)
+	e = NewEMail(h, body.sevenBit())
+	return
+}
+
+// newMultipart returns a multipart Body of the media type with a random boundary and the parameters.
+func newMultipart(mediaType string, params map[string]string) *Body {
+	p := map[string]string{"boundary": randomBoundary()}
+	for k, v := range params {
+		p[k] = v
+	}
+	b := NewBody()
+	b.setHeader("Content-Type", mime.FormatMediaType(mediaType, p))
+	return b
+}
+
+// newFilePart returns a base64 encoded Body of the file with the disposition.
+// An invalid content type is replaced with application/octet-stream.
+func newFilePart(disposition string, f templateFile) *Body {
+	b := NewBody()
+	mediaType, params, err := mime.ParseMediaType(f.contentType)
+	if err != nil {
+		mediaType, params = "application/octet-stream", map[string]string{}
+	}
+	params["name"] = f.name
+	contentType := mime.FormatMediaType(mediaType, params)
+	b.setHeader("Content-Type", contentType)
+	b.setHeader("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": f.name}))
+	b.setHeader("Content-Transfer-Encoding", "base64")
+	b.content = base64Lines(f.data)
+	return b
+}
@@ -0,0 +1,64 @@
+package rfc5322_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aethiopicuschan/rfc5322-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTemplate(t *testing.T) {
+	tmpl, err := rfc5322.NewTemplateWithFuncs(
+		map[string]any{"upper": strings.ToUpper},
+		"Hello {{.Name}}\n",
+		"Hello {{upper .Name}}, see {{attach \"invoice.pdf\"}}.\n",
+		`<p>Hello {{.Name}}</p><img src="{{inline "logo.png"}}"><img src="{{inline "logo.png"}}">`,
+	)
+	assert.NoError(t, err)
+	tmpl.AddFile("logo.png", "image/png", []byte("PNG")).
+		AddFile("invoice.pdf", "application/pdf", []byte("PDF")).
+		AddFile("unused.txt", "text/plain", []byte("unused"))
+
+	header := newTestHeader(t).AddTo(mustAddress(t, "bob@example.com"))
+	email, err := tmpl.Render(header, map[string]string{"Name": "<Bob & Café>"})
+	assert.NoError(t, err)
+	assert.True(t, header.Subject().IsNone())
+	assert.Equal(t, "Hello <Bob & Café>", email.Header().Subject().Unwrap())
+
+	s, err := email.String()
+	assert.NoError(t, err)
+	assert.Contains(t, s, "Content-Type: multipart/mixed; boundary=")
+	assert.Contains(t, s, "Content-Type: multipart/alternative; boundary=")
+	assert.Contains(t, s, "Content-Type: multipart/related; boundary=")
+	assert.Contains(t, s, `Content-Disposition: attachment; filename=invoice.pdf`)
+	assert.Contains(t, s, `Content-Disposition: inline; filename=logo.png`)
+	assert.NotContains(t, s, "unused.txt")
+	assert.Equal(t, 1, strings.Count(s, "Content-ID:"))
+	assert.Less(t, strings.Index(s, "text/plain"), strings.Index(s, "text/html"))
+
+	parsed, err := rfc5322.Parse(strings.NewReader(s))
+	assert.NoError(t, err)
+	mixed := parsed.Body().String()
+	assert.Contains(t, mixed, "Hello <BOB & CAF=C3=89>, see invoice.pdf.")
+	assert.Contains(t, mixed, "&lt;Bob &amp; Caf=C3=A9&gt;")
+	assert.Contains(t, mixed, `src=3D"cid:`)
+}
+
+func TestTemplateErrors(t *testing.T) {
+	_, err := rfc5322.NewTemplate("Subject", "", "")
+	assert.ErrorIs(t, err, rfc5322.ErrorInvalidTemplate)
+	_, err = rfc5322.NewTemplate("Subject", "{{.Name", "")
+	assert.Error(t, err)
+
+	tmpl, err := rfc5322.NewTemplate("Subject", `{{attach "missing.pdf"}}`, "")
+	assert.NoError(t, err)
+	_, err = tmpl.Render(newTestHeader(t), nil)
+	assert.ErrorIs(t, err, rfc5322.ErrorUnknownTemplateFile)
+
+	tmpl, err = rfc5322.NewTemplate("Subject", "Text only", "")
+	assert.NoError(t, err)
+	email, err := tmpl.Render(newTestHeader(t), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "text/plain", email.Body().ContentType())
+}
@@ -0,0 +1,49 @@
+package rfc5322
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"mime/quotedprintable"
+	"strings"
+)
+
+// decodedContent returns the content of the Body decoded with its Content-Transfer-Encoding.
+func (b *Body) decodedContent() (content []byte, err error) {
+	cte, _ := b.header("Content-Transfer-Encoding")
+	switch strings.ToLower(strings.TrimSpace(cte)) {
+	case "base64":
+		clean := bytes.Map(func(r rune) rune {
+			if r == ' ' || r == '\t' || r == '\r' || r == '\n' {
+				return -1
+			}
+			return r
+		}, b.content)
+		return base64.StdEncoding.DecodeString(string(clean))
+	case "quoted-printable":
+		return io.ReadAll(quotedprintable.NewReader(bytes.NewReader(b.content)))
+	}
+	return b.content, nil
+}
+
+// base64Lines encodes the data in base64 with lines of 76 characters, as per RFC 2045 section 6.8.
+func base64Lines(data []byte) []byte {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	var buf bytes.Buffer
+	for len(encoded) > 76 {
+		buf.WriteString(encoded[:76])
+		buf.WriteString("\r\n")
+		encoded = encoded[76:]
+	}
+	buf.WriteString(encoded)
+	return buf.Bytes()
+}
+
+// randomBoundary returns a new boundary that does not collide with the boundaries of nested parts.
+func randomBoundary() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return "BOUNDARY-" + hex.EncodeToString(b)
+}
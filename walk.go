@@ -0,0 +1,256 @@
+package rfc5322
+
+import (
+	"iter"
+	"mime"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// BodyPath is the position of a part in the tree of a Body, as the zero-based indexes of the parts from the root.
+type BodyPath []int
+
+// String returns the IMAP section number of the path, such as "1.2" for the second part of the first part,
+// or an empty string for the root.
+func (p BodyPath) String() string {
+	sections := make([]string, 0, len(p))
+	for _, i := range p {
+		sections = append(sections, strconv.Itoa(i+1))
+	}
+	return strings.Join(sections, ".")
+}
+
+// Parts returns the parts of a multipart Body.
+func (b *Body) Parts() []*Body {
+	return slices.Clone(b.parts)
+}
+
+// Content returns the content of the Body as is, encoded with its Content-Transfer-Encoding.
+func (b *Body) Content() []byte {
+	return b.content
+}
+
+// DecodedContent returns the content of the Body decoded with its Content-Transfer-Encoding.
+func (b *Body) DecodedContent() ([]byte, error) {
+	return b.decodedContent()
+}
+
+// Fields returns an iterator over the header fields of the Body in order.
+func (b *Body) Fields() iter.Seq2[string, string] {
+	return func(yield func(string, string) bool) {
+		for _, f := range b.headers {
+			if !yield(f.name, f.value) {
+				return
+			}
+		}
+	}
+}
+
+// Disposition returns the lowercase disposition type of the Content-Disposition field, or an empty string if it is not set.
+func (b *Body) Disposition() string {
+	cd, ok := b.header("Content-Disposition")
+	if !ok {
+		return ""
+	}
+	disposition, _, err := mime.ParseMediaType(cd)
+	if err != nil {
+		disposition, _, _ = strings.Cut(cd, ";")
+		return strings.ToLower(strings.TrimSpace(disposition))
+	}
+	return disposition
+}
+
+// Filename returns the filename parameter of the Content-Disposition field, or the name parameter of the Content-Type field.
+// Encoded-words are decoded, and it returns an empty string if neither is set.
+func (b *Body) Filename() string {
+	var name string
+	if cd, ok := b.header("Content-Disposition"); ok {
+		if _, params, err := mime.ParseMediaType(cd); err == nil {
+			name = params["filename"]
+		}
+	}
+	if name == "" {
+		_, params := b.mediaType()
+		name = params["name"]
+	}
+	return decodeText(name)
+}
+
+// ContentID returns the Content-ID of the Body without angle brackets, or an empty string if it is not set.
+func (b *Body) ContentID() string {
+	id, _ := b.header("Content-ID")
+	return strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(id), "<"), ">")
+}
+
+// Walk returns an iterator over the Body and its parts with their paths, in depth-first order.
+func (b *Body) Walk() iter.Seq2[BodyPath, *Body] {
+	return func(yield func(BodyPath, *Body) bool) {
+		b.walk(BodyPath{}, yield)
+	}
+}
+
+// walk yields the Body and its parts, and reports whether the walk continues.
+func (b *Body) walk(path BodyPath, yield func(BodyPath, *Body) bool) bool {
+	if !yield(path, b) {
+		return false
+	}
+	for i, part := range b.parts {
+		if !part.walk(append(slices.Clip(path), i), yield) {
+			return false
+		}
+	}
+	return true
+}
+
+// Part returns the part at the path, which is the Body itself for an empty path.
+func (b *Body) Part(path BodyPath) (part *Body, ok bool) {
+	part = b
+	for _, i := range path {
+		if i < 0 || i >= len(part.parts) {
+			return nil, false
+		}
+		part = part.parts[i]
+	}
+	return part, true
+}
+
+// FindAll returns the parts of the media type in the tree of the Body, in depth-first order.
+// A media type of the form "image/*" matches all subtypes.
+func (b *Body) FindAll(mediaType string) (parts []*Body) {
+	mediaType = strings.ToLower(mediaType)
+	prefix, wildcard := strings.CutSuffix(mediaType, "*")
+	for _, part := range b.Walk() {
+		ct := part.ContentType()
+		if ct == mediaType || wildcard && strings.HasPrefix(ct, prefix) {
+			parts = append(parts, part)
+		}
+	}
+	return
+}
+
+// TextBody returns the text/plain part that is the body of the message, if any.
+// See findBody for how the part is chosen.
+func (b *Body) TextBody() (*Body, bool) {
+	return b.findBody("text/plain")
+}
+
+// HTMLBody returns the text/html part that is the body of the message, if any.
+// See findBody for how the part is chosen.
+func (b *Body) HTMLBody() (*Body, bool) {
+	return b.findBody("text/html")
+}
+
+// findBody returns the body part of the media type, which is not an attachment.
+// The last matching alternative of multipart/alternative is preferred, only the root of multipart/related and
+// the signed part of multipart/signed are searched, and encrypted content is not searched.
+// The parts of the other multipart types are searched in order.
+func (b *Body) findBody(mediaType string) (part *Body, ok bool) {
+	if b.Disposition() == "attachment" {
+		return
+	}
+	switch ct := b.ContentType(); ct {
+	case mediaType:
+		return b, true
+	case "multipart/alternative":
+		for _, p := range slices.Backward(b.parts) {
+			if part, ok = p.findBody(mediaType); ok {
+				return
+			}
+		}
+	case "multipart/related":
+		if root := b.relatedRoot(); root != nil {
+			return root.findBody(mediaType)
+		}
+	case "multipart/signed":
+		if len(b.parts) > 0 {
+			return b.parts[0].findBody(mediaType)
+		}
+	case "multipart/encrypted":
+	default:
+		for _, p := range b.parts {
+			if part, ok = p.findBody(mediaType); ok {
+				return
+			}
+		}
+	}
+	return
+}
+
+// relatedRoot returns the root part of a multipart/related Body, which is the part of its start parameter or the first part,
+// as per RFC 2387.
+func (b *Body) relatedRoot() *Body {
+	if len(b.parts) == 0 {
+		return nil
+	}
+	_, params := b.mediaType()
+	if start := strings.Trim(params["start"], "<>"); start != "" {
+		for _, part := range b.parts {
+			if part.ContentID() == start {
+				return part
+			}
+		}
+	}
+	return b.parts[0]
+}
+
+// Attachments returns the attachments in the tree of the Body, in depth-first order.
+// An attachment is a part with the attachment disposition, or a part without disposition that is neither text nor referenced,
+// such as a PDF document. The parts of multipart/encrypted and the signature of multipart/signed are not included.
+func (b *Body) Attachments() []*Body {
+	attachments, _ := b.classifyParts()
+	return attachments
+}
+
+// Inlines returns the inline resources in the tree of the Body, in depth-first order.
+// An inline resource is a non-root part of multipart/related, or a part with the inline disposition
+// and a filename or a Content-ID, or a part without disposition with a Content-ID, such as an embedded image.
+func (b *Body) Inlines() []*Body {
+	_, inlines := b.classifyParts()
+	return inlines
+}
+
+// classifyParts returns the attachments and the inline resources in the tree of the Body.
+func (b *Body) classifyParts() (attachments, inlines []*Body) {
+	var classify func(part *Body, resource bool)
+	classify = func(part *Body, resource bool) {
+		if part.IsMultipart() {
+			switch part.ContentType() {
+			case "multipart/encrypted":
+			case "multipart/signed":
+				// The second part is the signature, not an attachment.
+				if len(part.parts) > 0 {
+					classify(part.parts[0], false)
+				}
+			case "multipart/related":
+				root := part.relatedRoot()
+				for _, p := range part.parts {
+					classify(p, p != root)
+				}
+			default:
+				for _, p := range part.parts {
+					classify(p, false)
+				}
+			}
+			return
+		}
+		disposition := part.Disposition()
+		text := part.ContentType() == "text/plain" || part.ContentType() == "text/html"
+		switch {
+		case disposition == "attachment":
+			attachments = append(attachments, part)
+		case resource:
+			inlines = append(inlines, part)
+		case disposition == "inline":
+			if part.Filename() != "" || part.ContentID() != "" || !text {
+				inlines = append(inlines, part)
+			}
+		case part.ContentID() != "":
+			inlines = append(inlines, part)
+		case !text:
+			attachments = append(attachments, part)
+		}
+	}
+	classify(b, false)
+	return
+}
@@ -0,0 +1,188 @@
+package rfc5322_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aethiopicuschan/rfc5322-go"
+	"github.com/stretchr/testify/assert"
+)
+
+const walkMessage = "Content-Type: multipart/mixed; boundary=outer\r\n" +
+	"\r\n" +
+	"--outer\r\n" +
+	"Content-Type: multipart/alternative; boundary=alt\r\n" +
+	"\r\n" +
+	"--alt\r\n" +
+	"Content-Type: text/plain; charset=UTF-8\r\n" +
+	"\r\n" +
+	"Hello\r\n" +
+	"--alt\r\n" +
+	"Content-Type: multipart/related; boundary=rel; type=\"text/html\"\r\n" +
+	"\r\n" +
+	"--rel\r\n" +
+	"Content-Type: text/html; charset=UTF-8\r\n" +
+	"\r\n" +
+	"<p>Hello</p><img src=\"cid:logo@example.com\">\r\n" +
+	"--rel\r\n" +
+	"Content-Type: image/png\r\n" +
+	"Content-ID: <logo@example.com>\r\n" +
+	"Content-Transfer-Encoding: base64\r\n" +
+	"\r\n" +
+	"iVBORw0KGgo=\r\n" +
+	"--rel--\r\n" +
+	"--alt--\r\n" +
+	"--outer\r\n" +
+	"Content-Type: text/plain; name=\"notes.txt\"\r\n" +
+	"Content-Disposition: attachment; filename=\"notes.txt\"\r\n" +
+	"\r\n" +
+	"Notes\r\n" +
+	"--outer\r\n" +
+	"Content-Type: application/pdf; name=\"=?UTF-8?B?5pel5pys6KqeLnBkZg==?=\"\r\n" +
+	"Content-Transfer-Encoding: base64\r\n" +
+	"\r\n" +
+	"JVBERi0=\r\n" +
+	"--outer--\r\n"
+
+func TestWalk(t *testing.T) {
+	b, err := rfc5322.ParseBody(strings.NewReader(walkMessage))
+	assert.NoError(t, err)
+
+	var paths, types []string
+	for path, part := range b.Walk() {
+		paths = append(paths, path.String())
+		types = append(types, part.ContentType())
+	}
+	assert.Equal(t, []string{"", "1", "1.1", "1.2", "1.2.1", "1.2.2", "2", "3"}, paths)
+	assert.Equal(t, []string{
+		"multipart/mixed", "multipart/alternative", "text/plain", "multipart/related",
+		"text/html", "image/png", "text/plain", "application/pdf",
+	}, types)
+
+	for path, part := range b.Walk() {
+		found, ok := b.Part(path)
+		assert.True(t, ok)
+		assert.Same(t, part, found)
+	}
+	_, ok := b.Part(rfc5322.BodyPath{0, 5})
+	assert.False(t, ok)
+
+	count := 0
+	for range b.Walk() {
+		if count++; count == 3 {
+			break
+		}
+	}
+	assert.Equal(t, 3, count)
+}
+
+func TestFindAll(t *testing.T) {
+	b, err := rfc5322.ParseBody(strings.NewReader(walkMessage))
+	assert.NoError(t, err)
+
+	testCases := []struct {
+		name      string
+		mediaType string
+		expected  int
+	}{
+		{"Exact", "text/plain", 2},
+		{"CaseInsensitive", "TEXT/HTML", 1},
+		{"Wildcard", "multipart/*", 3},
+		{"None", "video/mp4", 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Len(t, b.FindAll(tc.mediaType), tc.expected)
+		})
+	}
+}
+
+func TestTextBodyAndHTMLBody(t *testing.T) {
+	testCases := []struct {
+		name    string
+		message string
+		text    string
+		html    string
+	}{
+		{"Nested", walkMessage, "Hello", "<p>Hello</p><img src=\"cid:logo@example.com\">"},
+		{
+			"AlternativePrefersLast",
+			"Content-Type: multipart/alternative; boundary=alt\r\n\r\n" +
+				"--alt\r\nContent-Type: text/plain\r\n\r\nFirst\r\n" +
+				"--alt\r\nContent-Type: text/plain\r\n\r\nLast\r\n" +
+				"--alt--\r\n",
+			"Last", "",
+		},
+		{
+			"RelatedStart",
+			"Content-Type: multipart/related; boundary=rel; start=\"<root@example.com>\"\r\n\r\n" +
+				"--rel\r\nContent-Type: text/html\r\n\r\n<p>Resource</p>\r\n" +
+				"--rel\r\nContent-Type: text/html\r\nContent-ID: <root@example.com>\r\n\r\n<p>Root</p>\r\n" +
+				"--rel--\r\n",
+			"", "<p>Root</p>",
+		},
+		{
+			"AttachmentOnly",
+			"Content-Type: multipart/mixed; boundary=b\r\n\r\n" +
+				"--b\r\nContent-Type: text/plain\r\nContent-Disposition: attachment\r\n\r\nFile\r\n" +
+				"--b--\r\n",
+			"", "",
+		},
+		{"SinglePart", "Content-Type: text/plain\r\n\r\nSingle", "Single", ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			b, err := rfc5322.ParseBody(strings.NewReader(tc.message))
+			assert.NoError(t, err)
+
+			text, ok := b.TextBody()
+			assert.Equal(t, tc.text != "", ok)
+			if ok {
+				assert.Equal(t, tc.text, string(text.Content()))
+			}
+			html, ok := b.HTMLBody()
+			assert.Equal(t, tc.html != "", ok)
+			if ok {
+				assert.Equal(t, tc.html, string(html.Content()))
+			}
+		})
+	}
+}
+
+func TestAttachmentsAndInlines(t *testing.T) {
+	b, err := rfc5322.ParseBody(strings.NewReader(walkMessage))
+	assert.NoError(t, err)
+
+	attachments := b.Attachments()
+	if assert.Len(t, attachments, 2) {
+		assert.Equal(t, "notes.txt", attachments[0].Filename())
+		assert.Equal(t, "attachment", attachments[0].Disposition())
+		assert.Equal(t, "日本語.pdf", attachments[1].Filename())
+		assert.Equal(t, "", attachments[1].Disposition())
+		content, err := attachments[1].DecodedContent()
+		assert.NoError(t, err)
+		assert.Equal(t, "%PDF-", string(content))
+	}
+
+	inlines := b.Inlines()
+	if assert.Len(t, inlines, 1) {
+		assert.Equal(t, "logo@example.com", inlines[0].ContentID())
+		assert.Equal(t, "image/png", inlines[0].ContentType())
+	}
+}
+
+func TestAttachmentsSigned(t *testing.T) {
+	b, err := rfc5322.ParseBody(strings.NewReader(signedMessage))
+	assert.NoError(t, err)
+
+	// The signature of multipart/signed is not an attachment.
+	attachments := b.Attachments()
+	if assert.Len(t, attachments, 1) {
+		assert.Equal(t, "application/x-msdownload", attachments[0].ContentType())
+	}
+	assert.Empty(t, b.Inlines())
+}